@@ -0,0 +1,44 @@
+// Package dateutil menyediakan helper tanggal kalender (YYYY-MM-DD) yang dipakai bersama
+// oleh modul-modul yang menyimpan tanggal sebagai string atau kolom DATE.
+package dateutil
+
+import (
+	"math"
+	"time"
+)
+
+// Layout - Format tanggal YYYY-MM-DD untuk input dan output
+const Layout = "2006-01-02"
+
+// Parse membaca tanggal dari string YYYY-MM-DD atau RFC3339 (kolom DATE saat parseTime=true)
+func Parse(value string) (time.Time, bool) {
+	if len(value) < len(Layout) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(Layout, value[:len(Layout)])
+	return t, err == nil
+}
+
+// Format menormalkan nilai tanggal menjadi YYYY-MM-DD; nilai yang tidak terbaca dikembalikan apa adanya
+func Format(value string) string {
+	if t, ok := Parse(value); ok {
+		return t.Format(Layout)
+	}
+	return value
+}
+
+// Today mengembalikan tanggal hari ini tanpa komponen jam
+func Today() time.Time {
+	t, _ := time.Parse(Layout, time.Now().Format(Layout))
+	return t
+}
+
+// DateOnly membuang komponen jam sehingga bisa dibandingkan dengan tanggal hasil Parse
+func DateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DaysBetween menghitung selisih hari kalender dari a ke b
+func DaysBetween(a, b time.Time) int {
+	return int(math.Round(b.Sub(a).Hours() / 24))
+}
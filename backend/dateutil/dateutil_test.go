@@ -0,0 +1,66 @@
+package dateutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"2024-03-05", "2024-03-05", true},
+		{"2024-03-05T00:00:00Z", "2024-03-05", true},
+		{"2024-03-05 08:30:00", "2024-03-05", true},
+		{"2024-3-5", "", false},
+		{"", "", false},
+		{"05-03-2024", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.value)
+		if ok != tt.wantOK || (ok && got.Format(Layout) != tt.want) {
+			t.Errorf("Parse(%q) = %v, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2024-03-05", "2024-03-05", 0},
+		{"2024-03-05", "2024-03-06", 1},
+		{"2024-03-06", "2024-03-05", -1},
+		{"2024-02-28", "2024-03-01", 2},
+		{"2024-01-01", "2025-01-01", 366},
+	}
+	for _, tt := range tests {
+		a, _ := time.Parse(Layout, tt.a)
+		b, _ := time.Parse(Layout, tt.b)
+		if got := DaysBetween(a, b); got != tt.want {
+			t.Errorf("DaysBetween(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDateOnly(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), "2024-03-05"},
+		{time.Date(2024, 3, 5, 23, 59, 59, 0, time.UTC), "2024-03-05"},
+		{time.Date(2024, 3, 5, 6, 0, 0, 0, wib), "2024-03-05"},
+	}
+	for _, tt := range tests {
+		got := DateOnly(tt.in)
+		want, _ := time.Parse(Layout, tt.want)
+		if !got.Equal(want) {
+			t.Errorf("DateOnly(%v) = %v, want %v", tt.in, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package dberr mengenali error MySQL yang perlu dijawab selain 500, misalnya pelanggaran
+// unique key akibat dua request yang menyimpan data yang sama secara bersamaan.
+package dberr
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// erDupEntry - Kode error MySQL untuk pelanggaran UNIQUE/PRIMARY KEY
+const erDupEntry = 1062
+
+// IsDuplicateKey mengecek apakah err disebabkan oleh pelanggaran unique key
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDupEntry
+}
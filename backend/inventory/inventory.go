@@ -1,12 +1,16 @@
 package inventory
 
 import (
+	"errors"
 	"log" // Tambahkan import log untuk logging yang lebih baik
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 var db *gorm.DB
@@ -23,6 +27,8 @@ type Inventory struct {
 	// Perbaikan di sini:
 	// Tambahkan gorm:"column:itemCode" agar cocok dengan nama kolom di DB (camelCase)
 	ItemCode string `json:"itemCode" gorm:"column:itemCode"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
 func (Inventory) TableName() string {
@@ -31,6 +37,7 @@ func (Inventory) TableName() string {
 
 func Init(dbInstance *gorm.DB) {
 	db = dbInstance
+	trash.Register(trash.Purger{Module: "inventory", Model: &Inventory{}, Purge: purgeInventory})
 	log.Println("Inventory module initialized.") // Log inisialisasi
 	// Jika skema DB sudah fix dari atasan, jangan gunakan AutoMigrate.
 	// Jika belum ada tabel, dan Anda ingin GORM membuatnya, uncomment baris ini:
@@ -147,7 +154,71 @@ func updateInventory(c *gin.Context) {
 	c.JSON(http.StatusOK, item)
 }
 
-// Handler DELETE /api/inventory/:id
+// Handler PATCH /api/inventory/:id (JSON Merge Patch, RFC 7396)
+// Hanya field yang dikirim yang divalidasi dan disimpan.
+func patchInventory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		log.Printf("Invalid ID for patchInventory: %s", idStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var item Inventory
+	if err := db.First(&item, id).Error; err != nil {
+		log.Printf("Inventory item with ID %d not found for patch: %v", id, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item tidak ditemukan"})
+		return
+	}
+
+	var merged Inventory
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	merged.ID = item.ID
+
+	blank := patch.Blank(map[string]string{
+		"name": merged.Name, "location": merged.Location, "status": merged.Status, "itemCode": merged.ItemCode,
+	})
+	if len(blank) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field wajib tidak boleh kosong", "fields": blank})
+		return
+	}
+	if patch.Has("quantity") && merged.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity tidak boleh negatif"})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &Inventory{}, "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		if err := db.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+			log.Printf("Error patching inventory item with ID %d in DB: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update data", "details": err.Error()})
+			return
+		}
+	}
+	db.First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
+// Handler DELETE /api/inventory/:id (soft delete, item dipindahkan ke trash)
 func deleteInventory(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -181,16 +252,61 @@ func deleteInventory(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// purgeInventory menghapus permanen item inventory. Dampak ECR tetap tercatat lewat item_name,
+// hanya referensinya yang dilepas. Item yang masih dipakai part overhaul (atau modul lain) gagal
+// karena foreign key sehingga dilewati purge dan tetap berada di trash.
+func purgeInventory(tx *gorm.DB, id int) error {
+	if err := tx.Table("rekayasa_ecr_impact").Where("inventory_id = ?", id).Update("inventory_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Inventory{}, id).Error
+}
+
+// Handler GET /api/inventory/trash
+func getInventoryTrash(c *gin.Context) {
+	items := []Inventory{}
+	if err := trash.List(db, &items); err != nil {
+		log.Printf("Error fetching deleted inventory items: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash inventory", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// Handler POST /api/inventory/:id/restore
+func restoreInventory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &Inventory{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item tidak ditemukan di trash"})
+		} else {
+			log.Printf("Error restoring inventory item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan data", "details": err.Error()})
+		}
+		return
+	}
+	var item Inventory
+	db.First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
 // Register ke router Gin
 func RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("", getAllInventory)
 	r.GET("/", getAllInventory)
 
+	r.GET("/trash", getInventoryTrash)
 	r.GET("/:id", getInventoryByID)
 
 	r.POST("", createInventory)
 	r.POST("/", createInventory)
 
 	r.PUT("/:id", updateInventory)
+	r.PATCH("/:id", patchInventory)
 	r.DELETE("/:id", deleteInventory)
+	r.POST("/:id/restore", restoreInventory)
 }
@@ -23,6 +23,43 @@ SET time_zone = "+00:00";
 
 -- --------------------------------------------------------
 
+--
+-- Table structure for table `asset`
+--
+
+CREATE TABLE `asset` (
+  `asset_id` int(11) NOT NULL,
+  `asset_code` varchar(50) DEFAULT NULL,
+  `name` varchar(100) DEFAULT NULL,
+  `equipment_type` varchar(50) DEFAULT NULL,
+  `serial_number` varchar(100) DEFAULT NULL,
+  `location` varchar(100) DEFAULT NULL,
+  `installed_date` date DEFAULT NULL,
+  `operating_hours` decimal(12,1) DEFAULT 0.0,
+  `active` tinyint(1) DEFAULT 1,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `asset_maintenance_interval`
+--
+
+CREATE TABLE `asset_maintenance_interval` (
+  `interval_id` int(11) NOT NULL,
+  `asset_id` int(11) DEFAULT NULL,
+  `name` varchar(100) DEFAULT NULL,
+  `interval_days` int(11) DEFAULT NULL,
+  `interval_hours` decimal(12,1) DEFAULT NULL,
+  `last_completed_at` datetime DEFAULT NULL,
+  `last_completed_hours` decimal(12,1) DEFAULT NULL,
+  `last_overhaul_id` int(11) DEFAULT NULL,
+  `active` tinyint(1) DEFAULT 1
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Table structure for table `calibration`
 --
@@ -34,7 +71,118 @@ CREATE TABLE `calibration` (
   `progress_step` int(11) DEFAULT NULL,
   `due_date` date DEFAULT NULL,
   `last_update` datetime DEFAULT NULL,
-  `inventory_id` int(11) DEFAULT NULL
+  `inventory_id` int(11) DEFAULT NULL,
+  `completed_at` datetime DEFAULT NULL,
+  `next_calibration_id` int(11) DEFAULT NULL,
+  `technician_id` int(11) DEFAULT NULL,
+  `approver_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `calibration_certificate`
+--
+
+CREATE TABLE `calibration_certificate` (
+  `certificate_id` int(11) NOT NULL,
+  `calibration_id` int(11) DEFAULT NULL,
+  `year` int(11) DEFAULT NULL,
+  `sequence` int(11) DEFAULT NULL,
+  `certificate_number` varchar(50) DEFAULT NULL,
+  `issued_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `calibration_interval`
+--
+
+CREATE TABLE `calibration_interval` (
+  `interval_id` int(11) NOT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `tool_name` varchar(255) DEFAULT NULL,
+  `interval_months` int(11) DEFAULT NULL,
+  `active` tinyint(1) DEFAULT 1,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `calibration_measurement`
+--
+
+CREATE TABLE `calibration_measurement` (
+  `measurement_id` int(11) NOT NULL,
+  `calibration_id` int(11) DEFAULT NULL,
+  `sequence` int(11) DEFAULT NULL,
+  `parameter` varchar(100) DEFAULT NULL,
+  `unit` varchar(20) DEFAULT NULL,
+  `nominal_value` decimal(18,6) DEFAULT NULL,
+  `tolerance` decimal(18,6) DEFAULT NULL,
+  `reading_before` decimal(18,6) DEFAULT NULL,
+  `reading_after` decimal(18,6) DEFAULT NULL,
+  `uncertainty` decimal(18,6) DEFAULT NULL,
+  `coverage_factor` decimal(6,2) DEFAULT NULL,
+  `standard_inventory_id` int(11) DEFAULT NULL,
+  `standard_name` varchar(255) DEFAULT NULL,
+  `result_before` varchar(10) DEFAULT NULL,
+  `result` varchar(10) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `customer_order`
+--
+
+CREATE TABLE `customer_order` (
+  `order_id` int(11) NOT NULL,
+  `order_number` varchar(50) DEFAULT NULL,
+  `customer` varchar(100) DEFAULT NULL,
+  `region` varchar(100) DEFAULT NULL,
+  `requested_date` varchar(50) DEFAULT NULL,
+  `priority` varchar(20) DEFAULT NULL,
+  `status` varchar(50) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `customer_order_line`
+--
+
+CREATE TABLE `customer_order_line` (
+  `order_line_id` int(11) NOT NULL,
+  `order_id` int(11) DEFAULT NULL,
+  `product` varchar(255) DEFAULT NULL,
+  `quantity` int(11) DEFAULT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `customer_shipment`
+--
+
+CREATE TABLE `customer_shipment` (
+  `shipment_id` int(11) NOT NULL,
+  `order_line_id` int(11) DEFAULT NULL,
+  `stock_id` int(11) DEFAULT NULL,
+  `quantity` int(11) DEFAULT NULL,
+  `shipped_date` varchar(50) DEFAULT NULL,
+  `reference` varchar(100) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -86,7 +234,8 @@ CREATE TABLE `inventory` (
   `quantity` int(11) DEFAULT NULL,
   `location` varchar(100) DEFAULT NULL,
   `status` varchar(100) DEFAULT NULL,
-  `itemCode` varchar(100) DEFAULT NULL
+  `itemCode` varchar(100) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -99,8 +248,9 @@ CREATE TABLE `materials` (
   `materials_id` int(11) NOT NULL,
   `materials_name` varchar(100) DEFAULT NULL,
   `qty` int(11) DEFAULT NULL,
-  `price` int(11) DEFAULT NULL,
-  `satuan` int(11) DEFAULT NULL
+  `price` decimal(15,2) DEFAULT NULL,
+  `satuan` varchar(50) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -112,14 +262,182 @@ CREATE TABLE `materials` (
 CREATE TABLE `overhaul` (
   `overhaul_id` int(11) NOT NULL,
   `name` varchar(100) DEFAULT NULL,
+  `equipment_type` varchar(50) DEFAULT NULL,
   `location` varchar(100) DEFAULT NULL,
   `status` varchar(100) DEFAULT NULL,
-  `estimate` varchar(50) DEFAULT NULL,
+  `estimate` datetime DEFAULT NULL,
+  `started_at` datetime DEFAULT NULL,
+  `completed_at` datetime DEFAULT NULL,
+  `status_changed_at` datetime DEFAULT NULL,
   `progress` int(11) DEFAULT NULL,
   `personalia_id` int(11) DEFAULT NULL,
   `materials_id` int(11) DEFAULT NULL,
   `history_id` int(11) DEFAULT NULL,
-  `inventory_id` int(11) DEFAULT NULL
+  `inventory_id` int(11) DEFAULT NULL,
+  `asset_id` int(11) DEFAULT NULL,
+  `interval_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_checklist_template`
+--
+
+CREATE TABLE `overhaul_checklist_template` (
+  `template_id` int(11) NOT NULL,
+  `equipment_type` varchar(50) DEFAULT NULL,
+  `name` varchar(100) DEFAULT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `active` tinyint(1) DEFAULT 1,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_checklist_template_step`
+--
+
+CREATE TABLE `overhaul_checklist_template_step` (
+  `template_step_id` int(11) NOT NULL,
+  `template_id` int(11) DEFAULT NULL,
+  `sequence` int(11) DEFAULT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `requires_measurement` tinyint(1) DEFAULT 0,
+  `unit` varchar(20) DEFAULT NULL,
+  `min_value` decimal(12,3) DEFAULT NULL,
+  `max_value` decimal(12,3) DEFAULT NULL,
+  `requires_photo` tinyint(1) DEFAULT 0
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_crew`
+--
+
+CREATE TABLE `overhaul_crew` (
+  `overhaul_crew_id` int(11) NOT NULL,
+  `overhaul_id` int(11) DEFAULT NULL,
+  `personalia_id` int(11) DEFAULT NULL,
+  `role` varchar(20) DEFAULT NULL,
+  `assigned_hours` decimal(8,2) DEFAULT 0.00
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_job_card`
+--
+
+CREATE TABLE `overhaul_job_card` (
+  `job_card_id` int(11) NOT NULL,
+  `overhaul_id` int(11) DEFAULT NULL,
+  `template_id` int(11) DEFAULT NULL,
+  `title` varchar(100) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_job_card_step`
+--
+
+CREATE TABLE `overhaul_job_card_step` (
+  `step_id` int(11) NOT NULL,
+  `job_card_id` int(11) DEFAULT NULL,
+  `sequence` int(11) DEFAULT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `requires_measurement` tinyint(1) DEFAULT 0,
+  `unit` varchar(20) DEFAULT NULL,
+  `min_value` decimal(12,3) DEFAULT NULL,
+  `max_value` decimal(12,3) DEFAULT NULL,
+  `requires_photo` tinyint(1) DEFAULT 0,
+  `measured_value` decimal(12,3) DEFAULT NULL,
+  `within_limits` tinyint(1) DEFAULT NULL,
+  `signed_off_by` int(11) DEFAULT NULL,
+  `signed_off_at` datetime DEFAULT NULL,
+  `remarks` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_part`
+--
+
+CREATE TABLE `overhaul_part` (
+  `part_id` int(11) NOT NULL,
+  `overhaul_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `item_name` varchar(100) DEFAULT NULL,
+  `item_code` varchar(100) DEFAULT NULL,
+  `planned_qty` int(11) DEFAULT 0,
+  `fitted_qty` int(11) DEFAULT 0,
+  `removed_qty` int(11) DEFAULT 0,
+  `notes` varchar(255) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_part_movement`
+--
+
+CREATE TABLE `overhaul_part_movement` (
+  `movement_id` int(11) NOT NULL,
+  `part_id` int(11) DEFAULT NULL,
+  `overhaul_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `movement_type` varchar(20) DEFAULT NULL,
+  `qty` int(11) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_sla_target`
+--
+
+CREATE TABLE `overhaul_sla_target` (
+  `sla_target_id` int(11) NOT NULL,
+  `equipment_type` varchar(50) DEFAULT NULL,
+  `target_days` int(11) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_status_history`
+--
+
+CREATE TABLE `overhaul_status_history` (
+  `status_history_id` int(11) NOT NULL,
+  `overhaul_id` int(11) DEFAULT NULL,
+  `from_status` varchar(100) DEFAULT NULL,
+  `to_status` varchar(100) DEFAULT NULL,
+  `changed_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `overhaul_step_photo`
+--
+
+CREATE TABLE `overhaul_step_photo` (
+  `photo_id` int(11) NOT NULL,
+  `step_id` int(11) DEFAULT NULL,
+  `file_name` varchar(255) DEFAULT NULL,
+  `stored_path` varchar(500) DEFAULT NULL,
+  `content_type` varchar(100) DEFAULT NULL,
+  `size` bigint(20) DEFAULT NULL,
+  `uploaded_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -138,7 +456,9 @@ CREATE TABLE `personalia` (
   `join_date` date DEFAULT NULL,
   `phone_number` varchar(50) DEFAULT NULL,
   `urgent_number` varchar(50) DEFAULT NULL,
-  `profile_id` int(11) DEFAULT NULL
+  `hourly_rate` decimal(15,2) DEFAULT NULL,
+  `profile_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 --
@@ -163,12 +483,15 @@ CREATE TABLE `produksi` (
   `status` varchar(100) DEFAULT NULL,
   `start_date` varchar(50) DEFAULT NULL,
   `end_date` varchar(50) DEFAULT NULL,
+  `budget` decimal(15,2) DEFAULT NULL,
   `materials_id` int(11) DEFAULT NULL,
   `progress_id` int(11) DEFAULT NULL,
   `inventory_id` int(11) DEFAULT NULL,
   `personnel_data` text DEFAULT NULL,
   `materials_data` text DEFAULT NULL,
-  `progress_data` text DEFAULT NULL
+  `progress_data` text DEFAULT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 --
@@ -180,6 +503,55 @@ INSERT INTO `produksi` (`produksi_id`, `name`, `target`, `completed`, `status`,
 
 -- --------------------------------------------------------
 
+--
+-- Table structure for table `produksi_consumption`
+--
+
+CREATE TABLE `produksi_consumption` (
+  `consumption_id` int(11) NOT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `produksi_materials_id` int(11) DEFAULT NULL,
+  `progress_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `qty` int(11) DEFAULT NULL,
+  `shortage` int(11) DEFAULT NULL,
+  `unit_price` decimal(15,2) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `produksi_labour`
+--
+
+CREATE TABLE `produksi_labour` (
+  `labour_id` int(11) NOT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `personalia_id` int(11) DEFAULT NULL,
+  `date` date DEFAULT NULL,
+  `hours` decimal(6,2) DEFAULT NULL,
+  `rate` decimal(15,2) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `produksi_materials`
+--
+
+CREATE TABLE `produksi_materials` (
+  `produksi_materials_id` int(11) NOT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `materials_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `qty_per_unit` decimal(12,3) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Table structure for table `produksi_team`
 --
@@ -202,7 +574,8 @@ CREATE TABLE `profile` (
   `address` longtext DEFAULT NULL,
   `phone_number` longtext DEFAULT NULL,
   `education_id` int(11) DEFAULT NULL,
-  `experience_id` int(11) DEFAULT NULL
+  `experience_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -213,9 +586,10 @@ CREATE TABLE `profile` (
 
 CREATE TABLE `progress` (
   `progress_id` int(11) NOT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
   `date` varchar(100) DEFAULT NULL,
   `completed` int(11) DEFAULT NULL,
-  `notes` varchar(50) DEFAULT NULL
+  `notes` varchar(255) DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -236,7 +610,8 @@ CREATE TABLE `quality_control` (
   `produksi_id` int(11) DEFAULT NULL,
   `overhaul_id` int(11) DEFAULT NULL,
   `rekayasa_id` int(11) DEFAULT NULL,
-  `inventory_id` int(11) DEFAULT NULL
+  `inventory_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
@@ -250,89 +625,391 @@ CREATE TABLE `rekayasa` (
   `name` varchar(100) DEFAULT NULL,
   `status` varchar(100) DEFAULT NULL,
   `team` text DEFAULT NULL,
+  `start_date` date DEFAULT NULL,
   `deadline` date DEFAULT NULL,
-  `progress` varchar(100) DEFAULT NULL
+  `progress` varchar(100) DEFAULT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
 
 --
--- Table structure for table `rekayasa_team`
+-- Table structure for table `rekayasa_document`
 --
 
-CREATE TABLE `rekayasa_team` (
-  `rekayasa_team_id` int(11) NOT NULL,
-  `personalia_id` int(11) DEFAULT NULL,
-  `rekayasa_id` int(11) DEFAULT NULL
+CREATE TABLE `rekayasa_document` (
+  `document_id` int(11) NOT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `doc_number` varchar(50) DEFAULT NULL,
+  `title` varchar(255) DEFAULT NULL,
+  `doc_type` varchar(50) DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 -- --------------------------------------------------------
 
 --
--- Table structure for table `stock_production`
+-- Table structure for table `rekayasa_document_revision`
 --
 
-CREATE TABLE `stock_production` (
-  `stock_id` int(11) NOT NULL,
-  `item_name` varchar(255) DEFAULT NULL,
-  `quantity` int(11) DEFAULT NULL,
-  `location` varchar(100) DEFAULT NULL,
-  `status` varchar(50) DEFAULT NULL,
-  `last_update` datetime DEFAULT NULL,
-  `inventory_id` int(11) DEFAULT NULL,
-  `produksi_id` int(11) DEFAULT NULL
+CREATE TABLE `rekayasa_document_revision` (
+  `revision_id` int(11) NOT NULL,
+  `document_id` int(11) DEFAULT NULL,
+  `revision` varchar(5) DEFAULT NULL,
+  `status` varchar(20) DEFAULT NULL,
+  `file_name` varchar(255) DEFAULT NULL,
+  `stored_path` varchar(500) DEFAULT NULL,
+  `content_type` varchar(100) DEFAULT NULL,
+  `size` bigint(20) DEFAULT NULL,
+  `checksum` varchar(64) DEFAULT NULL,
+  `uploaded_by` int(11) DEFAULT NULL,
+  `uploaded_at` datetime DEFAULT NULL,
+  `released_at` datetime DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
+-- --------------------------------------------------------
+
 --
--- Indexes for dumped tables
+-- Table structure for table `rekayasa_ecr`
 --
 
+CREATE TABLE `rekayasa_ecr` (
+  `ecr_id` int(11) NOT NULL,
+  `ecr_number` varchar(20) DEFAULT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `title` varchar(255) DEFAULT NULL,
+  `description` text DEFAULT NULL,
+  `reason` text DEFAULT NULL,
+  `status` varchar(20) DEFAULT NULL,
+  `requested_by` int(11) DEFAULT NULL,
+  `submitted_at` datetime DEFAULT NULL,
+  `decided_at` datetime DEFAULT NULL,
+  `implemented_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
 --
--- Indexes for table `calibration`
+-- Table structure for table `rekayasa_ecr_comment`
 --
-ALTER TABLE `calibration`
-  ADD PRIMARY KEY (`calibration_id`),
-  ADD KEY `inventory_id` (`inventory_id`);
+
+CREATE TABLE `rekayasa_ecr_comment` (
+  `comment_id` int(11) NOT NULL,
+  `ecr_id` int(11) DEFAULT NULL,
+  `personalia_id` int(11) DEFAULT NULL,
+  `body` text DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `education`
+-- Table structure for table `rekayasa_ecr_impact`
 --
-ALTER TABLE `education`
-  ADD PRIMARY KEY (`education_id`);
+
+CREATE TABLE `rekayasa_ecr_impact` (
+  `impact_id` int(11) NOT NULL,
+  `ecr_id` int(11) DEFAULT NULL,
+  `impact_type` varchar(20) DEFAULT NULL,
+  `item_name` varchar(100) DEFAULT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `description` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `experience`
+-- Table structure for table `rekayasa_ecr_reviewer`
 --
-ALTER TABLE `experience`
-  ADD PRIMARY KEY (`experience_id`);
+
+CREATE TABLE `rekayasa_ecr_reviewer` (
+  `ecr_reviewer_id` int(11) NOT NULL,
+  `ecr_id` int(11) DEFAULT NULL,
+  `personalia_id` int(11) DEFAULT NULL,
+  `decision` varchar(20) DEFAULT NULL,
+  `remarks` varchar(255) DEFAULT NULL,
+  `decided_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `history`
+-- Table structure for table `rekayasa_material`
 --
-ALTER TABLE `history`
-  ADD PRIMARY KEY (`history_id`);
+
+CREATE TABLE `rekayasa_material` (
+  `rekayasa_material_id` int(11) NOT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `materials_id` int(11) DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `qty_per_unit` decimal(12,3) DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `inventory`
+-- Table structure for table `rekayasa_milestone`
 --
-ALTER TABLE `inventory`
-  ADD PRIMARY KEY (`inventory_id`);
+
+CREATE TABLE `rekayasa_milestone` (
+  `milestone_id` int(11) NOT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `name` varchar(100) DEFAULT NULL,
+  `due_date` varchar(50) DEFAULT NULL,
+  `sort_order` int(11) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `materials`
+-- Table structure for table `rekayasa_task`
 --
-ALTER TABLE `materials`
-  ADD PRIMARY KEY (`materials_id`);
+
+CREATE TABLE `rekayasa_task` (
+  `task_id` int(11) NOT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `milestone_id` int(11) DEFAULT NULL,
+  `name` varchar(255) DEFAULT NULL,
+  `owner_id` int(11) DEFAULT NULL,
+  `due_date` varchar(50) DEFAULT NULL,
+  `weight` decimal(6,2) DEFAULT NULL,
+  `status` varchar(50) DEFAULT NULL,
+  `completed_at` datetime DEFAULT NULL,
+  `notes` varchar(255) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
 
 --
--- Indexes for table `overhaul`
+-- Table structure for table `rekayasa_team`
 --
-ALTER TABLE `overhaul`
+
+CREATE TABLE `rekayasa_team` (
+  `rekayasa_team_id` int(11) NOT NULL,
+  `personalia_id` int(11) DEFAULT NULL,
+  `rekayasa_id` int(11) DEFAULT NULL,
+  `role` varchar(20) DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Table structure for table `stock_production`
+--
+
+CREATE TABLE `stock_production` (
+  `stock_id` int(11) NOT NULL,
+  `item_name` varchar(255) DEFAULT NULL,
+  `quantity` int(11) DEFAULT NULL,
+  `location` varchar(100) DEFAULT NULL,
+  `status` varchar(50) DEFAULT NULL,
+  `last_update` datetime DEFAULT NULL,
+  `inventory_id` int(11) DEFAULT NULL,
+  `produksi_id` int(11) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+--
+-- Indexes for dumped tables
+--
+
+--
+-- Indexes for table `asset`
+--
+ALTER TABLE `asset`
+  ADD PRIMARY KEY (`asset_id`),
+  ADD UNIQUE KEY `asset_code` (`asset_code`),
+  ADD UNIQUE KEY `serial_number` (`serial_number`),
+  ADD KEY `equipment_type` (`equipment_type`);
+
+--
+-- Indexes for table `asset_maintenance_interval`
+--
+ALTER TABLE `asset_maintenance_interval`
+  ADD PRIMARY KEY (`interval_id`),
+  ADD KEY `asset_id` (`asset_id`),
+  ADD KEY `last_overhaul_id` (`last_overhaul_id`);
+
+--
+-- Indexes for table `calibration`
+--
+ALTER TABLE `calibration`
+  ADD PRIMARY KEY (`calibration_id`),
+  ADD KEY `inventory_id` (`inventory_id`),
+  ADD KEY `next_calibration_id` (`next_calibration_id`),
+  ADD KEY `technician_id` (`technician_id`),
+  ADD KEY `approver_id` (`approver_id`);
+
+--
+-- Indexes for table `calibration_certificate`
+--
+ALTER TABLE `calibration_certificate`
+  ADD PRIMARY KEY (`certificate_id`),
+  ADD UNIQUE KEY `calibration_id` (`calibration_id`),
+  ADD UNIQUE KEY `certificate_number` (`certificate_number`),
+  ADD UNIQUE KEY `year_sequence` (`year`,`sequence`);
+
+--
+-- Indexes for table `calibration_interval`
+--
+ALTER TABLE `calibration_interval`
+  ADD PRIMARY KEY (`interval_id`),
+  ADD KEY `tool_name` (`tool_name`),
+  ADD UNIQUE KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `calibration_measurement`
+--
+ALTER TABLE `calibration_measurement`
+  ADD PRIMARY KEY (`measurement_id`),
+  ADD KEY `calibration_id` (`calibration_id`),
+  ADD KEY `standard_inventory_id` (`standard_inventory_id`);
+
+--
+-- Indexes for table `customer_order`
+--
+ALTER TABLE `customer_order`
+  ADD PRIMARY KEY (`order_id`),
+  ADD UNIQUE KEY `order_number` (`order_number`);
+
+--
+-- Indexes for table `customer_order_line`
+--
+ALTER TABLE `customer_order_line`
+  ADD PRIMARY KEY (`order_line_id`),
+  ADD KEY `order_id` (`order_id`),
+  ADD KEY `produksi_id` (`produksi_id`);
+
+--
+-- Indexes for table `customer_shipment`
+--
+ALTER TABLE `customer_shipment`
+  ADD PRIMARY KEY (`shipment_id`),
+  ADD KEY `order_line_id` (`order_line_id`),
+  ADD KEY `stock_id` (`stock_id`);
+
+--
+-- Indexes for table `education`
+--
+ALTER TABLE `education`
+  ADD PRIMARY KEY (`education_id`);
+
+--
+-- Indexes for table `experience`
+--
+ALTER TABLE `experience`
+  ADD PRIMARY KEY (`experience_id`);
+
+--
+-- Indexes for table `history`
+--
+ALTER TABLE `history`
+  ADD PRIMARY KEY (`history_id`);
+
+--
+-- Indexes for table `inventory`
+--
+ALTER TABLE `inventory`
+  ADD PRIMARY KEY (`inventory_id`);
+
+--
+-- Indexes for table `materials`
+--
+ALTER TABLE `materials`
+  ADD PRIMARY KEY (`materials_id`);
+
+--
+-- Indexes for table `overhaul`
+--
+ALTER TABLE `overhaul`
   ADD PRIMARY KEY (`overhaul_id`),
   ADD KEY `personalia_id` (`personalia_id`),
   ADD KEY `materials_id` (`materials_id`),
   ADD KEY `inventory_id` (`inventory_id`),
-  ADD KEY `history_id` (`history_id`);
+  ADD KEY `history_id` (`history_id`),
+  ADD KEY `asset_id` (`asset_id`),
+  ADD KEY `interval_id` (`interval_id`);
+
+--
+-- Indexes for table `overhaul_checklist_template`
+--
+ALTER TABLE `overhaul_checklist_template`
+  ADD PRIMARY KEY (`template_id`),
+  ADD KEY `equipment_type` (`equipment_type`);
+
+--
+-- Indexes for table `overhaul_checklist_template_step`
+--
+ALTER TABLE `overhaul_checklist_template_step`
+  ADD PRIMARY KEY (`template_step_id`),
+  ADD KEY `template_id` (`template_id`);
+
+--
+-- Indexes for table `overhaul_crew`
+--
+ALTER TABLE `overhaul_crew`
+  ADD PRIMARY KEY (`overhaul_crew_id`),
+  ADD UNIQUE KEY `overhaul_personalia` (`overhaul_id`,`personalia_id`),
+  ADD KEY `personalia_id` (`personalia_id`),
+  ADD KEY `overhaul_id` (`overhaul_id`);
+
+--
+-- Indexes for table `overhaul_job_card`
+--
+ALTER TABLE `overhaul_job_card`
+  ADD PRIMARY KEY (`job_card_id`),
+  ADD KEY `overhaul_id` (`overhaul_id`),
+  ADD KEY `template_id` (`template_id`);
+
+--
+-- Indexes for table `overhaul_job_card_step`
+--
+ALTER TABLE `overhaul_job_card_step`
+  ADD PRIMARY KEY (`step_id`),
+  ADD KEY `job_card_id` (`job_card_id`),
+  ADD KEY `signed_off_by` (`signed_off_by`);
+
+--
+-- Indexes for table `overhaul_part`
+--
+ALTER TABLE `overhaul_part`
+  ADD PRIMARY KEY (`part_id`),
+  ADD KEY `overhaul_id` (`overhaul_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `overhaul_part_movement`
+--
+ALTER TABLE `overhaul_part_movement`
+  ADD PRIMARY KEY (`movement_id`),
+  ADD KEY `part_id` (`part_id`),
+  ADD KEY `overhaul_id` (`overhaul_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `overhaul_sla_target`
+--
+ALTER TABLE `overhaul_sla_target`
+  ADD PRIMARY KEY (`sla_target_id`),
+  ADD UNIQUE KEY `equipment_type` (`equipment_type`);
+
+--
+-- Indexes for table `overhaul_status_history`
+--
+ALTER TABLE `overhaul_status_history`
+  ADD PRIMARY KEY (`status_history_id`),
+  ADD KEY `overhaul_id` (`overhaul_id`);
+
+--
+-- Indexes for table `overhaul_step_photo`
+--
+ALTER TABLE `overhaul_step_photo`
+  ADD PRIMARY KEY (`photo_id`),
+  ADD KEY `step_id` (`step_id`);
 
 --
 -- Indexes for table `personalia`
@@ -348,7 +1025,34 @@ ALTER TABLE `produksi`
   ADD PRIMARY KEY (`produksi_id`),
   ADD KEY `materials_id` (`materials_id`),
   ADD KEY `progress_id` (`progress_id`),
-  ADD KEY `fk_inventory_id` (`inventory_id`);
+  ADD KEY `fk_inventory_id` (`inventory_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`);
+
+--
+-- Indexes for table `produksi_consumption`
+--
+ALTER TABLE `produksi_consumption`
+  ADD PRIMARY KEY (`consumption_id`),
+  ADD KEY `produksi_id` (`produksi_id`),
+  ADD KEY `produksi_materials_id` (`produksi_materials_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `produksi_labour`
+--
+ALTER TABLE `produksi_labour`
+  ADD PRIMARY KEY (`labour_id`),
+  ADD KEY `produksi_id` (`produksi_id`),
+  ADD KEY `personalia_id` (`personalia_id`);
+
+--
+-- Indexes for table `produksi_materials`
+--
+ALTER TABLE `produksi_materials`
+  ADD PRIMARY KEY (`produksi_materials_id`),
+  ADD KEY `produksi_id` (`produksi_id`),
+  ADD KEY `materials_id` (`materials_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
 
 --
 -- Indexes for table `produksi_team`
@@ -370,7 +1074,8 @@ ALTER TABLE `profile`
 -- Indexes for table `progress`
 --
 ALTER TABLE `progress`
-  ADD PRIMARY KEY (`progress_id`);
+  ADD PRIMARY KEY (`progress_id`),
+  ADD KEY `produksi_id` (`produksi_id`);
 
 --
 -- Indexes for table `quality_control`
@@ -386,13 +1091,92 @@ ALTER TABLE `quality_control`
 -- Indexes for table `rekayasa`
 --
 ALTER TABLE `rekayasa`
-  ADD PRIMARY KEY (`rekayasa_id`);
+  ADD PRIMARY KEY (`rekayasa_id`),
+  ADD KEY `produksi_id` (`produksi_id`);
+
+--
+-- Indexes for table `rekayasa_document`
+--
+ALTER TABLE `rekayasa_document`
+  ADD PRIMARY KEY (`document_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`),
+  ADD UNIQUE KEY `doc_number` (`doc_number`);
+
+--
+-- Indexes for table `rekayasa_document_revision`
+--
+ALTER TABLE `rekayasa_document_revision`
+  ADD PRIMARY KEY (`revision_id`),
+  ADD UNIQUE KEY `document_revision` (`document_id`,`revision`),
+  ADD KEY `document_id` (`document_id`),
+  ADD KEY `uploaded_by` (`uploaded_by`);
+
+--
+-- Indexes for table `rekayasa_ecr`
+--
+ALTER TABLE `rekayasa_ecr`
+  ADD PRIMARY KEY (`ecr_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`),
+  ADD KEY `requested_by` (`requested_by`),
+  ADD UNIQUE KEY `ecr_number` (`ecr_number`);
+
+--
+-- Indexes for table `rekayasa_ecr_comment`
+--
+ALTER TABLE `rekayasa_ecr_comment`
+  ADD PRIMARY KEY (`comment_id`),
+  ADD KEY `ecr_id` (`ecr_id`),
+  ADD KEY `personalia_id` (`personalia_id`);
+
+--
+-- Indexes for table `rekayasa_ecr_impact`
+--
+ALTER TABLE `rekayasa_ecr_impact`
+  ADD PRIMARY KEY (`impact_id`),
+  ADD KEY `ecr_id` (`ecr_id`),
+  ADD KEY `produksi_id` (`produksi_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `rekayasa_ecr_reviewer`
+--
+ALTER TABLE `rekayasa_ecr_reviewer`
+  ADD PRIMARY KEY (`ecr_reviewer_id`),
+  ADD UNIQUE KEY `ecr_personalia` (`ecr_id`,`personalia_id`),
+  ADD KEY `ecr_id` (`ecr_id`),
+  ADD KEY `personalia_id` (`personalia_id`);
+
+--
+-- Indexes for table `rekayasa_material`
+--
+ALTER TABLE `rekayasa_material`
+  ADD PRIMARY KEY (`rekayasa_material_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`),
+  ADD KEY `materials_id` (`materials_id`),
+  ADD KEY `inventory_id` (`inventory_id`);
+
+--
+-- Indexes for table `rekayasa_milestone`
+--
+ALTER TABLE `rekayasa_milestone`
+  ADD PRIMARY KEY (`milestone_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`);
+
+--
+-- Indexes for table `rekayasa_task`
+--
+ALTER TABLE `rekayasa_task`
+  ADD PRIMARY KEY (`task_id`),
+  ADD KEY `rekayasa_id` (`rekayasa_id`),
+  ADD KEY `milestone_id` (`milestone_id`),
+  ADD KEY `owner_id` (`owner_id`);
 
 --
 -- Indexes for table `rekayasa_team`
 --
 ALTER TABLE `rekayasa_team`
   ADD PRIMARY KEY (`rekayasa_team_id`),
+  ADD UNIQUE KEY `rekayasa_personalia` (`rekayasa_id`,`personalia_id`),
   ADD KEY `personalia_id` (`personalia_id`),
   ADD KEY `rekayasa_id` (`rekayasa_id`);
 
@@ -408,12 +1192,60 @@ ALTER TABLE `stock_production`
 -- AUTO_INCREMENT for dumped tables
 --
 
+--
+-- AUTO_INCREMENT for table `asset`
+--
+ALTER TABLE `asset`
+  MODIFY `asset_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `asset_maintenance_interval`
+--
+ALTER TABLE `asset_maintenance_interval`
+  MODIFY `interval_id` int(11) NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT for table `calibration`
 --
 ALTER TABLE `calibration`
   MODIFY `calibration_id` int(11) NOT NULL AUTO_INCREMENT;
 
+--
+-- AUTO_INCREMENT for table `calibration_certificate`
+--
+ALTER TABLE `calibration_certificate`
+  MODIFY `certificate_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `calibration_interval`
+--
+ALTER TABLE `calibration_interval`
+  MODIFY `interval_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `calibration_measurement`
+--
+ALTER TABLE `calibration_measurement`
+  MODIFY `measurement_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `customer_order`
+--
+ALTER TABLE `customer_order`
+  MODIFY `order_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `customer_order_line`
+--
+ALTER TABLE `customer_order_line`
+  MODIFY `order_line_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `customer_shipment`
+--
+ALTER TABLE `customer_shipment`
+  MODIFY `shipment_id` int(11) NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT for table `education`
 --
@@ -450,6 +1282,66 @@ ALTER TABLE `materials`
 ALTER TABLE `overhaul`
   MODIFY `overhaul_id` int(11) NOT NULL AUTO_INCREMENT;
 
+--
+-- AUTO_INCREMENT for table `overhaul_checklist_template`
+--
+ALTER TABLE `overhaul_checklist_template`
+  MODIFY `template_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_checklist_template_step`
+--
+ALTER TABLE `overhaul_checklist_template_step`
+  MODIFY `template_step_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_crew`
+--
+ALTER TABLE `overhaul_crew`
+  MODIFY `overhaul_crew_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_job_card`
+--
+ALTER TABLE `overhaul_job_card`
+  MODIFY `job_card_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_job_card_step`
+--
+ALTER TABLE `overhaul_job_card_step`
+  MODIFY `step_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_part`
+--
+ALTER TABLE `overhaul_part`
+  MODIFY `part_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_part_movement`
+--
+ALTER TABLE `overhaul_part_movement`
+  MODIFY `movement_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_sla_target`
+--
+ALTER TABLE `overhaul_sla_target`
+  MODIFY `sla_target_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_status_history`
+--
+ALTER TABLE `overhaul_status_history`
+  MODIFY `status_history_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `overhaul_step_photo`
+--
+ALTER TABLE `overhaul_step_photo`
+  MODIFY `photo_id` int(11) NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT for table `personalia`
 --
@@ -462,6 +1354,24 @@ ALTER TABLE `personalia`
 ALTER TABLE `produksi`
   MODIFY `produksi_id` int(11) NOT NULL AUTO_INCREMENT, AUTO_INCREMENT=2;
 
+--
+-- AUTO_INCREMENT for table `produksi_consumption`
+--
+ALTER TABLE `produksi_consumption`
+  MODIFY `consumption_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `produksi_labour`
+--
+ALTER TABLE `produksi_labour`
+  MODIFY `labour_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `produksi_materials`
+--
+ALTER TABLE `produksi_materials`
+  MODIFY `produksi_materials_id` int(11) NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT for table `produksi_team`
 --
@@ -492,6 +1402,60 @@ ALTER TABLE `quality_control`
 ALTER TABLE `rekayasa`
   MODIFY `rekayasa_id` int(11) NOT NULL AUTO_INCREMENT;
 
+--
+-- AUTO_INCREMENT for table `rekayasa_document`
+--
+ALTER TABLE `rekayasa_document`
+  MODIFY `document_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_document_revision`
+--
+ALTER TABLE `rekayasa_document_revision`
+  MODIFY `revision_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_ecr`
+--
+ALTER TABLE `rekayasa_ecr`
+  MODIFY `ecr_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_ecr_comment`
+--
+ALTER TABLE `rekayasa_ecr_comment`
+  MODIFY `comment_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_ecr_impact`
+--
+ALTER TABLE `rekayasa_ecr_impact`
+  MODIFY `impact_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_ecr_reviewer`
+--
+ALTER TABLE `rekayasa_ecr_reviewer`
+  MODIFY `ecr_reviewer_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_material`
+--
+ALTER TABLE `rekayasa_material`
+  MODIFY `rekayasa_material_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_milestone`
+--
+ALTER TABLE `rekayasa_milestone`
+  MODIFY `milestone_id` int(11) NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT for table `rekayasa_task`
+--
+ALTER TABLE `rekayasa_task`
+  MODIFY `task_id` int(11) NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT for table `rekayasa_team`
 --
@@ -508,11 +1472,54 @@ ALTER TABLE `stock_production`
 -- Constraints for dumped tables
 --
 
+--
+-- Constraints for table `asset_maintenance_interval`
+--
+ALTER TABLE `asset_maintenance_interval`
+  ADD CONSTRAINT `asset_maintenance_interval_ibfk_1` FOREIGN KEY (`asset_id`) REFERENCES `asset` (`asset_id`),
+  ADD CONSTRAINT `asset_maintenance_interval_ibfk_2` FOREIGN KEY (`last_overhaul_id`) REFERENCES `overhaul` (`overhaul_id`);
+
 --
 -- Constraints for table `calibration`
 --
 ALTER TABLE `calibration`
-  ADD CONSTRAINT `calibration_ibfk_1` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+  ADD CONSTRAINT `calibration_ibfk_1` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`),
+  ADD CONSTRAINT `calibration_ibfk_2` FOREIGN KEY (`next_calibration_id`) REFERENCES `calibration` (`calibration_id`),
+  ADD CONSTRAINT `calibration_ibfk_3` FOREIGN KEY (`technician_id`) REFERENCES `personalia` (`personalia_id`),
+  ADD CONSTRAINT `calibration_ibfk_4` FOREIGN KEY (`approver_id`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `calibration_certificate`
+--
+ALTER TABLE `calibration_certificate`
+  ADD CONSTRAINT `calibration_certificate_ibfk_1` FOREIGN KEY (`calibration_id`) REFERENCES `calibration` (`calibration_id`);
+
+--
+-- Constraints for table `calibration_interval`
+--
+ALTER TABLE `calibration_interval`
+  ADD CONSTRAINT `calibration_interval_ibfk_1` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `calibration_measurement`
+--
+ALTER TABLE `calibration_measurement`
+  ADD CONSTRAINT `calibration_measurement_ibfk_1` FOREIGN KEY (`calibration_id`) REFERENCES `calibration` (`calibration_id`),
+  ADD CONSTRAINT `calibration_measurement_ibfk_2` FOREIGN KEY (`standard_inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `customer_order_line`
+--
+ALTER TABLE `customer_order_line`
+  ADD CONSTRAINT `customer_order_line_ibfk_1` FOREIGN KEY (`order_id`) REFERENCES `customer_order` (`order_id`),
+  ADD CONSTRAINT `customer_order_line_ibfk_2` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`);
+
+--
+-- Constraints for table `customer_shipment`
+--
+ALTER TABLE `customer_shipment`
+  ADD CONSTRAINT `customer_shipment_ibfk_1` FOREIGN KEY (`order_line_id`) REFERENCES `customer_order_line` (`order_line_id`),
+  ADD CONSTRAINT `customer_shipment_ibfk_2` FOREIGN KEY (`stock_id`) REFERENCES `stock_production` (`stock_id`);
 
 --
 -- Constraints for table `overhaul`
@@ -521,7 +1528,63 @@ ALTER TABLE `overhaul`
   ADD CONSTRAINT `history_id` FOREIGN KEY (`history_id`) REFERENCES `history` (`history_id`),
   ADD CONSTRAINT `inventory_id` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`),
   ADD CONSTRAINT `overhaul_ibfk_1` FOREIGN KEY (`personalia_id`) REFERENCES `personalia` (`personalia_id`),
-  ADD CONSTRAINT `overhaul_ibfk_2` FOREIGN KEY (`materials_id`) REFERENCES `materials` (`materials_id`);
+  ADD CONSTRAINT `overhaul_ibfk_2` FOREIGN KEY (`materials_id`) REFERENCES `materials` (`materials_id`),
+  ADD CONSTRAINT `overhaul_ibfk_3` FOREIGN KEY (`asset_id`) REFERENCES `asset` (`asset_id`),
+  ADD CONSTRAINT `overhaul_ibfk_4` FOREIGN KEY (`interval_id`) REFERENCES `asset_maintenance_interval` (`interval_id`);
+
+--
+-- Constraints for table `overhaul_checklist_template_step`
+--
+ALTER TABLE `overhaul_checklist_template_step`
+  ADD CONSTRAINT `overhaul_checklist_template_step_ibfk_1` FOREIGN KEY (`template_id`) REFERENCES `overhaul_checklist_template` (`template_id`);
+
+--
+-- Constraints for table `overhaul_crew`
+--
+ALTER TABLE `overhaul_crew`
+  ADD CONSTRAINT `overhaul_crew_ibfk_1` FOREIGN KEY (`overhaul_id`) REFERENCES `overhaul` (`overhaul_id`),
+  ADD CONSTRAINT `overhaul_crew_ibfk_2` FOREIGN KEY (`personalia_id`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `overhaul_job_card`
+--
+ALTER TABLE `overhaul_job_card`
+  ADD CONSTRAINT `overhaul_job_card_ibfk_1` FOREIGN KEY (`overhaul_id`) REFERENCES `overhaul` (`overhaul_id`),
+  ADD CONSTRAINT `overhaul_job_card_ibfk_2` FOREIGN KEY (`template_id`) REFERENCES `overhaul_checklist_template` (`template_id`);
+
+--
+-- Constraints for table `overhaul_job_card_step`
+--
+ALTER TABLE `overhaul_job_card_step`
+  ADD CONSTRAINT `overhaul_job_card_step_ibfk_1` FOREIGN KEY (`job_card_id`) REFERENCES `overhaul_job_card` (`job_card_id`),
+  ADD CONSTRAINT `overhaul_job_card_step_ibfk_2` FOREIGN KEY (`signed_off_by`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `overhaul_part`
+--
+ALTER TABLE `overhaul_part`
+  ADD CONSTRAINT `overhaul_part_ibfk_1` FOREIGN KEY (`overhaul_id`) REFERENCES `overhaul` (`overhaul_id`),
+  ADD CONSTRAINT `overhaul_part_ibfk_2` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `overhaul_part_movement`
+--
+ALTER TABLE `overhaul_part_movement`
+  ADD CONSTRAINT `overhaul_part_movement_ibfk_1` FOREIGN KEY (`part_id`) REFERENCES `overhaul_part` (`part_id`),
+  ADD CONSTRAINT `overhaul_part_movement_ibfk_2` FOREIGN KEY (`overhaul_id`) REFERENCES `overhaul` (`overhaul_id`),
+  ADD CONSTRAINT `overhaul_part_movement_ibfk_3` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `overhaul_status_history`
+--
+ALTER TABLE `overhaul_status_history`
+  ADD CONSTRAINT `overhaul_status_history_ibfk_1` FOREIGN KEY (`overhaul_id`) REFERENCES `overhaul` (`overhaul_id`);
+
+--
+-- Constraints for table `overhaul_step_photo`
+--
+ALTER TABLE `overhaul_step_photo`
+  ADD CONSTRAINT `overhaul_step_photo_ibfk_1` FOREIGN KEY (`step_id`) REFERENCES `overhaul_job_card_step` (`step_id`);
 
 --
 -- Constraints for table `personalia`
@@ -535,7 +1598,31 @@ ALTER TABLE `personalia`
 ALTER TABLE `produksi`
   ADD CONSTRAINT `fk_inventory_id` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`),
   ADD CONSTRAINT `materials_id` FOREIGN KEY (`materials_id`) REFERENCES `materials` (`materials_id`),
-  ADD CONSTRAINT `progress_id` FOREIGN KEY (`progress_id`) REFERENCES `progress` (`progress_id`);
+  ADD CONSTRAINT `progress_id` FOREIGN KEY (`progress_id`) REFERENCES `progress` (`progress_id`),
+  ADD CONSTRAINT `produksi_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`);
+
+--
+-- Constraints for table `produksi_consumption`
+--
+ALTER TABLE `produksi_consumption`
+  ADD CONSTRAINT `produksi_consumption_ibfk_1` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`),
+  ADD CONSTRAINT `produksi_consumption_ibfk_2` FOREIGN KEY (`produksi_materials_id`) REFERENCES `produksi_materials` (`produksi_materials_id`),
+  ADD CONSTRAINT `produksi_consumption_ibfk_3` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `produksi_labour`
+--
+ALTER TABLE `produksi_labour`
+  ADD CONSTRAINT `produksi_labour_ibfk_1` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`),
+  ADD CONSTRAINT `produksi_labour_ibfk_2` FOREIGN KEY (`personalia_id`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `produksi_materials`
+--
+ALTER TABLE `produksi_materials`
+  ADD CONSTRAINT `produksi_materials_ibfk_1` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`),
+  ADD CONSTRAINT `produksi_materials_ibfk_2` FOREIGN KEY (`materials_id`) REFERENCES `materials` (`materials_id`),
+  ADD CONSTRAINT `produksi_materials_ibfk_3` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
 
 --
 -- Constraints for table `produksi_team`
@@ -551,6 +1638,12 @@ ALTER TABLE `profile`
   ADD CONSTRAINT `experience_id` FOREIGN KEY (`experience_id`) REFERENCES `experience` (`experience_id`),
   ADD CONSTRAINT `fk_personalia_profile` FOREIGN KEY (`profile_id`) REFERENCES `personalia` (`personalia_id`);
 
+--
+-- Constraints for table `progress`
+--
+ALTER TABLE `progress`
+  ADD CONSTRAINT `progress_ibfk_1` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`);
+
 --
 -- Constraints for table `quality_control`
 --
@@ -560,6 +1653,76 @@ ALTER TABLE `quality_control`
   ADD CONSTRAINT `quality_control_ibfk_3` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`),
   ADD CONSTRAINT `quality_control_ibfk_4` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
 
+--
+-- Constraints for table `rekayasa`
+--
+ALTER TABLE `rekayasa`
+  ADD CONSTRAINT `rekayasa_ibfk_1` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`);
+
+--
+-- Constraints for table `rekayasa_document`
+--
+ALTER TABLE `rekayasa_document`
+  ADD CONSTRAINT `rekayasa_document_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`);
+
+--
+-- Constraints for table `rekayasa_document_revision`
+--
+ALTER TABLE `rekayasa_document_revision`
+  ADD CONSTRAINT `rekayasa_document_revision_ibfk_1` FOREIGN KEY (`document_id`) REFERENCES `rekayasa_document` (`document_id`),
+  ADD CONSTRAINT `rekayasa_document_revision_ibfk_2` FOREIGN KEY (`uploaded_by`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `rekayasa_ecr`
+--
+ALTER TABLE `rekayasa_ecr`
+  ADD CONSTRAINT `rekayasa_ecr_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`),
+  ADD CONSTRAINT `rekayasa_ecr_ibfk_2` FOREIGN KEY (`requested_by`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `rekayasa_ecr_comment`
+--
+ALTER TABLE `rekayasa_ecr_comment`
+  ADD CONSTRAINT `rekayasa_ecr_comment_ibfk_1` FOREIGN KEY (`ecr_id`) REFERENCES `rekayasa_ecr` (`ecr_id`),
+  ADD CONSTRAINT `rekayasa_ecr_comment_ibfk_2` FOREIGN KEY (`personalia_id`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `rekayasa_ecr_impact`
+--
+ALTER TABLE `rekayasa_ecr_impact`
+  ADD CONSTRAINT `rekayasa_ecr_impact_ibfk_1` FOREIGN KEY (`ecr_id`) REFERENCES `rekayasa_ecr` (`ecr_id`),
+  ADD CONSTRAINT `rekayasa_ecr_impact_ibfk_2` FOREIGN KEY (`produksi_id`) REFERENCES `produksi` (`produksi_id`),
+  ADD CONSTRAINT `rekayasa_ecr_impact_ibfk_3` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `rekayasa_ecr_reviewer`
+--
+ALTER TABLE `rekayasa_ecr_reviewer`
+  ADD CONSTRAINT `rekayasa_ecr_reviewer_ibfk_1` FOREIGN KEY (`ecr_id`) REFERENCES `rekayasa_ecr` (`ecr_id`),
+  ADD CONSTRAINT `rekayasa_ecr_reviewer_ibfk_2` FOREIGN KEY (`personalia_id`) REFERENCES `personalia` (`personalia_id`);
+
+--
+-- Constraints for table `rekayasa_material`
+--
+ALTER TABLE `rekayasa_material`
+  ADD CONSTRAINT `rekayasa_material_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`),
+  ADD CONSTRAINT `rekayasa_material_ibfk_2` FOREIGN KEY (`materials_id`) REFERENCES `materials` (`materials_id`),
+  ADD CONSTRAINT `rekayasa_material_ibfk_3` FOREIGN KEY (`inventory_id`) REFERENCES `inventory` (`inventory_id`);
+
+--
+-- Constraints for table `rekayasa_milestone`
+--
+ALTER TABLE `rekayasa_milestone`
+  ADD CONSTRAINT `rekayasa_milestone_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`);
+
+--
+-- Constraints for table `rekayasa_task`
+--
+ALTER TABLE `rekayasa_task`
+  ADD CONSTRAINT `rekayasa_task_ibfk_1` FOREIGN KEY (`rekayasa_id`) REFERENCES `rekayasa` (`rekayasa_id`),
+  ADD CONSTRAINT `rekayasa_task_ibfk_2` FOREIGN KEY (`milestone_id`) REFERENCES `rekayasa_milestone` (`milestone_id`),
+  ADD CONSTRAINT `rekayasa_task_ibfk_3` FOREIGN KEY (`owner_id`) REFERENCES `personalia` (`personalia_id`);
+
 --
 -- Constraints for table `rekayasa_team`
 --
@@ -0,0 +1,423 @@
+package kalibrasi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dateutil"
+	"kai-backend/dberr"
+	"kai-backend/pdfdoc"
+)
+
+// certificateDateLayout - Format tanggal pada sertifikat kalibrasi
+const certificateDateLayout = "02-01-2006"
+
+// issueAttempts - Jumlah percobaan penerbitan jika nomor urut bentrok dengan request lain
+const issueAttempts = 3
+
+// errCertificateIssued dikembalikan jika data yang tercetak pada sertifikat diubah setelah terbit
+var errCertificateIssued = errors.New("Certificate already issued; measurements, personnel and progress can no longer be changed")
+
+// Personalia - Referensi minimal ke tabel 'personalia' untuk teknisi dan penyetuju kalibrasi
+type Personalia struct {
+	PersonaliaID int    `json:"personalia_id" gorm:"column:personalia_id;primaryKey"`
+	NIP          string `json:"nip" gorm:"column:nip"`
+	Jabatan      string `json:"jabatan" gorm:"column:jabatan"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Personalia di trash dianggap tidak ada
+}
+
+func (Personalia) TableName() string {
+	return "personalia"
+}
+
+// CalibrationCertificate - Sertifikat yang diterbitkan untuk satu kalibrasi. Nomor urut dihitung
+// per tahun terbit dan tidak berubah saat sertifikat dicetak ulang.
+type CalibrationCertificate struct {
+	CertificateID     int       `json:"id" gorm:"column:certificate_id;primaryKey;autoIncrement"`
+	CalibrationID     int       `json:"calibration_id" gorm:"column:calibration_id"`
+	Year              int       `json:"year" gorm:"column:year"`
+	Sequence          int       `json:"sequence" gorm:"column:sequence"`
+	CertificateNumber string    `json:"certificateNumber" gorm:"column:certificate_number"`
+	IssuedAt          time.Time `json:"issuedAt" gorm:"column:issued_at"`
+}
+
+func (CalibrationCertificate) TableName() string {
+	return "calibration_certificate"
+}
+
+// certificateNumber membentuk nomor sertifikat dari nomor urut dan tahun terbit
+func certificateNumber(year, sequence int) string {
+	return fmt.Sprintf("%04d/KAL/BY/%d", sequence, year)
+}
+
+// lockUnissued mengunci baris kalibrasi dan mengembalikan errCertificateIssued jika sertifikatnya
+// sudah terbit. Dipakai sebelum mengubah data yang tercetak pada sertifikat; penerbitan mengunci
+// baris yang sama sehingga keduanya tidak dapat berjalan bersamaan.
+func lockUnissued(tx *gorm.DB, calibrationID int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("calibration_id").
+		First(&Calibration{}, calibrationID).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := tx.Model(&CalibrationCertificate{}).Where("calibration_id = ?", calibrationID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errCertificateIssued
+	}
+	return nil
+}
+
+// sameID membandingkan dua referensi personalia yang boleh kosong
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// certifiedChanged mengecek apakah perubahan kalibrasi menyentuh field yang tercetak pada sertifikat
+func certifiedChanged(before, after Calibration) bool {
+	return before.ProgressStep != after.ProgressStep ||
+		!sameID(before.TechnicianID, after.TechnicianID) || !sameID(before.ApproverID, after.ApproverID)
+}
+
+// validatePersonnel memastikan teknisi dan penyetuju (jika diisi) terdaftar di personalia
+func validatePersonnel(tx *gorm.DB, item Calibration) (int, string) {
+	for _, ref := range []struct {
+		field string
+		id    *int
+	}{{"technician_id", item.TechnicianID}, {"approver_id", item.ApproverID}} {
+		if ref.id == nil {
+			continue
+		}
+		var count int64
+		if err := tx.Model(&Personalia{}).Where("personalia_id = ?", *ref.id).Count(&count).Error; err != nil {
+			return http.StatusInternalServerError, "Failed to fetch personalia"
+		}
+		if count == 0 {
+			return http.StatusBadRequest, fmt.Sprintf("Personalia with ID %d not found (%s)", *ref.id, ref.field)
+		}
+	}
+	return 0, ""
+}
+
+// issueCertificate mengembalikan sertifikat kalibrasi; sertifikat baru diberi nomor urut
+// berikutnya pada tahun terbit. Baris kalibrasi dikunci lebih dulu agar satu kalibrasi hanya
+// mendapat satu sertifikat, lalu baris sertifikat tahun berjalan dikunci agar nomor tidak ganda.
+// Jika nomor tetap bentrok (UNIQUE year, sequence) penerbitan diulang.
+func issueCertificate(calibrationID int, now time.Time) (CalibrationCertificate, error) {
+	var cert CalibrationCertificate
+	var err error
+	for attempt := 1; attempt <= issueAttempts; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return createCertificate(tx, calibrationID, now, &cert)
+		})
+		if !dberr.IsDuplicateKey(err) {
+			break
+		}
+		log.Printf("Certificate number collision for calibration %d (attempt %d): %v", calibrationID, attempt, err)
+	}
+	return cert, err
+}
+
+// createCertificate mengisi cert dengan sertifikat kalibrasi yang sudah ada atau yang baru dibuat
+func createCertificate(tx *gorm.DB, calibrationID int, now time.Time, cert *CalibrationCertificate) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("calibration_id").
+		First(&Calibration{}, calibrationID).Error; err != nil {
+		return err
+	}
+	*cert = CalibrationCertificate{}
+	err := tx.Where("calibration_id = ?", calibrationID).First(cert).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	var last struct{ Max int }
+	if err := tx.Model(&CalibrationCertificate{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("COALESCE(MAX(sequence), 0) AS max").Where("year = ?", now.Year()).Scan(&last).Error; err != nil {
+		return err
+	}
+	*cert = CalibrationCertificate{
+		CalibrationID:     calibrationID,
+		Year:              now.Year(),
+		Sequence:          last.Max + 1,
+		CertificateNumber: certificateNumber(now.Year(), last.Max+1),
+		IssuedAt:          now,
+	}
+	if err := tx.Create(cert).Error; err != nil {
+		return err
+	}
+	log.Printf("Issued calibration certificate %s for calibration %d", cert.CertificateNumber, calibrationID)
+	return nil
+}
+
+// formatDate menampilkan tanggal sertifikat, "-" jika kosong
+func formatDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format(certificateDateLayout)
+}
+
+// resultLabel menerjemahkan hasil evaluasi untuk dokumen cetak
+func resultLabel(result string) string {
+	switch result {
+	case resultPass:
+		return "Lulus"
+	case resultFail:
+		return "Tidak Lulus"
+	case resultInconclusive:
+		return "Tidak dapat disimpulkan"
+	case resultPending:
+		return "Belum lengkap"
+	default:
+		return "-"
+	}
+}
+
+// personName menampilkan personalia sebagai NIP beserta jabatannya
+func personName(id *int) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	var p Personalia
+	if err := db.First(&p, *id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if p.Jabatan == "" {
+		return p.NIP, nil
+	}
+	return p.NIP + " (" + p.Jabatan + ")", nil
+}
+
+// dueDateOf mengembalikan due date kalibrasi berikutnya yang dijadwalkan, nil jika belum ada
+// atau sudah dipindahkan ke trash
+func dueDateOf(nextID *int) (*time.Time, error) {
+	if nextID == nil {
+		return nil, nil
+	}
+	var next Calibration
+	if err := db.First(&next, *nextID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &next.DueDate, nil
+}
+
+// standardRow menyusun baris standar acuan beserta validitas kalibrasinya sendiri: kalibrasi
+// selesai terakhir dari alat standar sebelum tanggal kalibrasi ini, sertifikatnya, dan masa
+// berlakunya (due date kalibrasi berikutnya). Standar yang hasil kalibrasinya tidak lulus
+// tidak dinyatakan berlaku.
+func standardRow(no int, m Measurement, calibratedAt time.Time) ([]string, error) {
+	if m.StandardInventoryID == nil {
+		return []string{strconv.Itoa(no), m.StandardName, "-", "-", "-", "-", "Tidak terdaftar di inventory"}, nil
+	}
+	code, name := "-", m.StandardName
+	var inv Inventory
+	if err := db.Unscoped().First(&inv, *m.StandardInventoryID).Error; err == nil {
+		code = inv.ItemCode
+		if name == "" {
+			name = inv.Name
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var rows []Calibration
+	if err := db.Where("inventory_id = ? AND completed_at IS NOT NULL AND completed_at <= ?", *m.StandardInventoryID, calibratedAt).
+		Order("completed_at DESC").Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []string{strconv.Itoa(no), name, code, "-", "-", "-", "Tidak ada data kalibrasi"}, nil
+	}
+	last := rows[0]
+	number := "-"
+	var cert CalibrationCertificate
+	if err := db.Where("calibration_id = ?", last.CalibrationID).First(&cert).Error; err == nil {
+		number = cert.CertificateNumber
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	validUntil, err := dueDateOf(last.NextCalibrationID)
+	if err != nil {
+		return nil, err
+	}
+	points, err := loadMeasurements(db, []int{last.CalibrationID})
+	if err != nil {
+		return nil, err
+	}
+	status := "Berlaku"
+	switch result := overallResult(points[last.CalibrationID]); {
+	case result == resultFail || result == resultInconclusive || result == resultPending:
+		status = "Hasil kalibrasi: " + resultLabel(result)
+	case validUntil == nil:
+		status = "Masa berlaku tidak diketahui"
+	case validUntil.Before(dateutil.DateOnly(calibratedAt)):
+		status = "Kedaluwarsa"
+	}
+	return []string{strconv.Itoa(no), name, code, formatDate(last.CompletedAt), number, formatDate(validUntil), status}, nil
+}
+
+// exportCertificatePDF menerbitkan (atau mencetak ulang) sertifikat kalibrasi: identitas alat,
+// tabel titik ukur, standar acuan beserta validitasnya, teknisi dan penyetuju, tanggal terbit dan
+// due date kalibrasi berikutnya. Hanya kalibrasi selesai dengan semua titik ukur terbaca.
+func exportCertificatePDF(c *gin.Context) {
+	item, ok := findCalibration(c)
+	if !ok {
+		return
+	}
+	if item.CompletedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Calibration is not completed yet"})
+		return
+	}
+	points, err := loadMeasurements(db, []int{item.CalibrationID})
+	if err != nil {
+		log.Printf("Error fetching measurements for certificate %d: %v", item.CalibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch measurements", "details": err.Error()})
+		return
+	}
+	rows := points[item.CalibrationID]
+	result := overallResult(rows)
+	switch result {
+	case "":
+		c.JSON(http.StatusConflict, gin.H{"error": "Calibration has no measurement points"})
+		return
+	case resultPending:
+		c.JSON(http.StatusConflict, gin.H{"error": "All measurement points must have readings before issuing a certificate"})
+		return
+	}
+
+	var inv *Inventory
+	if item.InventoryID != nil {
+		var found Inventory
+		if err := db.Unscoped().First(&found, *item.InventoryID).Error; err == nil {
+			inv = &found
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error fetching inventory for certificate %d: %v", item.CalibrationID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory item", "details": err.Error()})
+			return
+		}
+	}
+	technician, err := personName(item.TechnicianID)
+	if err != nil {
+		log.Printf("Error fetching technician for certificate %d: %v", item.CalibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch technician", "details": err.Error()})
+		return
+	}
+	approver, err := personName(item.ApproverID)
+	if err != nil {
+		log.Printf("Error fetching approver for certificate %d: %v", item.CalibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approver", "details": err.Error()})
+		return
+	}
+	nextDue, err := dueDateOf(item.NextCalibrationID)
+	if err != nil {
+		log.Printf("Error fetching next calibration for certificate %d: %v", item.CalibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch next calibration", "details": err.Error()})
+		return
+	}
+
+	// Standar acuan unik per alat inventory atau per nama
+	var standardRows [][]string
+	seen := make(map[string]bool)
+	for _, m := range rows {
+		key := "name:" + m.StandardName
+		if m.StandardInventoryID != nil {
+			key = fmt.Sprintf("inv:%d", *m.StandardInventoryID)
+		} else if m.StandardName == "" {
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		row, err := standardRow(len(standardRows)+1, m, *item.CompletedAt)
+		if err != nil {
+			log.Printf("Error fetching reference standard for certificate %d: %v", item.CalibrationID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reference standards", "details": err.Error()})
+			return
+		}
+		standardRows = append(standardRows, row)
+	}
+
+	cert, err := issueCertificate(item.CalibrationID, time.Now())
+	if err != nil {
+		log.Printf("Error issuing certificate for calibration %d: %v", item.CalibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue certificate", "details": err.Error()})
+		return
+	}
+
+	doc := pdfdoc.New("L", "Sertifikat Kalibrasi", cert.CertificateNumber)
+
+	doc.Section("Identitas Alat")
+	fields := []pdfdoc.Field{
+		{Label: "No. Sertifikat", Value: cert.CertificateNumber},
+		{Label: "Nama Alat", Value: item.ToolName},
+	}
+	if inv != nil {
+		fields = append(fields,
+			pdfdoc.Field{Label: "Kode Barang", Value: inv.ItemCode},
+			pdfdoc.Field{Label: "Nama Inventory", Value: inv.Name},
+			pdfdoc.Field{Label: "Lokasi", Value: inv.Location},
+		)
+	}
+	fields = append(fields,
+		pdfdoc.Field{Label: "Tanggal Kalibrasi", Value: formatDate(item.CompletedAt)},
+		pdfdoc.Field{Label: "Tanggal Terbit", Value: formatDate(&cert.IssuedAt)},
+		pdfdoc.Field{Label: "Kalibrasi Berikutnya", Value: formatDate(nextDue)},
+		pdfdoc.Field{Label: "Hasil", Value: resultLabel(result)},
+	)
+	doc.Fields(fields)
+
+	doc.Section("Hasil Pengukuran")
+	measurementRows := make([][]string, 0, len(rows))
+	for _, m := range rows {
+		uncertainty := "-"
+		if m.Uncertainty != nil {
+			uncertainty = pdfdoc.FormatValue(m.Uncertainty)
+			if m.CoverageFactor != nil {
+				uncertainty += " (k=" + pdfdoc.FormatValue(m.CoverageFactor) + ")"
+			}
+		}
+		measurementRows = append(measurementRows, []string{
+			strconv.Itoa(m.Sequence), m.Parameter, m.Unit, pdfdoc.FormatValue(m.NominalValue), "± " + pdfdoc.FormatValue(m.Tolerance),
+			pdfdoc.FormatValue(m.ReadingBefore), pdfdoc.FormatValue(m.ReadingAfter), pdfdoc.FormatValue(m.Deviation), uncertainty,
+			resultLabel(m.Result), m.Notes,
+		})
+	}
+	doc.Table([]string{"No", "Parameter", "Satuan", "Nominal", "Toleransi", "Sebelum", "Sesudah", "Penyimpangan", "Ketidakpastian", "Hasil", "Catatan"},
+		[]float64{8, 40, 15, 20, 20, 20, 20, 18, 28, 20, 40}, measurementRows)
+	doc.Paragraph("Ketidakpastian yang dilaporkan adalah ketidakpastian diperluas dengan faktor cakupan k. " +
+		"Titik ukur dinyatakan lulus jika penyimpangan ditambah ketidakpastian masih dalam toleransi, " +
+		"tidak lulus jika penyimpangan dikurangi ketidakpastian di luar toleransi, dan tidak dapat disimpulkan di antaranya.")
+
+	doc.Section("Standar Acuan")
+	doc.Table([]string{"No", "Standar", "Kode Barang", "Dikalibrasi", "No. Sertifikat", "Berlaku s.d.", "Status"},
+		[]float64{8, 60, 30, 25, 35, 25, 45}, standardRows)
+
+	doc.Signatures([]pdfdoc.Signature{
+		{Role: "Teknisi Kalibrasi", Name: technician},
+		{Role: "Disetujui oleh", Name: approver},
+	})
+
+	if err := doc.Send(c, fmt.Sprintf("calibration_certificate_%d_%d.pdf", cert.Year, cert.Sequence)); err != nil {
+		log.Printf("Error writing calibration certificate PDF to response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write PDF file"})
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package kalibrasi
+
+import "testing"
+
+func TestCertificateNumber(t *testing.T) {
+	tests := []struct {
+		year, sequence int
+		want           string
+	}{
+		{2024, 1, "0001/KAL/BY/2024"},
+		{2024, 42, "0042/KAL/BY/2024"},
+		{2025, 9999, "9999/KAL/BY/2025"},
+		{2025, 10000, "10000/KAL/BY/2025"},
+	}
+	for _, tt := range tests {
+		if got := certificateNumber(tt.year, tt.sequence); got != tt.want {
+			t.Errorf("certificateNumber(%d, %d) = %q, want %q", tt.year, tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestCertifiedChanged(t *testing.T) {
+	one, two := 1, 2
+	base := Calibration{ProgressStep: finalProgressStep, TechnicianID: &one, ApproverID: &two}
+	tests := []struct {
+		name  string
+		after Calibration
+		want  bool
+	}{
+		{"unchanged", Calibration{ProgressStep: finalProgressStep, TechnicianID: &one, ApproverID: &two}, false},
+		{"other fields only", Calibration{ToolName: "Multimeter", ProgressStep: finalProgressStep, TechnicianID: &one, ApproverID: &two}, false},
+		{"progress", Calibration{ProgressStep: 2, TechnicianID: &one, ApproverID: &two}, true},
+		{"technician", Calibration{ProgressStep: finalProgressStep, TechnicianID: &two, ApproverID: &two}, true},
+		{"approver cleared", Calibration{ProgressStep: finalProgressStep, TechnicianID: &one}, true},
+	}
+	for _, tt := range tests {
+		if got := certifiedChanged(base, tt.after); got != tt.want {
+			t.Errorf("%s: certifiedChanged = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package kalibrasi
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,6 +9,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Calibration mewakili struktur data untuk tabel 'calibration'
@@ -16,10 +21,23 @@ type Calibration struct {
 
 	ToolName     string    `json:"name" gorm:"column:tool_name"`
 	Status       string    `json:"status" gorm:"column:status"`
-	ProgressStep int       `json:"progress" gorm:"column:progress_step"` // Progress step (0-5)
+	ProgressStep int       `json:"progress" gorm:"column:progress_step"` // Progress step (0-4, lihat finalProgressStep)
 	DueDate      time.Time `json:"dueDate" gorm:"column:due_date;type:date"`
 	LastUpdate   time.Time `json:"lastUpdate" gorm:"column:last_update"`              // Menggunakan time.Time untuk datetime
 	InventoryID  *uint     `json:"inventory_id,omitempty" gorm:"column:inventory_id"` // Tambahkan kembali InventoryID
+
+	// Diisi server saat kalibrasi mencapai langkah terakhir (lihat completeCalibration)
+	CompletedAt       *time.Time `json:"completedAt,omitempty" gorm:"column:completed_at"`
+	NextCalibrationID *int       `json:"next_calibration_id,omitempty" gorm:"column:next_calibration_id"` // Kalibrasi berikutnya sesuai siklus alat
+
+	// Personalia pelaksana dan penyetuju kalibrasi, dicetak pada sertifikat
+	TechnicianID *int `json:"technician_id,omitempty" gorm:"column:technician_id"`
+	ApproverID   *int `json:"approver_id,omitempty" gorm:"column:approver_id"`
+
+	// Hasil keseluruhan dari titik ukur (pass, fail, inconclusive, pending), dihitung saat dibaca
+	Result string `json:"result,omitempty" gorm:"-"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
 // TableName mengembalikan nama tabel di database untuk model Calibration
@@ -32,6 +50,7 @@ var db *gorm.DB // Variabel global untuk instance GORM DB
 // Init menginisialisasi modul kalibrasi dengan instance database GORM
 func Init(dbInstance *gorm.DB) {
 	db = dbInstance
+	trash.Register(trash.Purger{Module: "kalibrasi", Model: &Calibration{}, Purge: purgeCalibration})
 	log.Println("Kalibrasi module initialized.")
 	// Opsional: AutoMigrate jika Anda ingin GORM membuat/memperbarui tabel
 	// err := db.AutoMigrate(&Calibration{})
@@ -48,6 +67,11 @@ func getAllCalibrations(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration data", "details": result.Error.Error()})
 		return
 	}
+	if err := attachResults(calibrationItems); err != nil {
+		log.Printf("Error fetching calibration measurements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch measurements", "details": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, calibrationItems)
 }
 
@@ -70,7 +94,13 @@ func getCalibrationByID(c *gin.Context) {
 		}
 		return
 	}
-	c.JSON(http.StatusOK, item)
+	items := []Calibration{item}
+	if err := attachResults(items); err != nil {
+		log.Printf("Error fetching measurements for calibration %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch measurements", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items[0])
 }
 
 // createCalibration menambahkan item kalibrasi baru ke database.
@@ -88,20 +118,37 @@ func createCalibration(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields 'name', 'status', and 'dueDate' are required."})
 		return
 	}
-	// Asumsi max steps 5 (0-4 atau 1-5), sesuaikan jika perlu
-	if newItem.ProgressStep < 0 || newItem.ProgressStep > 5 {
+	if msg := validateProgressStep(newItem.ProgressStep); msg != "" {
 		log.Printf("Validation failed for createCalibration: invalid progress step %d.", newItem.ProgressStep)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress step must be between 0 and 5."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if status, msg := validatePersonnel(db, newItem); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
 		return
 	}
 
 	newItem.CalibrationID = 0 // Biarkan GORM mengisi ID jika auto-increment
 	newItem.LastUpdate = time.Now()
 
+	newItem.CompletedAt, newItem.NextCalibrationID = nil, nil
+
 	log.Printf("Attempting to create calibration item: %+v", newItem)
-	if result := db.Create(&newItem); result.Error != nil {
-		log.Printf("Error creating calibration item in DB: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calibration item", "details": result.Error.Error()})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newItem).Error; err != nil {
+			return err
+		}
+		return completeCalibration(tx, &newItem, newItem.LastUpdate)
+	})
+	var blocked *completionBlocked
+	if errors.As(err, &blocked) {
+		c.JSON(http.StatusConflict, gin.H{"error": blocked.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating calibration item in DB: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calibration item", "details": err.Error()})
 		return
 	}
 	log.Printf("Successfully created calibration item with ID: %d", newItem.CalibrationID)
@@ -135,10 +182,76 @@ func updateCalibration(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields 'name', 'status', and 'dueDate' are required."})
 		return
 	}
-	// Asumsi max steps 5 (0-4 atau 1-5), sesuaikan jika perlu
-	if updatedItem.ProgressStep < 0 || updatedItem.ProgressStep > 5 {
+	if msg := validateProgressStep(updatedItem.ProgressStep); msg != "" {
 		log.Printf("Validation failed for updateCalibration: invalid progress step %d.", updatedItem.ProgressStep)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress step must be between 0 and 5."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if status, msg := validatePersonnel(db, updatedItem); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	// Baris dibaca ulang dengan lock di dalam transaksi agar Save tidak menimpa
+	// completed_at/next_calibration_id yang diisi request lain
+	var item Calibration
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, id).Error; err != nil {
+			return err
+		}
+		if certifiedChanged(item, updatedItem) {
+			if err := lockUnissued(tx, item.CalibrationID); err != nil {
+				return err
+			}
+		}
+
+		// Update fields
+		item.ToolName = updatedItem.ToolName
+		item.Status = updatedItem.Status
+		item.ProgressStep = updatedItem.ProgressStep
+		item.DueDate = updatedItem.DueDate
+		item.LastUpdate = time.Now()
+		item.InventoryID = updatedItem.InventoryID // Update InventoryID
+		item.TechnicianID = updatedItem.TechnicianID
+		item.ApproverID = updatedItem.ApproverID
+
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		return completeCalibration(tx, &item, item.LastUpdate)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calibration item not found"})
+		return
+	}
+	var blocked *completionBlocked
+	if errors.As(err, &blocked) || errors.Is(err, errCertificateIssued) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating calibration item with ID %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calibration item", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// patchCalibration memperbarui sebagian item kalibrasi (JSON Merge Patch, RFC 7396).
+// Hanya field yang dikirim yang divalidasi; lastUpdate selalu diisi waktu sekarang.
+func patchCalibration(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calibration ID"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Invalid patch document", "details": err.Error()})
 		return
 	}
 
@@ -147,30 +260,78 @@ func updateCalibration(c *gin.Context) {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Calibration item not found"})
 		} else {
-			log.Printf("Error fetching calibration item with ID %d for update: %v", id, result.Error)
+			log.Printf("Error fetching calibration item with ID %d for patch: %v", id, result.Error)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration data", "details": result.Error.Error()})
 		}
 		return
 	}
 
-	// Update fields
-	item.ToolName = updatedItem.ToolName
-	item.Status = updatedItem.Status
-	item.ProgressStep = updatedItem.ProgressStep
-	item.DueDate = updatedItem.DueDate
-	item.LastUpdate = time.Now()
-	item.InventoryID = updatedItem.InventoryID // Update InventoryID
+	var merged Calibration
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	merged.CalibrationID = item.CalibrationID
 
-	if result := db.Save(&item); result.Error != nil {
-		log.Printf("Error updating calibration item with ID %d: %v", id, result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calibration item", "details": result.Error.Error()})
+	if blank := patch.Blank(map[string]string{"name": merged.ToolName, "status": merged.Status}); len(blank) > 0 ||
+		(patch.Has("dueDate") && merged.DueDate.IsZero()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields 'name', 'status', and 'dueDate' cannot be empty."})
 		return
 	}
+	if msg := validateProgressStep(merged.ProgressStep); patch.Has("progress") && msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if patch.Has("technician_id") || patch.Has("approver_id") {
+		if status, msg := validatePersonnel(db, merged); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+	}
 
+	columns, rejected, err := patch.Columns(db, &Calibration{}, "lastUpdate", "deleted_at", "completedAt", "next_calibration_id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only fields", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		merged.LastUpdate = time.Now()
+		columns = append(columns, "last_update")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if certifiedChanged(item, merged) {
+				if err := lockUnissued(tx, item.CalibrationID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&item, id).Error; err != nil {
+				return err
+			}
+			return completeCalibration(tx, &item, merged.LastUpdate)
+		})
+		var blocked *completionBlocked
+		if errors.As(err, &blocked) || errors.Is(err, errCertificateIssued) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error patching calibration item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calibration item", "details": err.Error()})
+			return
+		}
+	}
+	db.First(&item, id)
 	c.JSON(http.StatusOK, item)
 }
 
-// deleteCalibration menghapus item kalibrasi dari database.
+// deleteCalibration memindahkan item kalibrasi ke trash (soft delete).
 func deleteCalibration(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -179,21 +340,93 @@ func deleteCalibration(c *gin.Context) {
 		return
 	}
 
-	if result := db.Delete(&Calibration{}, id); result.Error != nil {
+	result := db.Delete(&Calibration{}, id)
+	if result.Error != nil {
 		log.Printf("Error deleting calibration item with ID %d: %v", id, result.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calibration item", "details": result.Error.Error()})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calibration item not found"})
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 
+// purgeCalibration menghapus permanen item kalibrasi beserta titik ukur dan sertifikatnya;
+// tautan dari kalibrasi sebelumnya dilepas
+func purgeCalibration(tx *gorm.DB, id int) error {
+	if err := tx.Where("calibration_id = ?", id).Delete(&Measurement{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("calibration_id = ?", id).Delete(&CalibrationCertificate{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Calibration{}).Unscoped().Where("next_calibration_id = ?", id).Update("next_calibration_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Calibration{}, id).Error
+}
+
+// getCalibrationTrash menampilkan item kalibrasi yang sedang berada di trash
+func getCalibrationTrash(c *gin.Context) {
+	items := []Calibration{}
+	if err := trash.List(db, &items); err != nil {
+		log.Printf("Error fetching deleted calibration items: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted calibration items", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreCalibration memulihkan item kalibrasi dari trash
+func restoreCalibration(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calibration ID"})
+		return
+	}
+	if err := trash.Restore(db, &Calibration{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calibration item not found in trash"})
+		} else {
+			log.Printf("Error restoring calibration item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore calibration item", "details": err.Error()})
+		}
+		return
+	}
+	var item Calibration
+	db.First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
 // RegisterRoutes mendaftarkan rute API untuk modul Kalibrasi
 func RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/", getAllCalibrations)
 	rg.GET("", getAllCalibrations) // <-- INI YANG BENAR
+	rg.GET("/trash", getCalibrationTrash)
+
+	// Siklus kalibrasi per alat dan kalender jatuh tempo
+	rg.GET("/intervals", getIntervals)
+	rg.POST("/intervals", createInterval)
+	rg.PUT("/intervals/:intervalId", updateInterval)
+	rg.DELETE("/intervals/:intervalId", deleteInterval)
+	rg.GET("/calendar", getCalendar)
+
 	rg.GET("/:id", getCalibrationByID)
 	rg.POST("/", createCalibration)
 	rg.POST("", createCalibration)
 	rg.PUT("/:id", updateCalibration)
+	rg.PATCH("/:id", patchCalibration)
 	rg.DELETE("/:id", deleteCalibration)
+	rg.POST("/:id/restore", restoreCalibration)
+
+	// Titik ukur kalibrasi dengan evaluasi toleransi
+	rg.GET("/:id/measurements", getMeasurements)
+	rg.POST("/:id/measurements", createMeasurement)
+	rg.PUT("/:id/measurements/:pointId", updateMeasurement)
+	rg.DELETE("/:id/measurements/:pointId", deleteMeasurement)
+
+	// Sertifikat kalibrasi bernomor urut
+	rg.GET("/:id/certificate.pdf", exportCertificatePDF)
 }
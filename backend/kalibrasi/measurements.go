@@ -0,0 +1,369 @@
+package kalibrasi
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Hasil evaluasi titik ukur dan hasil keseluruhan kalibrasi
+const (
+	resultPass         = "pass"
+	resultFail         = "fail"
+	resultInconclusive = "inconclusive" // Zona ketidakpastian melewati batas toleransi
+	resultPending      = "pending"      // Masih ada titik ukur tanpa pembacaan
+)
+
+// defaultCoverageFactor - Faktor cakupan k untuk ketidakpastian diperluas (tingkat kepercayaan ~95%)
+const defaultCoverageFactor = 2.0
+
+// Measurement - Satu titik ukur kalibrasi: nilai nominal dan toleransi (±), pembacaan sebelum
+// (as found) dan sesudah penyetelan (as left), ketidakpastian dan standar acuan yang dipakai.
+// Hasil evaluasi dihitung server setiap kali titik ukur disimpan (lihat evaluate).
+type Measurement struct {
+	MeasurementID  int      `json:"id" gorm:"column:measurement_id;primaryKey;autoIncrement"`
+	CalibrationID  int      `json:"calibration_id" gorm:"column:calibration_id"`
+	Sequence       int      `json:"sequence" gorm:"column:sequence"`
+	Parameter      string   `json:"parameter" gorm:"column:parameter"` // mis. "Tegangan DC 10 V"
+	Unit           string   `json:"unit" gorm:"column:unit"`
+	NominalValue   *float64 `json:"nominalValue" gorm:"column:nominal_value"`
+	Tolerance      *float64 `json:"tolerance" gorm:"column:tolerance"` // Batas ± terhadap nominal
+	ReadingBefore  *float64 `json:"readingBefore" gorm:"column:reading_before"`
+	ReadingAfter   *float64 `json:"readingAfter" gorm:"column:reading_after"`     // Kosong jika alat tidak disetel
+	Uncertainty    *float64 `json:"uncertainty" gorm:"column:uncertainty"`        // Ketidakpastian diperluas (U)
+	CoverageFactor *float64 `json:"coverageFactor" gorm:"column:coverage_factor"` // Faktor cakupan k
+	// Standar acuan: alat di inventory (validitasnya dari riwayat kalibrasi) atau nama saja
+	StandardInventoryID *uint  `json:"standard_inventory_id" gorm:"column:standard_inventory_id"`
+	StandardName        string `json:"standardName" gorm:"column:standard_name"`
+	ResultBefore        string `json:"resultBefore" gorm:"column:result_before"` // Evaluasi pembacaan as found
+	Result              string `json:"result" gorm:"column:result"`              // Evaluasi pembacaan akhir
+	Notes               string `json:"notes" gorm:"column:notes"`
+
+	// Penyimpangan pembacaan akhir terhadap nominal, dihitung saat dibaca
+	Deviation *float64 `json:"deviation" gorm:"-"`
+}
+
+func (Measurement) TableName() string {
+	return "calibration_measurement"
+}
+
+// finalReading mengembalikan pembacaan as left, atau as found jika alat tidak disetel
+func (m Measurement) finalReading() *float64 {
+	if m.ReadingAfter != nil {
+		return m.ReadingAfter
+	}
+	return m.ReadingBefore
+}
+
+// measurementDeviation menghitung penyimpangan pembacaan akhir terhadap nominal; nil jika belum dibaca
+func measurementDeviation(m Measurement) *float64 {
+	reading := m.finalReading()
+	if reading == nil || m.NominalValue == nil {
+		return nil
+	}
+	diff := *reading - *m.NominalValue
+	return &diff
+}
+
+// evaluate membandingkan pembacaan dengan nominal ± toleransi memakai aturan keputusan dengan
+// guard band sebesar ketidakpastian diperluas U (ILAC-G8): pass jika |penyimpangan| + U masih
+// dalam toleransi, fail jika |penyimpangan| - U sudah di luar toleransi, selain itu inconclusive.
+// Tanpa U pembacaan dibandingkan langsung dengan toleransi. Kosong jika belum ada pembacaan.
+func evaluate(nominal, tolerance float64, uncertainty, reading *float64) string {
+	if reading == nil {
+		return ""
+	}
+	u := 0.0
+	if uncertainty != nil {
+		u = *uncertainty
+	}
+	deviation := math.Abs(*reading - nominal)
+	// Toleransi kecil untuk galat pembulatan float dari kolom decimal
+	switch {
+	case deviation+u <= tolerance+1e-9:
+		return resultPass
+	case deviation-u > tolerance+1e-9:
+		return resultFail
+	default:
+		return resultInconclusive
+	}
+}
+
+// evaluateMeasurement mengisi hasil as found, hasil akhir dan penyimpangan titik ukur
+func evaluateMeasurement(m *Measurement) {
+	m.ResultBefore = evaluate(*m.NominalValue, *m.Tolerance, m.Uncertainty, m.ReadingBefore)
+	m.Result = evaluate(*m.NominalValue, *m.Tolerance, m.Uncertainty, m.finalReading())
+	m.Deviation = measurementDeviation(*m)
+}
+
+// overallResult menghitung hasil keseluruhan: fail jika ada titik gagal, pending jika ada titik
+// yang belum dibaca, inconclusive jika ada titik yang tidak dapat disimpulkan, pass jika semua
+// titik lulus. Kosong jika kalibrasi belum memiliki titik ukur.
+func overallResult(points []Measurement) string {
+	if len(points) == 0 {
+		return ""
+	}
+	result := resultPass
+	for _, m := range points {
+		switch m.Result {
+		case resultFail:
+			return resultFail
+		case "":
+			result = resultPending
+		case resultInconclusive:
+			if result != resultPending {
+				result = resultInconclusive
+			}
+		}
+	}
+	return result
+}
+
+// loadMeasurements memuat titik ukur beberapa kalibrasi sekaligus sesuai urutan
+func loadMeasurements(tx *gorm.DB, ids []int) (map[int][]Measurement, error) {
+	points := make(map[int][]Measurement)
+	if len(ids) == 0 {
+		return points, nil
+	}
+	var rows []Measurement
+	if err := tx.Where("calibration_id IN ?", ids).Order("calibration_id, sequence, measurement_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range rows {
+		m.Deviation = measurementDeviation(m)
+		points[m.CalibrationID] = append(points[m.CalibrationID], m)
+	}
+	return points, nil
+}
+
+// attachResults mengisi hasil keseluruhan pada daftar kalibrasi
+func attachResults(items []Calibration) error {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.CalibrationID)
+	}
+	points, err := loadMeasurements(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Result = overallResult(points[items[i].CalibrationID])
+	}
+	return nil
+}
+
+// validateMeasurement memeriksa titik ukur dan melengkapi nama standar acuan dari inventory
+func validateMeasurement(tx *gorm.DB, m *Measurement) (int, string) {
+	m.Parameter = strings.TrimSpace(m.Parameter)
+	m.StandardName = strings.TrimSpace(m.StandardName)
+	if m.Parameter == "" || m.NominalValue == nil || m.Tolerance == nil {
+		return http.StatusBadRequest, "Fields 'parameter', 'nominalValue' and 'tolerance' are required."
+	}
+	if *m.Tolerance < 0 {
+		return http.StatusBadRequest, "tolerance cannot be negative"
+	}
+	if m.Uncertainty != nil && *m.Uncertainty < 0 {
+		return http.StatusBadRequest, "uncertainty cannot be negative"
+	}
+	if m.CoverageFactor != nil && *m.CoverageFactor <= 0 {
+		return http.StatusBadRequest, "coverageFactor must be greater than 0"
+	}
+	if m.Uncertainty != nil && m.CoverageFactor == nil {
+		k := defaultCoverageFactor
+		m.CoverageFactor = &k
+	}
+	if m.StandardInventoryID != nil {
+		var inv Inventory
+		if err := tx.First(&inv, *m.StandardInventoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return http.StatusBadRequest, "Reference standard not found in inventory"
+			}
+			return http.StatusInternalServerError, "Failed to fetch reference standard"
+		}
+		if m.StandardName == "" {
+			m.StandardName = inv.Name
+		}
+	}
+	evaluateMeasurement(m)
+	return 0, ""
+}
+
+// findCalibration mengambil kalibrasi (yang belum dihapus) berdasarkan parameter :id
+func findCalibration(c *gin.Context) (Calibration, bool) {
+	var item Calibration
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calibration ID"})
+		return item, false
+	}
+	if err := db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calibration item not found"})
+		} else {
+			log.Printf("Error fetching calibration item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration data", "details": err.Error()})
+		}
+		return item, false
+	}
+	return item, true
+}
+
+// findMeasurement mengambil titik ukur berdasarkan parameter :pointId milik kalibrasi
+func findMeasurement(c *gin.Context, item Calibration) (Measurement, bool) {
+	var m Measurement
+	pointID, err := strconv.Atoi(c.Param("pointId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid measurement ID"})
+		return m, false
+	}
+	if err := db.Where("calibration_id = ?", item.CalibrationID).First(&m, pointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Measurement not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch measurement", "details": err.Error()})
+		}
+		return m, false
+	}
+	return m, true
+}
+
+// measurementWriteError menjawab kegagalan menyimpan titik ukur; 409 jika sertifikat sudah terbit
+func measurementWriteError(c *gin.Context, err error, action string) {
+	if errors.Is(err, errCertificateIssued) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " measurement", "details": err.Error()})
+}
+
+// respondMeasurements mengirim titik ukur kalibrasi beserta hasil keseluruhannya
+func respondMeasurements(c *gin.Context, status, calibrationID int) {
+	points, err := loadMeasurements(db, []int{calibrationID})
+	if err != nil {
+		log.Printf("Error fetching measurements for calibration %d: %v", calibrationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch measurements", "details": err.Error()})
+		return
+	}
+	rows := points[calibrationID]
+	if rows == nil {
+		rows = []Measurement{}
+	}
+	c.JSON(status, gin.H{"result": overallResult(rows), "measurements": rows})
+}
+
+// getMeasurements menampilkan titik ukur satu kalibrasi
+func getMeasurements(c *gin.Context) {
+	item, ok := findCalibration(c)
+	if !ok {
+		return
+	}
+	respondMeasurements(c, http.StatusOK, item.CalibrationID)
+}
+
+// createMeasurement menambah titik ukur di akhir urutan. Titik ukur kalibrasi yang sudah
+// bersertifikat tidak dapat diubah.
+func createMeasurement(c *gin.Context) {
+	item, ok := findCalibration(c)
+	if !ok {
+		return
+	}
+	var m Measurement
+	if err := c.ShouldBindJSON(&m); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	m.MeasurementID = 0
+	m.CalibrationID = item.CalibrationID
+	if status, msg := validateMeasurement(db, &m); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUnissued(tx, item.CalibrationID); err != nil {
+			return err
+		}
+		if m.Sequence <= 0 {
+			var last struct{ Max int }
+			if err := tx.Model(&Measurement{}).Select("COALESCE(MAX(sequence), 0) AS max").
+				Where("calibration_id = ?", item.CalibrationID).Scan(&last).Error; err != nil {
+				return err
+			}
+			m.Sequence = last.Max + 1
+		}
+		return tx.Create(&m).Error
+	})
+	if err != nil {
+		log.Printf("Error creating measurement for calibration %d: %v", item.CalibrationID, err)
+		measurementWriteError(c, err, "create")
+		return
+	}
+	respondMeasurements(c, http.StatusCreated, item.CalibrationID)
+}
+
+// updateMeasurement mengganti isi titik ukur dan mengevaluasi ulang hasilnya
+func updateMeasurement(c *gin.Context) {
+	item, ok := findCalibration(c)
+	if !ok {
+		return
+	}
+	m, ok := findMeasurement(c, item)
+	if !ok {
+		return
+	}
+	var req Measurement
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	req.MeasurementID = m.MeasurementID
+	req.CalibrationID = m.CalibrationID
+	if req.Sequence <= 0 {
+		req.Sequence = m.Sequence
+	}
+	if status, msg := validateMeasurement(db, &req); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUnissued(tx, item.CalibrationID); err != nil {
+			return err
+		}
+		return tx.Select("*").Updates(&req).Error
+	})
+	if err != nil {
+		log.Printf("Error updating measurement %d: %v", m.MeasurementID, err)
+		measurementWriteError(c, err, "update")
+		return
+	}
+	respondMeasurements(c, http.StatusOK, item.CalibrationID)
+}
+
+// deleteMeasurement menghapus titik ukur
+func deleteMeasurement(c *gin.Context) {
+	item, ok := findCalibration(c)
+	if !ok {
+		return
+	}
+	m, ok := findMeasurement(c, item)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUnissued(tx, item.CalibrationID); err != nil {
+			return err
+		}
+		return tx.Delete(&Measurement{}, m.MeasurementID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting measurement %d: %v", m.MeasurementID, err)
+		measurementWriteError(c, err, "delete")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
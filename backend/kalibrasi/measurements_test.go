@@ -0,0 +1,82 @@
+package kalibrasi
+
+import "testing"
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		nominal     float64
+		tolerance   float64
+		uncertainty *float64
+		reading     *float64
+		want        string
+	}{
+		{"no reading", 10, 0.5, nil, nil, ""},
+		{"exact", 10, 0.5, nil, value(10), resultPass},
+		{"on the limit", 10, 0.5, nil, value(10.5), resultPass},
+		{"below the limit", 10, 0.5, nil, value(9.5), resultPass},
+		{"outside", 10, 0.5, nil, value(10.6), resultFail},
+		{"decimal rounding", 0.3, 0.1, nil, value(0.1 + 0.1), resultPass},
+		{"pass with guard band", 10, 0.5, value(0.2), value(10.3), resultPass},
+		{"inside tolerance but within U of the limit", 10, 0.5, value(0.2), value(10.4), resultInconclusive},
+		{"outside tolerance but within U of the limit", 10, 0.5, value(0.2), value(10.6), resultInconclusive},
+		{"outside tolerance beyond U", 10, 0.5, value(0.2), value(10.8), resultFail},
+		{"U larger than tolerance", 10, 0.5, value(0.6), value(10), resultInconclusive},
+		{"zero tolerance", 10, 0, nil, value(10), resultPass},
+	}
+	for _, tt := range tests {
+		if got := evaluate(tt.nominal, tt.tolerance, tt.uncertainty, tt.reading); got != tt.want {
+			t.Errorf("%s: evaluate = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOverallResult(t *testing.T) {
+	points := func(results ...string) []Measurement {
+		rows := make([]Measurement, 0, len(results))
+		for _, r := range results {
+			rows = append(rows, Measurement{Result: r})
+		}
+		return rows
+	}
+	tests := []struct {
+		name   string
+		points []Measurement
+		want   string
+	}{
+		{"no points", nil, ""},
+		{"all pass", points(resultPass, resultPass), resultPass},
+		{"one fail", points(resultPass, resultFail, ""), resultFail},
+		{"unread point", points(resultPass, ""), resultPending},
+		{"inconclusive point", points(resultPass, resultInconclusive), resultInconclusive},
+		{"pending outranks inconclusive", points(resultInconclusive, ""), resultPending},
+		{"fail outranks inconclusive", points(resultInconclusive, resultFail), resultFail},
+	}
+	for _, tt := range tests {
+		if got := overallResult(tt.points); got != tt.want {
+			t.Errorf("%s: overallResult = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMeasurementDeviation(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Measurement
+		want *float64
+	}{
+		{"no reading", Measurement{NominalValue: value(10)}, nil},
+		{"as found only", Measurement{NominalValue: value(10), ReadingBefore: value(10.25)}, value(0.25)},
+		{"as left wins", Measurement{NominalValue: value(10), ReadingBefore: value(10.5), ReadingAfter: value(9.75)}, value(-0.25)},
+	}
+	for _, tt := range tests {
+		got := measurementDeviation(tt.m)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: measurementDeviation = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
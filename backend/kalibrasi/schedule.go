@@ -0,0 +1,373 @@
+package kalibrasi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dateutil"
+)
+
+// finalProgressStep - Langkah terakhir stepper kalibrasi (Sertifikasi), stepper berisi langkah
+// 0-4. Kalibrasi yang mencapai langkah ini dianggap selesai dan memicu pembuatan jadwal
+// kalibrasi berikutnya.
+const finalProgressStep = 4
+
+// validateProgressStep memeriksa langkah stepper kalibrasi (0 sampai finalProgressStep)
+func validateProgressStep(step int) string {
+	if step < 0 || step > finalProgressStep {
+		return fmt.Sprintf("Progress step must be between 0 and %d.", finalProgressStep)
+	}
+	return ""
+}
+
+// statusBelumDimulai dipakai untuk kalibrasi berikutnya yang dibuat otomatis
+const statusBelumDimulai = "Belum Dimulai"
+
+// monthLayout - Format bulan YYYY-MM untuk kalender kalibrasi
+const monthLayout = "2006-01"
+
+// Inventory - Referensi minimal ke tabel 'inventory' untuk identifikasi alat
+type Inventory struct {
+	InventoryID uint   `json:"id" gorm:"column:inventory_id;primaryKey"`
+	Name        string `json:"name" gorm:"column:name"`
+	ItemCode    string `json:"itemCode" gorm:"column:itemCode"`
+	Location    string `json:"location" gorm:"column:location"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
+}
+
+func (Inventory) TableName() string {
+	return "inventory"
+}
+
+// CalibrationInterval - Siklus kalibrasi satu alat dalam bulan. Alat diidentifikasi lewat
+// inventory_id; alat tanpa data inventory dicocokkan berdasarkan tool_name.
+type CalibrationInterval struct {
+	IntervalID     int       `json:"id" gorm:"column:interval_id;primaryKey;autoIncrement"`
+	InventoryID    *uint     `json:"inventory_id" gorm:"column:inventory_id"`
+	ToolName       string    `json:"name" gorm:"column:tool_name"`
+	IntervalMonths int       `json:"intervalMonths" gorm:"column:interval_months"`
+	Active         bool      `json:"active" gorm:"column:active"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
+}
+
+func (CalibrationInterval) TableName() string {
+	return "calibration_interval"
+}
+
+// intervalFor mencari siklus aktif untuk alat kalibrasi: berdasarkan inventory_id lebih dulu,
+// lalu berdasarkan nama alat untuk siklus tanpa inventory
+func intervalFor(tx *gorm.DB, item Calibration) (*CalibrationInterval, error) {
+	var rows []CalibrationInterval
+	query := tx.Where("active = ?", true)
+	if item.InventoryID != nil {
+		query = query.Where("inventory_id = ? OR (inventory_id IS NULL AND tool_name = ?)", *item.InventoryID, item.ToolName).
+			Order("inventory_id IS NULL")
+	} else {
+		query = query.Where("inventory_id IS NULL AND tool_name = ?", item.ToolName)
+	}
+	if err := query.Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// completionBlocked dikembalikan jika hasil titik ukur belum lulus saat kalibrasi diselesaikan
+type completionBlocked struct{ msg string }
+
+func (e *completionBlocked) Error() string {
+	return e.msg
+}
+
+// checkCompletion memastikan kalibrasi dengan titik ukur hanya dapat diselesaikan jika hasil
+// keseluruhannya lulus. Kalibrasi tanpa titik ukur tetap dapat diselesaikan.
+func checkCompletion(tx *gorm.DB, calibrationID int) error {
+	points, err := loadMeasurements(tx, []int{calibrationID})
+	if err != nil {
+		return err
+	}
+	switch overallResult(points[calibrationID]) {
+	case resultFail:
+		return &completionBlocked{"Calibration cannot be completed: one or more measurement points failed"}
+	case resultInconclusive:
+		return &completionBlocked{"Calibration cannot be completed: one or more measurement points are inconclusive"}
+	case resultPending:
+		return &completionBlocked{"Calibration cannot be completed: all measurement points must have readings"}
+	}
+	return nil
+}
+
+// completeCalibration mencatat penyelesaian kalibrasi yang mencapai langkah terakhir dan membuat
+// kalibrasi berikutnya satu kali sesuai siklus alat (due date = tanggal selesai + interval).
+// Penyelesaian ditolak (completionBlocked) jika hasil titik ukur belum lulus.
+// completed_at dikosongkan jika progress mundur; kalibrasi berikutnya yang sudah dibuat tetap ada.
+// Baris dikunci lebih dulu agar dua penyelesaian bersamaan tidak membuat jadwal berikutnya dua kali.
+func completeCalibration(tx *gorm.DB, item *Calibration, now time.Time) error {
+	var current Calibration
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("calibration_id", "next_calibration_id").
+		First(&current, item.CalibrationID).Error; err != nil {
+		return err
+	}
+	item.NextCalibrationID = current.NextCalibrationID
+
+	if item.ProgressStep < finalProgressStep {
+		item.CompletedAt = nil
+	} else {
+		if item.CompletedAt == nil {
+			if err := checkCompletion(tx, item.CalibrationID); err != nil {
+				return err
+			}
+			item.CompletedAt = &now
+		}
+		if item.NextCalibrationID == nil {
+			interval, err := intervalFor(tx, *item)
+			if err != nil {
+				return err
+			}
+			if interval != nil {
+				next := Calibration{
+					ToolName:     item.ToolName,
+					Status:       statusBelumDimulai,
+					ProgressStep: 0,
+					DueDate:      dateutil.DateOnly(item.CompletedAt.AddDate(0, interval.IntervalMonths, 0)),
+					LastUpdate:   now,
+					InventoryID:  item.InventoryID,
+				}
+				if err := tx.Create(&next).Error; err != nil {
+					return err
+				}
+				item.NextCalibrationID = &next.CalibrationID
+				log.Printf("Scheduled calibration %d for %s due %s", next.CalibrationID, next.ToolName, next.DueDate.Format("2006-01-02"))
+			}
+		}
+	}
+	return tx.Model(item).Select("completed_at", "next_calibration_id").Updates(item).Error
+}
+
+// validateInterval memeriksa siklus kalibrasi; nama alat diambil dari inventory jika kosong
+func validateInterval(tx *gorm.DB, iv *CalibrationInterval) (int, string) {
+	iv.ToolName = strings.TrimSpace(iv.ToolName)
+	if iv.IntervalMonths < 1 || iv.IntervalMonths > 120 {
+		return http.StatusBadRequest, "intervalMonths must be between 1 and 120."
+	}
+	if iv.InventoryID != nil {
+		var inv Inventory
+		if err := tx.First(&inv, *iv.InventoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return http.StatusBadRequest, "Inventory item not found"
+			}
+			return http.StatusInternalServerError, "Failed to fetch inventory item"
+		}
+		if iv.ToolName == "" {
+			iv.ToolName = inv.Name
+		}
+	}
+	if iv.ToolName == "" {
+		return http.StatusBadRequest, "Either 'inventory_id' or 'name' is required."
+	}
+
+	// Satu siklus per alat
+	query := tx.Model(&CalibrationInterval{}).Where("interval_id <> ?", iv.IntervalID)
+	if iv.InventoryID != nil {
+		query = query.Where("inventory_id = ?", *iv.InventoryID)
+	} else {
+		query = query.Where("inventory_id IS NULL AND tool_name = ?", iv.ToolName)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, "Failed to check existing intervals"
+	}
+	if count > 0 {
+		return http.StatusConflict, "This tool already has a calibration interval"
+	}
+	return 0, ""
+}
+
+// findInterval mengambil siklus kalibrasi berdasarkan parameter :intervalId
+func findInterval(c *gin.Context) (CalibrationInterval, bool) {
+	var iv CalibrationInterval
+	id, err := strconv.Atoi(c.Param("intervalId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval ID"})
+		return iv, false
+	}
+	if err := db.Preload("Inventory").First(&iv, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calibration interval not found"})
+		} else {
+			log.Printf("Error fetching calibration interval %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration interval", "details": err.Error()})
+		}
+		return iv, false
+	}
+	return iv, true
+}
+
+// getIntervals menampilkan semua siklus kalibrasi per alat
+func getIntervals(c *gin.Context) {
+	intervals := []CalibrationInterval{}
+	if err := db.Preload("Inventory").Order("tool_name, interval_id").Find(&intervals).Error; err != nil {
+		log.Printf("Error fetching calibration intervals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration intervals", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, intervals)
+}
+
+// createInterval menambahkan siklus kalibrasi untuk satu alat (default aktif)
+func createInterval(c *gin.Context) {
+	var req struct {
+		CalibrationInterval
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	iv := req.CalibrationInterval
+	iv.IntervalID = 0
+	iv.Active = req.Active == nil || *req.Active
+	iv.CreatedAt = time.Now()
+	iv.Inventory = nil
+	if status, msg := validateInterval(db, &iv); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	if err := db.Create(&iv).Error; err != nil {
+		log.Printf("Error creating calibration interval: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calibration interval", "details": err.Error()})
+		return
+	}
+	db.Preload("Inventory").First(&iv, iv.IntervalID)
+	c.JSON(http.StatusCreated, iv)
+}
+
+// updateInterval mengubah alat, lama siklus atau status aktif siklus kalibrasi
+func updateInterval(c *gin.Context) {
+	iv, ok := findInterval(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		CalibrationInterval
+		Active *bool `json:"active"` // Tidak dikirim = status aktif tidak berubah
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	iv.InventoryID = req.InventoryID
+	iv.ToolName = req.ToolName
+	iv.IntervalMonths = req.IntervalMonths
+	if req.Active != nil {
+		iv.Active = *req.Active
+	}
+	iv.Inventory = nil
+	if status, msg := validateInterval(db, &iv); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	if err := db.Select("inventory_id", "tool_name", "interval_months", "active").Updates(&iv).Error; err != nil {
+		log.Printf("Error updating calibration interval %d: %v", iv.IntervalID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calibration interval", "details": err.Error()})
+		return
+	}
+	db.Preload("Inventory").First(&iv, iv.IntervalID)
+	c.JSON(http.StatusOK, iv)
+}
+
+// deleteInterval menghapus siklus kalibrasi; kalibrasi yang sudah dijadwalkan tidak ikut dihapus
+func deleteInterval(c *gin.Context) {
+	iv, ok := findInterval(c)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&CalibrationInterval{}, iv.IntervalID).Error; err != nil {
+		log.Printf("Error deleting calibration interval %d: %v", iv.IntervalID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calibration interval", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CalendarMonth - Kalibrasi terbuka yang jatuh tempo pada satu bulan
+type CalendarMonth struct {
+	Month        string        `json:"month"` // YYYY-MM
+	Count        int           `json:"count"`
+	Calibrations []Calibration `json:"calibrations"`
+}
+
+// getCalendar menampilkan kalibrasi yang belum selesai per bulan jatuh tempo mulai ?from=YYYY-MM
+// (default bulan ini) sebanyak ?months= bulan (default 6, maksimal 24), ditambah daftar
+// kalibrasi yang sudah lewat jatuh tempo sebelum hari ini.
+func getCalendar(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(monthLayout, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' month, use YYYY-MM"})
+			return
+		}
+		from = t
+	}
+	months := 6
+	if v := c.Query("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 24 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "months must be between 1 and 24"})
+			return
+		}
+		months = n
+	}
+	to := from.AddDate(0, months, 0)
+
+	var upcoming []Calibration
+	if err := db.Where("progress_step < ? AND due_date >= ? AND due_date < ?", finalProgressStep, from, to).
+		Order("due_date, calibration_id").Find(&upcoming).Error; err != nil {
+		log.Printf("Error fetching calibration calendar: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration data", "details": err.Error()})
+		return
+	}
+	overdue := []Calibration{}
+	if err := db.Where("progress_step < ? AND due_date < ?", finalProgressStep, dateutil.DateOnly(now)).
+		Order("due_date, calibration_id").Find(&overdue).Error; err != nil {
+		log.Printf("Error fetching overdue calibrations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibration data", "details": err.Error()})
+		return
+	}
+
+	calendar := make([]CalendarMonth, months)
+	for i := range calendar {
+		calendar[i] = CalendarMonth{Month: from.AddDate(0, i, 0).Format(monthLayout), Calibrations: []Calibration{}}
+	}
+	for _, item := range upcoming {
+		due := item.DueDate
+		i := (due.Year()-from.Year())*12 + int(due.Month()) - int(from.Month())
+		if i < 0 || i >= months {
+			continue
+		}
+		calendar[i].Calibrations = append(calendar[i].Calibrations, item)
+		calendar[i].Count++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from.Format(monthLayout),
+		"months":  calendar,
+		"overdue": overdue,
+	})
+}
@@ -14,6 +14,8 @@ import (
 
 	"kai-backend/inventory"
 	"kai-backend/kalibrasi"
+	"kai-backend/materials"
+	"kai-backend/orders"
 	"kai-backend/overhaul" // Modul overhaul Anda
 	"kai-backend/personalia"
 	"kai-backend/produksi"
@@ -21,6 +23,7 @@ import (
 	"kai-backend/quality"
 	"kai-backend/rekayasa"
 	"kai-backend/stock"
+	"kai-backend/trash"
 )
 
 // connectDB mencoba terhubung ke database dengan beberapa percobaan
@@ -79,7 +82,7 @@ func main() {
 	// Pastikan ini diterapkan SEBELUM rute-rute API Anda
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:5173"} // URL frontend React Anda
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	// Tambahkan "Content-Type" dan "Authorization" jika frontend Anda menggunakannya
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
 	// *** PENTING: Ubah AllowCredentials menjadi true jika frontend mengirim cookie/header Auth ***
@@ -91,6 +94,10 @@ func main() {
 	// Inisialisasi dan Daftarkan Route untuk Setiap Modul API
 	api := r.Group("/api")
 	{
+		// Purge trash semua modul (setiap modul mendaftarkan purge-nya saat Init)
+		trash.Init(db)
+		trash.RegisterRoutes(api.Group("/admin"))
+
 		overhaul.Init(db)
 		overhaul.RegisterRoutes(api.Group("/overhaul")) // Pastikan ini benar
 
@@ -112,9 +119,15 @@ func main() {
 		personalia.Init(db)
 		personalia.RegisterRoutes(api.Group("/personalia"))
 
+		materials.Init(db)
+		materials.RegisterRoutes(api.Group("/materials"))
+
 		produksi.Init(db)
 		produksi.RegisterRoutes(api.Group("/produksi"))
 
+		orders.Init(db)
+		orders.RegisterRoutes(api.Group("/orders"))
+
 		profile.Init(db)
 		profile.RegisterRoutes(api.Group("/profile"))
 	}
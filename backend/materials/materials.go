@@ -0,0 +1,282 @@
+package materials
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
+)
+
+// Materials mewakili data master material pada tabel 'materials'.
+// Data ini direferensikan oleh baris BOM produksi (tabel 'produksi_materials').
+type Materials struct {
+	MaterialsID   int     `json:"id" gorm:"column:materials_id;primaryKey;autoIncrement"`
+	MaterialsName string  `json:"name" gorm:"column:materials_name"`
+	Qty           int     `json:"qty" gorm:"column:qty"`
+	Price         float64 `json:"harga" gorm:"column:price"`
+	Satuan        string  `json:"satuan" gorm:"column:satuan"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
+}
+
+// TableName mengembalikan nama tabel di database untuk model Materials
+func (Materials) TableName() string {
+	return "materials"
+}
+
+var db *gorm.DB
+
+// Init menginisialisasi modul materials dengan instance database GORM
+func Init(database *gorm.DB) {
+	db = database
+	trash.Register(trash.Purger{Module: "materials", Model: &Materials{}})
+	log.Println("Materials module initialized.")
+}
+
+// validateMaterials memeriksa field wajib pada data master material
+func validateMaterials(item Materials) string {
+	if item.MaterialsName == "" || item.Satuan == "" {
+		return "Field name dan satuan wajib diisi"
+	}
+	if item.Qty < 0 || item.Price < 0 {
+		return "Qty dan harga tidak boleh negatif"
+	}
+	return ""
+}
+
+// getAllMaterials mengambil semua data master material
+func getAllMaterials(c *gin.Context) {
+	var items []Materials
+	if err := db.Order("materials_name").Find(&items).Error; err != nil {
+		log.Printf("Error fetching materials: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data material", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// getMaterialsByID mengambil satu data master material berdasarkan ID
+func getMaterialsByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var item Materials
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Material tidak ditemukan"})
+		} else {
+			log.Printf("Error fetching material with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data material", "details": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// createMaterials menambahkan data master material baru
+func createMaterials(c *gin.Context) {
+	var item Materials
+	if err := c.ShouldBindJSON(&item); err != nil {
+		log.Printf("Error binding JSON for createMaterials: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if msg := validateMaterials(item); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	item.MaterialsID = 0
+	if err := db.Create(&item).Error; err != nil {
+		log.Printf("Error creating material in DB: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data material", "details": err.Error()})
+		return
+	}
+	log.Printf("Successfully created material with ID: %d", item.MaterialsID)
+	c.JSON(http.StatusCreated, item)
+}
+
+// updateMaterials memperbarui data master material
+func updateMaterials(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var input Materials
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if msg := validateMaterials(input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var item Materials
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Material tidak ditemukan"})
+		return
+	}
+
+	item.MaterialsName = input.MaterialsName
+	item.Qty = input.Qty
+	item.Price = input.Price
+	item.Satuan = input.Satuan
+
+	if err := db.Save(&item).Error; err != nil {
+		log.Printf("Error updating material with ID %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update data material", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// patchMaterials memperbarui sebagian data master material (JSON Merge Patch, RFC 7396)
+func patchMaterials(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var item Materials
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Material tidak ditemukan"})
+		return
+	}
+
+	var merged Materials
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	merged.MaterialsID = item.MaterialsID
+
+	if blank := patch.Blank(map[string]string{"name": merged.MaterialsName, "satuan": merged.Satuan}); len(blank) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field name dan satuan wajib diisi", "fields": blank})
+		return
+	}
+	if (patch.Has("qty") && merged.Qty < 0) || (patch.Has("harga") && merged.Price < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Qty dan harga tidak boleh negatif"})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &Materials{}, "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		if err := db.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+			log.Printf("Error patching material with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update data material", "details": err.Error()})
+			return
+		}
+	}
+	db.First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
+// deleteMaterials memindahkan data master material ke trash (soft delete).
+// Material yang masih dipakai di BOM produksi tidak boleh dihapus.
+func deleteMaterials(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var item Materials
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Material tidak ditemukan"})
+		return
+	}
+
+	var used int64
+	if err := db.Table("produksi_materials").Where("materials_id = ?", id).Count(&used).Error; err != nil {
+		log.Printf("Error checking BOM usage for material %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa pemakaian material", "details": err.Error()})
+		return
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Material masih digunakan pada BOM produksi"})
+		return
+	}
+
+	if err := db.Delete(&item).Error; err != nil {
+		log.Printf("Error deleting material with ID %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus data material", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// getMaterialsTrash menampilkan material yang sedang berada di trash
+func getMaterialsTrash(c *gin.Context) {
+	items := []Materials{}
+	if err := trash.List(db, &items); err != nil {
+		log.Printf("Error fetching deleted materials: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash material", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreMaterials memulihkan material dari trash
+func restoreMaterials(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &Materials{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Material tidak ditemukan di trash"})
+		} else {
+			log.Printf("Error restoring material with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan data material", "details": err.Error()})
+		}
+		return
+	}
+	var item Materials
+	db.First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
+// RegisterRoutes mendaftarkan rute API untuk modul materials
+func RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", getAllMaterials)
+	rg.GET("/", getAllMaterials)
+
+	rg.GET("/trash", getMaterialsTrash)
+	rg.GET("/:id", getMaterialsByID)
+
+	rg.POST("", createMaterials)
+	rg.POST("/", createMaterials)
+	rg.PUT("/:id", updateMaterials)
+	rg.PATCH("/:id", patchMaterials)
+	rg.DELETE("/:id", deleteMaterials)
+	rg.POST("/:id/restore", restoreMaterials)
+}
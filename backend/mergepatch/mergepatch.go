@@ -0,0 +1,203 @@
+// Package mergepatch mengimplementasikan JSON Merge Patch (RFC 7396) untuk endpoint PATCH.
+// Dokumen patch diterapkan pada representasi JSON resource; hanya field yang dikirim
+// yang divalidasi dan disimpan, kolom lain tidak disentuh.
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ContentType adalah media type resmi untuk JSON Merge Patch
+const ContentType = "application/merge-patch+json"
+
+var (
+	// ErrContentType dikembalikan jika Content-Type bukan merge-patch+json atau json
+	ErrContentType = errors.New("Content-Type harus application/merge-patch+json atau application/json")
+	// ErrNotObject dikembalikan jika dokumen patch bukan objek JSON
+	ErrNotObject = errors.New("dokumen patch harus berupa objek JSON")
+)
+
+// Patch - Dokumen merge patch yang sudah dibaca dari request body
+type Patch struct {
+	raw    []byte
+	fields map[string]json.RawMessage
+}
+
+// Bind membaca dan memvalidasi dokumen merge patch dari request body
+func Bind(c *gin.Context) (*Patch, error) {
+	if ct := c.GetHeader("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != ContentType && mediaType != "application/json") {
+			return nil, ErrContentType
+		}
+	}
+	raw, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse membaca dokumen merge patch; dokumen untuk resource harus berupa objek
+func Parse(raw []byte) (*Patch, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return nil, ErrNotObject
+	}
+	return &Patch{raw: raw, fields: fields}, nil
+}
+
+// Status memetakan error dari Bind ke HTTP status yang sesuai
+func Status(err error) int {
+	if errors.Is(err, ErrContentType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+// Has mengecek apakah field dikirim di patch (termasuk bernilai null)
+func (p *Patch) Has(key string) bool {
+	_, ok := p.fields[key]
+	return ok
+}
+
+// HasAny mengecek apakah salah satu field dikirim di patch
+func (p *Patch) HasAny(keys ...string) bool {
+	for _, k := range keys {
+		if p.Has(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blank mengembalikan field wajib (json key -> nilai setelah patch) yang dikirim di patch
+// tetapi bernilai kosong, terurut. Field wajib yang tidak dikirim tidak diperiksa.
+func (p *Patch) Blank(required map[string]string) []string {
+	var blank []string
+	for key, value := range required {
+		if p.Has(key) && strings.TrimSpace(value) == "" {
+			blank = append(blank, key)
+		}
+	}
+	sort.Strings(blank)
+	return blank
+}
+
+// Take mengambil field dari patch dan menandainya sudah ditangani sehingga tidak
+// ikut dipetakan oleh Columns. Dipakai untuk field yang disimpan dengan cara khusus.
+func (p *Patch) Take(key string) (json.RawMessage, bool) {
+	v, ok := p.fields[key]
+	delete(p.fields, key)
+	return v, ok
+}
+
+// Keys mengembalikan nama field yang (masih) ada di patch, terurut
+func (p *Patch) Keys() []string {
+	keys := make([]string, 0, len(p.fields))
+	for k := range p.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplyTo menerapkan patch pada representasi JSON current lalu menuliskan hasilnya ke target
+func (p *Patch) ApplyTo(current, target interface{}) error {
+	original, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := Apply(original, p.raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(merged, target)
+}
+
+// Columns memetakan field patch ke nama kolom model GORM berdasarkan tag json.
+// Primary key diabaikan; field yang tidak dikenal, relasi, field non-kolom dan
+// field pada readOnly dikembalikan sebagai rejected.
+func (p *Patch) Columns(db *gorm.DB, model interface{}, readOnly ...string) (columns, rejected []string, err error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, nil, err
+	}
+
+	blocked := make(map[string]bool, len(readOnly))
+	for _, k := range readOnly {
+		blocked[k] = true
+	}
+	byJSON := make(map[string]string)
+	primary := make(map[string]bool)
+	for _, field := range stmt.Schema.Fields {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if field.PrimaryKey {
+			primary[name] = true
+			continue
+		}
+		if field.DBName != "" && field.Updatable {
+			byJSON[name] = field.DBName
+		}
+	}
+
+	for _, key := range p.Keys() {
+		column, ok := byJSON[key]
+		switch {
+		case primary[key]:
+			// ID resource ditentukan oleh URL, nilai di body diabaikan
+		case !ok || blocked[key]:
+			rejected = append(rejected, key)
+		default:
+			columns = append(columns, column)
+		}
+	}
+	return columns, rejected, nil
+}
+
+// Apply menerapkan dokumen merge patch ke dokumen JSON asli sesuai RFC 7396
+func Apply(original, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &target); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = merge(targetObj[key], value)
+	}
+	return targetObj
+}
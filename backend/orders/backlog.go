@@ -0,0 +1,184 @@
+package orders
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// BacklogLine - Satu baris order terbuka yang masih memiliki sisa untuk dikirim
+type BacklogLine struct {
+	OrderID        int    `json:"order_id"`
+	OrderNumber    string `json:"orderNumber"`
+	Customer       string `json:"customer"`
+	Region         string `json:"region"`
+	Priority       string `json:"priority"`
+	RequestedDate  string `json:"requestedDate"`
+	OrderLineID    int    `json:"order_line_id"`
+	Product        string `json:"product"`
+	Quantity       int    `json:"quantity"`
+	Shipped        int    `json:"shipped"`
+	Outstanding    int    `json:"outstanding"`
+	InStock        int    `json:"inStock"`
+	ProduksiID     *int   `json:"produksi_id"`
+	ProduksiStatus string `json:"produksiStatus,omitempty"`
+	ProduksiEnd    string `json:"produksiEndDate,omitempty"`
+	DaysOverdue    int    `json:"daysOverdue"` // Hari lewat dari requestedDate, 0 jika belum jatuh tempo
+	Overdue        bool   `json:"overdue"`
+	Covered        bool   `json:"covered"`      // Stok hasil produksi sudah cukup untuk sisa order
+	Unplanned      bool   `json:"unplanned"`    // Belum ada job produksi maupun stok untuk memenuhi sisa
+	LateProduksi   bool   `json:"lateProduksi"` // Job produksi dijadwalkan selesai setelah requestedDate
+}
+
+// BacklogGroup - Rekap backlog per produk atau per unit pemesan
+type BacklogGroup struct {
+	Key         string `json:"key"`
+	Lines       int    `json:"lines"`
+	Outstanding int    `json:"outstanding"`
+	InStock     int    `json:"inStock"`
+	Overdue     int    `json:"overdue"`
+}
+
+// addLine menambahkan satu baris backlog ke rekap grup
+func (g *BacklogGroup) addLine(line BacklogLine) {
+	g.Lines++
+	g.Outstanding += line.Outstanding
+	g.InStock += line.InStock
+	if line.Overdue {
+		g.Overdue++
+	}
+}
+
+// sortedGroups mengurutkan rekap dari sisa order terbanyak
+func sortedGroups(groups map[string]*BacklogGroup) []BacklogGroup {
+	result := make([]BacklogGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Outstanding != result[j].Outstanding {
+			return result[i].Outstanding > result[j].Outstanding
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// getBacklog menampilkan baris order terbuka yang belum terkirim penuh, diurutkan berdasarkan
+// prioritas lalu tanggal permintaan, beserta rekap per produk dan per unit pemesan.
+// Filter opsional: ?customer=, ?region=, ?priority=
+func getBacklog(c *gin.Context) {
+	query := db.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("order_line_id") }).Preload("Lines.Produksi").
+		Where("status NOT IN ?", []string{statusSelesai, statusDibatalkan})
+	if customer := c.Query("customer"); customer != "" {
+		query = query.Where("customer LIKE ?", "%"+customer+"%")
+	}
+	if region := c.Query("region"); region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if priority := c.Query("priority"); priority != "" {
+		query = query.Where("priority = ?", priority)
+	}
+
+	var items []Order
+	if err := query.Find(&items).Error; err != nil {
+		log.Printf("Error fetching orders for backlog: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		return
+	}
+	if err := attachFulfilment(items); err != nil {
+		log.Printf("Error computing backlog fulfilment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pemenuhan order", "details": err.Error()})
+		return
+	}
+
+	today := dateutil.Today()
+	lines := []BacklogLine{}
+	orders := make(map[int]bool)
+	byProduct := make(map[string]*BacklogGroup)
+	byCustomer := make(map[string]*BacklogGroup)
+	outstanding, overdue := 0, 0
+	for _, item := range items {
+		requested, errRequested := time.Parse(dateutil.Layout, item.RequestedDate)
+		for _, l := range item.Lines {
+			if l.Outstanding <= 0 {
+				continue
+			}
+			row := BacklogLine{
+				OrderID:       item.OrderID,
+				OrderNumber:   item.OrderNumber,
+				Customer:      item.Customer,
+				Region:        item.Region,
+				Priority:      item.Priority,
+				RequestedDate: item.RequestedDate,
+				OrderLineID:   l.OrderLineID,
+				Product:       l.Product,
+				Quantity:      l.Quantity,
+				Shipped:       l.Shipped,
+				Outstanding:   l.Outstanding,
+				InStock:       l.InStock,
+				ProduksiID:    l.ProduksiID,
+			}
+			row.Covered = row.InStock >= row.Outstanding
+			row.Unplanned = row.ProduksiID == nil && row.InStock == 0
+			if l.Produksi != nil {
+				row.ProduksiStatus = l.Produksi.Status
+				row.ProduksiEnd = l.Produksi.EndDate
+			}
+			if errRequested == nil {
+				if today.After(requested) {
+					row.Overdue = true
+					row.DaysOverdue = int(math.Round(today.Sub(requested).Hours() / 24))
+				}
+				if end, err := time.Parse(dateutil.Layout, row.ProduksiEnd); err == nil && !row.Covered && end.After(requested) {
+					row.LateProduksi = true
+				}
+			}
+
+			lines = append(lines, row)
+			orders[item.OrderID] = true
+			outstanding += row.Outstanding
+			if row.Overdue {
+				overdue++
+			}
+			if byProduct[row.Product] == nil {
+				byProduct[row.Product] = &BacklogGroup{Key: row.Product}
+			}
+			byProduct[row.Product].addLine(row)
+			if byCustomer[row.Customer] == nil {
+				byCustomer[row.Customer] = &BacklogGroup{Key: row.Customer}
+			}
+			byCustomer[row.Customer].addLine(row)
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		pi, pj := priorityRank[lines[i].Priority], priorityRank[lines[j].Priority]
+		if pi != pj {
+			return pi < pj
+		}
+		if lines[i].RequestedDate != lines[j].RequestedDate {
+			return lines[i].RequestedDate < lines[j].RequestedDate
+		}
+		return lines[i].OrderLineID < lines[j].OrderLineID
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"lines": lines,
+		"summary": gin.H{
+			"orders":      len(orders),
+			"lines":       len(lines),
+			"outstanding": outstanding,
+			"overdue":     overdue,
+		},
+		"by_product":  sortedGroups(byProduct),
+		"by_customer": sortedGroups(byCustomer),
+	})
+}
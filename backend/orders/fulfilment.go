@@ -0,0 +1,474 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dateutil"
+	"kai-backend/produksi"
+)
+
+// Shipment - Pengiriman sebagian/seluruh baris order ke unit pemesan.
+// Barang diambil dari stock_production sehingga stok berkurang sebesar Quantity.
+type Shipment struct {
+	ShipmentID  int       `json:"id" gorm:"column:shipment_id;primaryKey;autoIncrement"`
+	OrderLineID int       `json:"order_line_id" gorm:"column:order_line_id"`
+	StockID     *int      `json:"stock_id" gorm:"column:stock_id"`
+	Quantity    int       `json:"quantity" gorm:"column:quantity"`
+	ShippedDate string    `json:"shippedDate" gorm:"column:shipped_date"`
+	Reference   string    `json:"reference" gorm:"column:reference"` // Nomor surat jalan/BAST
+	Notes       string    `json:"notes" gorm:"column:notes"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (Shipment) TableName() string {
+	return "customer_shipment"
+}
+
+// StockShortageError dikembalikan jika stok hasil produksi tidak cukup untuk pengiriman
+type StockShortageError struct {
+	Requested int
+	Available int
+}
+
+func (e *StockShortageError) Error() string {
+	return fmt.Sprintf("Stok tidak mencukupi: diminta %d, tersedia %d", e.Requested, e.Available)
+}
+
+// errOverShipment dikembalikan jika pengiriman melebihi sisa quantity baris order
+var errOverShipment = errors.New("Jumlah pengiriman melebihi sisa order")
+
+// errLineLinked dikembalikan jika baris order sudah ditautkan ke job produksi oleh request lain
+var errLineLinked = errors.New("Baris order sudah terhubung ke job produksi")
+
+// shippedQty menghitung total unit yang sudah dikirim untuk satu baris order
+func shippedQty(tx *gorm.DB, lineID int) (int, error) {
+	var shipped int
+	err := tx.Model(&Shipment{}).Where("order_line_id = ?", lineID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&shipped).Error
+	return shipped, err
+}
+
+// attachFulfilment mengisi pemenuhan per baris (diproduksi, di stok, dikirim, sisa)
+// dan rekap per order. Lines beserta Produksi harus sudah dimuat.
+func attachFulfilment(items []Order) error {
+	var lineIDs, produksiIDs []int
+	for _, item := range items {
+		for _, line := range item.Lines {
+			lineIDs = append(lineIDs, line.OrderLineID)
+			if line.ProduksiID != nil {
+				produksiIDs = append(produksiIDs, *line.ProduksiID)
+			}
+		}
+	}
+
+	shipped := make(map[int]int)
+	if len(lineIDs) > 0 {
+		var rows []struct {
+			OrderLineID int
+			Total       int
+		}
+		if err := db.Model(&Shipment{}).Select("order_line_id, COALESCE(SUM(quantity), 0) AS total").
+			Where("order_line_id IN ?", lineIDs).Group("order_line_id").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			shipped[r.OrderLineID] = r.Total
+		}
+	}
+
+	stock := make(map[int]int)
+	if len(produksiIDs) > 0 {
+		var rows []struct {
+			ProduksiID int
+			Total      int
+		}
+		if err := db.Model(&StockProduction{}).Select("produksi_id, COALESCE(SUM(quantity), 0) AS total").
+			Where("produksi_id IN ?", produksiIDs).Group("produksi_id").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			stock[r.ProduksiID] = r.Total
+		}
+	}
+
+	for i := range items {
+		item := &items[i]
+		item.TotalQty, item.ShippedQty, item.OutstandingQty = 0, 0, 0
+		for j := range item.Lines {
+			line := &item.Lines[j]
+			line.Shipped = shipped[line.OrderLineID]
+			line.Outstanding = line.Quantity - line.Shipped
+			if line.Outstanding < 0 {
+				line.Outstanding = 0
+			}
+			if line.ProduksiID != nil {
+				line.InStock = stock[*line.ProduksiID]
+			}
+			if line.Produksi != nil {
+				line.Produced = line.Produksi.Completed
+			}
+			item.TotalQty += line.Quantity
+			item.ShippedQty += line.Shipped
+			item.OutstandingQty += line.Outstanding
+		}
+		item.FulfilledPct = 0
+		if item.TotalQty > 0 {
+			item.FulfilledPct = math.Round(float64(item.TotalQty-item.OutstandingQty)/float64(item.TotalQty)*1000) / 10
+		}
+	}
+	return nil
+}
+
+// recomputeOrder menghitung ulang status order dari job produksi dan pengiriman baris-barisnya.
+// Order yang dibatalkan tidak diubah.
+func recomputeOrder(tx *gorm.DB, orderID int) error {
+	var item Order
+	if err := tx.First(&item, orderID).Error; err != nil {
+		return err
+	}
+	if item.Status == statusDibatalkan {
+		return nil
+	}
+
+	var lines []OrderLine
+	if err := tx.Where("order_id = ?", orderID).Find(&lines).Error; err != nil {
+		return err
+	}
+
+	status := statusBaru
+	complete, anyShipped, anyProduksi := len(lines) > 0, false, false
+	for _, line := range lines {
+		shipped, err := shippedQty(tx, line.OrderLineID)
+		if err != nil {
+			return err
+		}
+		if shipped < line.Quantity {
+			complete = false
+		}
+		anyShipped = anyShipped || shipped > 0
+		anyProduksi = anyProduksi || line.ProduksiID != nil
+	}
+	switch {
+	case complete:
+		status = statusSelesai
+	case anyShipped:
+		status = statusSebagian
+	case anyProduksi:
+		status = statusDiproses
+	}
+	if status == item.Status {
+		return nil
+	}
+	return tx.Model(&item).Update("status", status).Error
+}
+
+// orderOpen menolak perubahan pemenuhan pada order yang dibatalkan atau sudah selesai
+func orderOpen(c *gin.Context, item Order) bool {
+	if item.Status == statusDibatalkan || item.Status == statusSelesai {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Order berstatus %s", item.Status)})
+		return false
+	}
+	return true
+}
+
+// spawnProduksi membuat job produksi untuk memenuhi sisa quantity baris order lalu
+// menautkannya ke baris tersebut. Personel dan BOM diatur lewat modul produksi.
+func spawnProduksi(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+	line, ok := findLine(c, item)
+	if !ok || !orderOpen(c, item) {
+		return
+	}
+	if line.ProduksiID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Baris order sudah terhubung ke job produksi", "produksi_id": *line.ProduksiID})
+		return
+	}
+
+	var req struct {
+		Name      string  `json:"name"`
+		Target    int     `json:"target"`
+		StartDate string  `json:"startDate"`
+		EndDate   string  `json:"endDate"`
+		Budget    float64 `json:"budget"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+
+	shipped, err := shippedQty(db, line.OrderLineID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pengiriman", "details": err.Error()})
+		return
+	}
+	if req.Target == 0 {
+		req.Target = line.Quantity - shipped
+	}
+	if req.Target <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target harus lebih dari 0; baris order sudah terkirim seluruhnya"})
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		req.Name = fmt.Sprintf("%s (%s)", line.Product, item.OrderNumber)
+	}
+
+	// Job dibuat lewat modul produksi agar validasi jadwal dan personel sama dengan POST /api/produksi
+	var job produksi.Produksi
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		job, err = produksi.CreateJob(tx, produksi.NewJob{
+			Name:          req.Name,
+			Target:        req.Target,
+			StartDate:     req.StartDate,
+			EndDate:       req.EndDate,
+			Budget:        req.Budget,
+			AllowConflict: c.Query("allow_conflict") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		// Tautan hanya dipasang jika baris belum terhubung, sehingga submit ganda tidak
+		// meninggalkan job produksi yatim
+		result := tx.Model(&OrderLine{}).Where("order_line_id = ? AND produksi_id IS NULL", line.OrderLineID).
+			Update("produksi_id", job.ProduksiID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLineLinked
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	var invalid *produksi.JobError
+	if errors.As(err, &invalid) {
+		c.JSON(invalid.Status, invalid.Body())
+		return
+	}
+	if errors.Is(err, errLineLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error spawning produksi for order line %d: %v", line.OrderLineID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat job produksi", "details": err.Error()})
+		return
+	}
+	log.Printf("Spawned produksi %d for order %s line %d", job.ProduksiID, item.OrderNumber, line.OrderLineID)
+
+	order, err := loadOrder(item.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		return
+	}
+	response := gin.H{"produksi": job, "order": order}
+	end, _ := time.Parse(dateutil.Layout, job.EndDate) // Sudah divalidasi CreateJob
+	if requested, err := time.Parse(dateutil.Layout, item.RequestedDate); err == nil && end.After(requested) {
+		response["warning"] = fmt.Sprintf("endDate job (%s) melewati tanggal permintaan order (%s)", req.EndDate, item.RequestedDate)
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// getShipments menampilkan seluruh pengiriman order
+func getShipments(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+
+	var shipments []Shipment
+	if err := db.Where("order_line_id IN (?)", db.Model(&OrderLine{}).Select("order_line_id").Where("order_id = ?", item.OrderID)).
+		Order("shipped_date, shipment_id").Find(&shipments).Error; err != nil {
+		log.Printf("Error fetching shipments for order %d: %v", item.OrderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pengiriman", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, shipments)
+}
+
+// issueStock mengurangi stock_production untuk pengiriman dan mencatat Shipment per baris stok.
+// Jika stock_id tidak diisi, stok diambil dari hasil job produksi baris order (urut stock_id).
+func issueStock(tx *gorm.DB, line OrderLine, req Shipment) ([]Shipment, error) {
+	var sources []StockProduction
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if req.StockID != nil {
+		query = query.Where("stock_id = ?", *req.StockID)
+	} else {
+		query = query.Where("produksi_id = ? AND quantity > 0", *line.ProduksiID).Order("stock_id")
+	}
+	if err := query.Find(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	available := 0
+	for _, s := range sources {
+		available += s.Quantity
+	}
+	if available < req.Quantity {
+		return nil, &StockShortageError{Requested: req.Quantity, Available: available}
+	}
+
+	var created []Shipment
+	remaining := req.Quantity
+	now := time.Now()
+	for _, s := range sources {
+		if remaining == 0 {
+			break
+		}
+		take := s.Quantity
+		if take > remaining {
+			take = remaining
+		}
+		if take <= 0 {
+			continue
+		}
+		if err := tx.Model(&StockProduction{}).Where("stock_id = ?", s.StockID).Updates(map[string]interface{}{
+			"quantity":    gorm.Expr("quantity - ?", take),
+			"last_update": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		stockID := s.StockID
+		shipment := Shipment{
+			OrderLineID: line.OrderLineID,
+			StockID:     &stockID,
+			Quantity:    take,
+			ShippedDate: req.ShippedDate,
+			Reference:   req.Reference,
+			Notes:       req.Notes,
+			CreatedAt:   now,
+		}
+		if err := tx.Create(&shipment).Error; err != nil {
+			return nil, err
+		}
+		created = append(created, shipment)
+		remaining -= take
+	}
+	return created, nil
+}
+
+// createShipment mencatat pengiriman baris order dari stock_production
+func createShipment(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+	line, ok := findLine(c, item)
+	if !ok || !orderOpen(c, item) {
+		return
+	}
+
+	var req Shipment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if req.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity harus lebih dari 0"})
+		return
+	}
+	if req.ShippedDate == "" {
+		req.ShippedDate = time.Now().Format(dateutil.Layout)
+	}
+	if _, err := time.Parse(dateutil.Layout, req.ShippedDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("shippedDate '%s' tidak valid, gunakan format YYYY-MM-DD", req.ShippedDate)})
+		return
+	}
+	if req.StockID == nil && line.ProduksiID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stock_id wajib diisi karena baris order belum terhubung ke job produksi"})
+		return
+	}
+
+	var created []Shipment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		shipped, err := shippedQty(tx, line.OrderLineID)
+		if err != nil {
+			return err
+		}
+		if outstanding := line.Quantity - shipped; req.Quantity > outstanding {
+			return fmt.Errorf("%w: sisa baris order %d unit", errOverShipment, outstanding)
+		}
+		if created, err = issueStock(tx, line, req); err != nil {
+			return err
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	var shortage *StockShortageError
+	switch {
+	case errors.As(err, &shortage):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "requested": shortage.Requested, "available": shortage.Available})
+		return
+	case errors.Is(err, errOverShipment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Error creating shipment for order line %d: %v", line.OrderLineID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data pengiriman", "details": err.Error()})
+		return
+	}
+
+	order, err := loadOrder(item.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"shipments": created, "order": order})
+}
+
+// deleteShipment membatalkan pengiriman dan mengembalikan barang ke stock_production
+func deleteShipment(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+	shipmentID, err := strconv.Atoi(c.Param("shipmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID pengiriman tidak valid"})
+		return
+	}
+
+	var shipment Shipment
+	if err := db.Joins("JOIN customer_order_line l ON l.order_line_id = customer_shipment.order_line_id").
+		Where("l.order_id = ?", item.OrderID).First(&shipment, shipmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data pengiriman tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pengiriman", "details": err.Error()})
+		}
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if shipment.StockID != nil {
+			if err := tx.Model(&StockProduction{}).Where("stock_id = ?", *shipment.StockID).Updates(map[string]interface{}{
+				"quantity":    gorm.Expr("quantity + ?", shipment.Quantity),
+				"last_update": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&Shipment{}, shipment.ShipmentID).Error; err != nil {
+			return err
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	if err != nil {
+		log.Printf("Error deleting shipment %d: %v", shipment.ShipmentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus data pengiriman", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,742 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dateutil"
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
+)
+
+// Status order. Selain Dibatalkan, status dihitung ulang dari job produksi dan pengiriman.
+const (
+	statusBaru       = "Baru"
+	statusDiproses   = "Dalam Proses"
+	statusSebagian   = "Dikirim Sebagian"
+	statusSelesai    = "Selesai"
+	statusDibatalkan = "Dibatalkan"
+)
+
+// Prioritas order, dari yang paling mendesak
+const (
+	priorityMendesak = "Mendesak"
+	priorityTinggi   = "Tinggi"
+	priorityNormal   = "Normal"
+	priorityRendah   = "Rendah"
+)
+
+// priorityRank dipakai untuk validasi dan pengurutan backlog
+var priorityRank = map[string]int{priorityMendesak: 0, priorityTinggi: 1, priorityNormal: 2, priorityRendah: 3}
+
+// Produksi - Referensi minimal ke tabel 'produksi' (dikelola lewat modul produksi)
+type Produksi struct {
+	ProduksiID int     `json:"id" gorm:"column:produksi_id;primaryKey;autoIncrement"`
+	Name       string  `json:"name" gorm:"column:name"`
+	Target     int     `json:"target" gorm:"column:target"`
+	Completed  int     `json:"completed" gorm:"column:completed"`
+	Status     string  `json:"status" gorm:"column:status"`
+	StartDate  string  `json:"startDate" gorm:"column:start_date"`
+	EndDate    string  `json:"endDate" gorm:"column:end_date"`
+	Budget     float64 `json:"budget" gorm:"column:budget"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Job di trash dianggap tidak ada
+}
+
+func (Produksi) TableName() string {
+	return "produksi"
+}
+
+// StockProduction - Referensi minimal ke tabel 'stock_production' (hasil produksi siap kirim)
+type StockProduction struct {
+	StockID    int       `json:"id" gorm:"column:stock_id;primaryKey"`
+	ItemName   string    `json:"itemName" gorm:"column:item_name"`
+	Quantity   int       `json:"quantity" gorm:"column:quantity"`
+	Location   string    `json:"location" gorm:"column:location"`
+	Status     string    `json:"status" gorm:"column:status"`
+	LastUpdate time.Time `json:"lastUpdate" gorm:"column:last_update"`
+	ProduksiID *int      `json:"produksi_id,omitempty" gorm:"column:produksi_id"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Stok di trash tidak dapat dikirim
+}
+
+func (StockProduction) TableName() string {
+	return "stock_production"
+}
+
+// Order - Pesanan dari unit pemesan (depot/daop) ke Balai Yasa
+type Order struct {
+	OrderID       int       `json:"id" gorm:"column:order_id;primaryKey;autoIncrement"`
+	OrderNumber   string    `json:"orderNumber" gorm:"column:order_number"`
+	Customer      string    `json:"customer" gorm:"column:customer"` // Unit pemesan, mis. "Depo Lokomotif Jatinegara"
+	Region        string    `json:"region" gorm:"column:region"`     // Daop/Divre unit pemesan
+	RequestedDate string    `json:"requestedDate" gorm:"column:requested_date"`
+	Priority      string    `json:"priority" gorm:"column:priority"`
+	Status        string    `json:"status" gorm:"column:status"`
+	Notes         string    `json:"notes" gorm:"column:notes"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
+
+	Lines []OrderLine `json:"lines" gorm:"foreignKey:OrderID;references:OrderID"`
+
+	// Rekap pemenuhan seluruh baris, dihitung setiap kali order dimuat
+	TotalQty       int     `json:"totalQty" gorm:"-"`
+	ShippedQty     int     `json:"shippedQty" gorm:"-"`
+	OutstandingQty int     `json:"outstandingQty" gorm:"-"`
+	FulfilledPct   float64 `json:"fulfilledPct" gorm:"-"`
+}
+
+func (Order) TableName() string {
+	return "customer_order"
+}
+
+// OrderLine - Satu produk yang dipesan. Baris dapat dipenuhi oleh satu job produksi;
+// hasilnya masuk stock_production dan keluar lewat pengiriman (Shipment).
+type OrderLine struct {
+	OrderLineID int    `json:"id" gorm:"column:order_line_id;primaryKey;autoIncrement"`
+	OrderID     int    `json:"order_id" gorm:"column:order_id"`
+	Product     string `json:"product" gorm:"column:product"`
+	Quantity    int    `json:"quantity" gorm:"column:quantity"`
+	ProduksiID  *int   `json:"produksi_id" gorm:"column:produksi_id"`
+	Notes       string `json:"notes" gorm:"column:notes"`
+
+	Produksi *Produksi `json:"produksi,omitempty" gorm:"foreignKey:ProduksiID;references:ProduksiID"`
+
+	// Pemenuhan baris, dihitung dari job produksi, stok dan pengiriman
+	Produced    int `json:"produced" gorm:"-"`    // Unit selesai pada job produksi terkait
+	InStock     int `json:"inStock" gorm:"-"`     // Sisa stok hasil job produksi terkait di stock_production
+	Shipped     int `json:"shipped" gorm:"-"`     // Total unit yang sudah dikirim
+	Outstanding int `json:"outstanding" gorm:"-"` // Quantity - Shipped
+}
+
+func (OrderLine) TableName() string {
+	return "customer_order_line"
+}
+
+var db *gorm.DB
+
+func Init(database *gorm.DB) {
+	db = database
+	trash.Register(trash.Purger{Module: "orders", Model: &Order{}, Purge: purgeOrder})
+	log.Println("Orders module initialized.")
+}
+
+// orderNumber membentuk nomor order default dari ID
+func orderNumber(id int) string {
+	return fmt.Sprintf("ORD-%05d", id)
+}
+
+// validateOrder memeriksa field wajib, format tanggal dan prioritas order
+func validateOrder(item Order) string {
+	if strings.TrimSpace(item.Customer) == "" || item.RequestedDate == "" {
+		return "Field customer dan requestedDate wajib diisi"
+	}
+	if _, err := time.Parse(dateutil.Layout, item.RequestedDate); err != nil {
+		return fmt.Sprintf("requestedDate '%s' tidak valid, gunakan format YYYY-MM-DD", item.RequestedDate)
+	}
+	if _, ok := priorityRank[item.Priority]; !ok {
+		return fmt.Sprintf("Prioritas '%s' tidak valid (Mendesak, Tinggi, Normal, Rendah)", item.Priority)
+	}
+	if item.Status != "" && item.Status != statusDibatalkan && orderStatusRank(item.Status) < 0 {
+		return fmt.Sprintf("Status '%s' tidak valid", item.Status)
+	}
+	return ""
+}
+
+// orderStatusRank mengembalikan -1 untuk status yang tidak dikenal
+func orderStatusRank(status string) int {
+	for i, s := range []string{statusBaru, statusDiproses, statusSebagian, statusSelesai, statusDibatalkan} {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateLine memeriksa baris order dan job produksi yang dirujuk
+func validateLine(tx *gorm.DB, line OrderLine) string {
+	if strings.TrimSpace(line.Product) == "" {
+		return "Field product wajib diisi"
+	}
+	if line.Quantity <= 0 {
+		return "Quantity harus lebih dari 0"
+	}
+	if line.ProduksiID != nil {
+		var count int64
+		if err := tx.Model(&Produksi{}).Where("produksi_id = ?", *line.ProduksiID).Count(&count).Error; err != nil || count == 0 {
+			return fmt.Sprintf("Job produksi dengan ID %d tidak ditemukan", *line.ProduksiID)
+		}
+	}
+	return ""
+}
+
+// errProduksiLinked dikembalikan jika job produksi sudah ditautkan ke baris order lain
+var errProduksiLinked = errors.New("Job produksi sudah terhubung ke baris order lain")
+
+// lockProduksiLink mengunci job produksi yang akan ditautkan ke baris lineID (0 untuk baris baru)
+// dan menolak job yang sudah dipakai baris order lain, sehingga satu job memenuhi satu baris saja
+func lockProduksiLink(tx *gorm.DB, produksiID *int, lineID int) error {
+	if produksiID == nil {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Produksi{}, *produksiID).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := tx.Model(&OrderLine{}).Where("produksi_id = ? AND order_line_id <> ?", *produksiID, lineID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errProduksiLinked
+	}
+	return nil
+}
+
+// findOrder mengambil order berdasarkan parameter :id
+func findOrder(c *gin.Context) (Order, bool) {
+	var item Order
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return item, false
+	}
+	if err := db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data order tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		}
+		return item, false
+	}
+	return item, true
+}
+
+// findLine mengambil baris order berdasarkan parameter :lineId milik order
+func findLine(c *gin.Context, order Order) (OrderLine, bool) {
+	var line OrderLine
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID baris tidak valid"})
+		return line, false
+	}
+	if err := db.Where("order_id = ?", order.OrderID).First(&line, lineID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Baris order tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil baris order", "details": err.Error()})
+		}
+		return line, false
+	}
+	return line, true
+}
+
+// loadOrder memuat order lengkap dengan baris, job produksi dan rekap pemenuhan
+func loadOrder(id int) (Order, error) {
+	var item Order
+	if err := db.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("order_line_id") }).
+		Preload("Lines.Produksi").First(&item, id).Error; err != nil {
+		return item, err
+	}
+	items := []Order{item}
+	if err := attachFulfilment(items); err != nil {
+		return item, err
+	}
+	return items[0], nil
+}
+
+func respondOrder(c *gin.Context, status, id int) {
+	item, err := loadOrder(id)
+	if err != nil {
+		log.Printf("Error reloading order %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		return
+	}
+	c.JSON(status, item)
+}
+
+// getAllOrders menampilkan order, dapat difilter dengan ?status=, ?customer= dan ?priority=
+func getAllOrders(c *gin.Context) {
+	query := db.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("order_line_id") }).Preload("Lines.Produksi")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if customer := c.Query("customer"); customer != "" {
+		query = query.Where("customer LIKE ?", "%"+customer+"%")
+	}
+	if priority := c.Query("priority"); priority != "" {
+		query = query.Where("priority = ?", priority)
+	}
+
+	var items []Order
+	if err := query.Order("requested_date, order_id").Find(&items).Error; err != nil {
+		log.Printf("Error fetching orders: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data order", "details": err.Error()})
+		return
+	}
+	if err := attachFulfilment(items); err != nil {
+		log.Printf("Error computing order fulfilment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pemenuhan order", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func getOrderByID(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+	respondOrder(c, http.StatusOK, item.OrderID)
+}
+
+// createOrder membuat order beserta baris-barisnya. Nomor order dibuat otomatis jika kosong.
+func createOrder(c *gin.Context) {
+	var req Order
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if req.Priority == "" {
+		req.Priority = priorityNormal
+	}
+	if msg := validateOrder(req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	for _, line := range req.Lines {
+		if msg := validateLine(db, line); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if req.OrderNumber != "" && !orderNumberAvailable(c, req.OrderNumber, 0) {
+		return
+	}
+
+	req.OrderID = 0
+	req.CreatedAt = time.Now()
+	cancelled := req.Status == statusDibatalkan
+	req.Status = statusBaru
+	for i := range req.Lines {
+		req.Lines[i].OrderLineID = 0
+		req.Lines[i].Produksi = nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		linked := make(map[int]bool)
+		for _, line := range req.Lines {
+			if line.ProduksiID == nil {
+				continue
+			}
+			if linked[*line.ProduksiID] {
+				return errProduksiLinked
+			}
+			linked[*line.ProduksiID] = true
+			if err := lockProduksiLink(tx, line.ProduksiID, 0); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&req).Error; err != nil {
+			return err
+		}
+		if req.OrderNumber == "" {
+			req.OrderNumber = orderNumber(req.OrderID)
+			if err := tx.Model(&req).Update("order_number", req.OrderNumber).Error; err != nil {
+				return err
+			}
+		}
+		if cancelled {
+			return tx.Model(&req).Update("status", statusDibatalkan).Error
+		}
+		return recomputeOrder(tx, req.OrderID)
+	})
+	if errors.Is(err, errProduksiLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating order: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data order", "details": err.Error()})
+		return
+	}
+	respondOrder(c, http.StatusCreated, req.OrderID)
+}
+
+// orderNumberAvailable memastikan nomor order belum dipakai order lain, termasuk order di trash
+func orderNumberAvailable(c *gin.Context, number string, exceptID int) bool {
+	var count int64
+	if err := db.Unscoped().Model(&Order{}).Where("order_number = ? AND order_id <> ?", number, exceptID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa nomor order", "details": err.Error()})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Nomor order '%s' sudah dipakai", number)})
+		return false
+	}
+	return true
+}
+
+// applyStatus menyimpan pembatalan order; status lain selalu dihitung ulang dari pemenuhan
+func applyStatus(tx *gorm.DB, item Order) error {
+	if item.Status == statusDibatalkan {
+		return tx.Model(&item).Update("status", statusDibatalkan).Error
+	}
+	return recomputeOrder(tx, item.OrderID)
+}
+
+// updateOrder memperbarui header order. Baris dikelola lewat /api/orders/:id/lines.
+func updateOrder(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+
+	var req Order
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if req.Priority == "" {
+		req.Priority = priorityNormal
+	}
+	if msg := validateOrder(req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.OrderNumber == "" {
+		req.OrderNumber = orderNumber(item.OrderID)
+	}
+	if !orderNumberAvailable(c, req.OrderNumber, item.OrderID) {
+		return
+	}
+
+	item.OrderNumber = req.OrderNumber
+	item.Customer = req.Customer
+	item.Region = req.Region
+	item.RequestedDate = req.RequestedDate
+	item.Priority = req.Priority
+	item.Notes = req.Notes
+	item.Status = req.Status
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines", "status").Save(&item).Error; err != nil {
+			return err
+		}
+		return applyStatus(tx, item)
+	})
+	if err != nil {
+		log.Printf("Error updating order %d: %v", item.OrderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data order", "details": err.Error()})
+		return
+	}
+	respondOrder(c, http.StatusOK, item.OrderID)
+}
+
+// patchOrder memperbarui sebagian header order (JSON Merge Patch, RFC 7396).
+// status hanya dapat diisi "Dibatalkan" atau status lain untuk membuka kembali order.
+func patchOrder(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var merged Order
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	merged.OrderID = item.OrderID
+
+	if msg := validateOrder(merged); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if patch.Has("orderNumber") {
+		if merged.OrderNumber == "" {
+			merged.OrderNumber = orderNumber(item.OrderID)
+		}
+		if !orderNumberAvailable(c, merged.OrderNumber, item.OrderID) {
+			return
+		}
+	}
+
+	_, statusChanged := patch.Take("status")
+	columns, rejected, err := patch.Columns(db, &Order{}, "createdAt", "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah (baris order diubah lewat /lines)", "fields": rejected})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+				return err
+			}
+		}
+		if statusChanged {
+			return applyStatus(tx, merged)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error patching order %d: %v", item.OrderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data order", "details": err.Error()})
+		return
+	}
+	respondOrder(c, http.StatusOK, item.OrderID)
+}
+
+// deleteOrder memindahkan order yang belum memiliki pengiriman ke trash. Baris order tetap
+// disimpan untuk restore; job produksi yang di-spawn dari order tidak ikut dihapus.
+func deleteOrder(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+
+	var shipped int64
+	if err := db.Model(&Shipment{}).Joins("JOIN customer_order_line l ON l.order_line_id = customer_shipment.order_line_id").
+		Where("l.order_id = ?", item.OrderID).Count(&shipped).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa pengiriman order", "details": err.Error()})
+		return
+	}
+	if shipped > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order yang sudah memiliki pengiriman tidak dapat dihapus, batalkan order sebagai gantinya"})
+		return
+	}
+
+	if err := db.Delete(&Order{}, item.OrderID).Error; err != nil {
+		log.Printf("Error deleting order %d: %v", item.OrderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus data order", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// purgeOrder menghapus permanen order beserta barisnya
+func purgeOrder(tx *gorm.DB, id int) error {
+	if err := tx.Where("order_id = ?", id).Delete(&OrderLine{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Order{}, id).Error
+}
+
+// getOrderTrash menampilkan order yang sedang berada di trash
+func getOrderTrash(c *gin.Context) {
+	items := []Order{}
+	query := db.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("order_line_id") }).Preload("Lines.Produksi")
+	if err := trash.List(query, &items); err != nil {
+		log.Printf("Error fetching deleted orders: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash order", "details": err.Error()})
+		return
+	}
+	if err := attachFulfilment(items); err != nil {
+		log.Printf("Error computing order fulfilment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pemenuhan order", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreOrder memulihkan order dari trash
+func restoreOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &Order{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data order tidak ditemukan di trash"})
+		} else {
+			log.Printf("Error restoring order %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan data order", "details": err.Error()})
+		}
+		return
+	}
+	respondOrder(c, http.StatusOK, id)
+}
+
+// createLine menambah baris pada order
+func createLine(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok {
+		return
+	}
+
+	if !orderOpen(c, item) {
+		return
+	}
+
+	var line OrderLine
+	if err := c.ShouldBindJSON(&line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if msg := validateLine(db, line); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	line.OrderLineID = 0
+	line.OrderID = item.OrderID
+	line.Produksi = nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduksiLink(tx, line.ProduksiID, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&line).Error; err != nil {
+			return err
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	if errors.Is(err, errProduksiLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating line for order %d: %v", item.OrderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan baris order", "details": err.Error()})
+		return
+	}
+	respondOrder(c, http.StatusCreated, item.OrderID)
+}
+
+// updateLine memperbarui baris order. Quantity tidak boleh di bawah jumlah yang sudah dikirim.
+func updateLine(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok || !orderOpen(c, item) {
+		return
+	}
+	line, ok := findLine(c, item)
+	if !ok {
+		return
+	}
+
+	var req OrderLine
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if msg := validateLine(db, req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	shipped, err := shippedQty(db, line.OrderLineID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pengiriman", "details": err.Error()})
+		return
+	}
+	if req.Quantity < shipped {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantity tidak boleh kurang dari jumlah yang sudah dikirim (%d)", shipped)})
+		return
+	}
+
+	line.Product = req.Product
+	line.Quantity = req.Quantity
+	line.ProduksiID = req.ProduksiID
+	line.Notes = req.Notes
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduksiLink(tx, line.ProduksiID, line.OrderLineID); err != nil {
+			return err
+		}
+		if err := tx.Omit("Produksi").Save(&line).Error; err != nil {
+			return err
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	if errors.Is(err, errProduksiLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating order line %d: %v", line.OrderLineID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan baris order", "details": err.Error()})
+		return
+	}
+	respondOrder(c, http.StatusOK, item.OrderID)
+}
+
+// deleteLine menghapus baris order yang belum memiliki pengiriman
+func deleteLine(c *gin.Context) {
+	item, ok := findOrder(c)
+	if !ok || !orderOpen(c, item) {
+		return
+	}
+	line, ok := findLine(c, item)
+	if !ok {
+		return
+	}
+
+	shipped, err := shippedQty(db, line.OrderLineID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung pengiriman", "details": err.Error()})
+		return
+	}
+	if shipped > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Baris order yang sudah memiliki pengiriman tidak dapat dihapus"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&OrderLine{}, line.OrderLineID).Error; err != nil {
+			return err
+		}
+		return recomputeOrder(tx, item.OrderID)
+	})
+	if err != nil {
+		log.Printf("Error deleting order line %d: %v", line.OrderLineID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus baris order", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", getAllOrders)
+	rg.GET("/", getAllOrders)
+
+	// Laporan backlog order terbuka
+	rg.GET("/backlog", getBacklog)
+	rg.GET("/trash", getOrderTrash)
+
+	rg.GET("/:id", getOrderByID)
+	rg.POST("", createOrder)
+	rg.POST("/", createOrder)
+	rg.PUT("/:id", updateOrder)
+	rg.PATCH("/:id", patchOrder)
+	rg.DELETE("/:id", deleteOrder)
+	rg.POST("/:id/restore", restoreOrder)
+
+	// Baris order dan job produksi untuk memenuhinya
+	rg.POST("/:id/lines", createLine)
+	rg.PUT("/:id/lines/:lineId", updateLine)
+	rg.DELETE("/:id/lines/:lineId", deleteLine)
+	rg.POST("/:id/lines/:lineId/produksi", spawnProduksi)
+
+	// Pengiriman dari stock_production ke unit pemesan
+	rg.GET("/:id/shipments", getShipments)
+	rg.POST("/:id/lines/:lineId/shipments", createShipment)
+	rg.DELETE("/:id/shipments/:shipmentId", deleteShipment)
+}
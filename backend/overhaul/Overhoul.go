@@ -1,6 +1,7 @@
 package overhaul
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,23 +9,51 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Overhaul mewakili struktur data untuk tabel 'overhaul'
 type Overhaul struct {
-	OverhaulID   int        `json:"id" gorm:"column:overhaul_id;primaryKey;autoIncrement"`
-	Name         string     `json:"name" gorm:"column:name"`
-	Location     *string    `json:"location,omitempty" gorm:"column:location"`
-	Status       string     `json:"status" gorm:"column:status"`
-	Estimate     string     `json:"estimate,omitempty" gorm:"column:estimate;type:datetime"`
-	Progress     int        `json:"progress" gorm:"column:progress"`
-	PersonaliaID *int       `json:"personalia_id,omitempty" gorm:"column:personalia_id"` // UBAH INI KE *int
-	MaterialsID  *int       `json:"materials_id,omitempty" gorm:"column:materials_id"`   // UBAH INI KE *int
-	HistoryID    *int       `json:"history_id,omitempty" gorm:"column:history_id"`       // UBAH INI KE *int
-	InventoryID  *int       `json:"inventory_id,omitempty" gorm:"column:inventory_id"`   // UBAH INI KE *int
-	DeletedAt    *time.Time `json:"deleted_at,omitempty" gorm:"column:deleted_at;type:datetime"`
+	OverhaulID int    `json:"id" gorm:"column:overhaul_id;primaryKey;autoIncrement"`
+	Name       string `json:"name" gorm:"column:name"`
+	// EquipmentType menentukan template checklist yang dipakai untuk job card
+	EquipmentType string     `json:"equipmentType" gorm:"column:equipment_type"`
+	Location      *string    `json:"location,omitempty" gorm:"column:location"`
+	Status        string     `json:"status" gorm:"column:status"`
+	Estimate      *time.Time `json:"estimate,omitempty" gorm:"column:estimate;type:datetime"` // Estimasi selesai
+	Progress      int        `json:"progress" gorm:"column:progress"`
+	PersonaliaID  *int       `json:"personalia_id,omitempty" gorm:"column:personalia_id"` // UBAH INI KE *int
+	MaterialsID   *int       `json:"materials_id,omitempty" gorm:"column:materials_id"`   // UBAH INI KE *int
+	HistoryID     *int       `json:"history_id,omitempty" gorm:"column:history_id"`       // UBAH INI KE *int
+	InventoryID   *int       `json:"inventory_id,omitempty" gorm:"column:inventory_id"`   // UBAH INI KE *int
+	AssetID       *int       `json:"asset_id,omitempty" gorm:"column:asset_id"`
+	IntervalID    *int       `json:"interval_id,omitempty" gorm:"column:interval_id"` // Siklus perawatan aset yang dijadwalkan
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;type:datetime"`
+
+	// Timestamp berikut diisi server saat status berubah (lihat applyStatusChange)
+	StartedAt       *time.Time `json:"started_at,omitempty" gorm:"column:started_at;type:datetime"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at;type:datetime"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" gorm:"column:status_changed_at;type:datetime"`
+
+	// Crew dimuat dari tabel overhaul_crew; saat create dapat diisi untuk menugaskan kru sekaligus
+	Crew []CrewMember `json:"crew" gorm:"-"`
+	// Turnaround dan SLA dihitung saat dibaca (lihat attachSLA)
+	TurnaroundDays *float64   `json:"turnaroundDays,omitempty" gorm:"-"`
+	SLATargetDays  *int       `json:"slaTargetDays,omitempty" gorm:"-"`
+	SLADueAt       *time.Time `json:"slaDueAt,omitempty" gorm:"-"`
+	SLABreached    bool       `json:"slaBreached" gorm:"-"`
 }
 
+// Status overhaul (nilai yang dipakai frontend)
+const (
+	statusBelumDimulai = "Belum Dimulai"
+	statusDalamProses  = "Dalam Proses"
+	statusSelesai      = "Selesai"
+)
+
 // TableName mengembalikan nama tabel di database untuk model Overhaul
 func (Overhaul) TableName() string {
 	return "overhaul"
@@ -35,6 +64,9 @@ var db *gorm.DB // Variabel global untuk instance GORM DB
 // Init menginisialisasi modul overhaul dengan instance database GORM
 func Init(dbInstance *gorm.DB) {
 	db = dbInstance
+	migrateEstimateColumn()
+	migrateLegacyCrews()
+	trash.Register(trash.Purger{Module: "overhaul", Model: &Overhaul{}, Purge: purgeOverhaul, AfterPurge: removeOverhaulPhotos})
 	log.Println("Overhaul module initialized.")
 	// Opsional: AutoMigrate jika Anda ingin GORM membuat/memperbarui tabel
 	// err := db.AutoMigrate(&Overhaul{})
@@ -46,11 +78,21 @@ func Init(dbInstance *gorm.DB) {
 // getAllOverhauls mengambil semua item overhaul dari database.
 func getAllOverhauls(c *gin.Context) {
 	var overhaulItems []Overhaul
-	if result := db.Where("deleted_at IS NULL").Find(&overhaulItems); result.Error != nil {
+	if result := db.Find(&overhaulItems); result.Error != nil {
 		log.Printf("Error fetching all overhaul items: %v", result.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul data", "details": result.Error.Error()})
 		return
 	}
+	if err := attachCrews(overhaulItems); err != nil {
+		log.Printf("Error fetching overhaul crews: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	if err := attachSLA(overhaulItems); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA targets", "details": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, overhaulItems)
 }
 
@@ -64,7 +106,7 @@ func getOverhaulByID(c *gin.Context) {
 	}
 
 	var item Overhaul
-	if result := db.Where("deleted_at IS NULL").First(&item, id); result.Error != nil {
+	if result := db.First(&item, id); result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Overhaul item not found"})
 		} else {
@@ -73,7 +115,18 @@ func getOverhaulByID(c *gin.Context) {
 		}
 		return
 	}
-	c.JSON(http.StatusOK, item)
+	items := []Overhaul{item}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching crew for overhaul %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	if err := attachSLA(items); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA targets", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items[0])
 }
 
 // createOverhaul menambahkan item overhaul baru ke database.
@@ -96,27 +149,71 @@ func createOverhaul(c *gin.Context) {
 		newItem.Progress = 0 // Default progress 0%
 	}
 	if newItem.Status == "" {
-		newItem.Status = "Belum Dimulai" // Default status
+		newItem.Status = statusBelumDimulai // Default status
 	}
 	// Karena PersonaliaID, MaterialsID, HistoryID, InventoryID sekarang *int,
 	// jika tidak ada di JSON, nilainya akan menjadi nil, yang akan disimpan sebagai NULL di DB.
 	// Jika ada di JSON dan nilainya 0, ia akan menjadi pointer ke 0.
 	// Kunci sekarang adalah memastikan frontend TIDAK mengirimkan 0 jika memang tidak ada relasi.
 
+	if msg := personaliaExists(newItem.PersonaliaID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if msg := validateAssetLink(db, &newItem); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	// Tanpa daftar kru, penanggung jawab (personalia_id) menjadi lead kru
+	crew := newItem.Crew
+	if len(crew) == 0 && newItem.PersonaliaID != nil {
+		crew = []CrewMember{{PersonaliaID: *newItem.PersonaliaID, Role: roleLead}}
+	}
+	crew, msg := resolveCrew(db, crew)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	newItem.OverhaulID = 0 // Biarkan GORM mengisi ID jika auto-increment
+	// Timestamp status dikelola server
+	newItem.StartedAt, newItem.CompletedAt, newItem.StatusChangedAt = nil, nil, nil
+	applyStatusChange(&newItem, "", time.Now())
 
 	log.Printf("Attempting to create overhaul item: %+v", newItem)
-	if result := db.Create(&newItem); result.Error != nil {
-		log.Printf("Error creating overhaul item in DB: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create overhaul item", "details": result.Error.Error()})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newItem).Error; err != nil {
+			return err
+		}
+		if err := recordStatusChange(tx, newItem, ""); err != nil {
+			return err
+		}
+		for _, m := range crew {
+			row := CrewMember{OverhaulID: newItem.OverhaulID, PersonaliaID: m.PersonaliaID, Role: m.Role, AssignedHours: m.AssignedHours}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error creating overhaul item in DB: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create overhaul item", "details": err.Error()})
 		return
 	}
 	log.Printf("Successfully created overhaul item with ID: %d", newItem.OverhaulID)
 
 	var createdItem Overhaul
 	db.First(&createdItem, newItem.OverhaulID)
+	items := []Overhaul{createdItem}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching crew for overhaul %d: %v", newItem.OverhaulID, err)
+	}
+	if err := attachSLA(items); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+	}
 
-	c.JSON(http.StatusCreated, createdItem)
+	c.JSON(http.StatusCreated, items[0])
 }
 
 // updateOverhaul memperbarui item overhaul di database.
@@ -145,9 +242,13 @@ func updateOverhaul(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress must be between 0 and 100."})
 		return
 	}
+	if msg := personaliaExists(updatedItem.PersonaliaID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
 	var item Overhaul
-	if result := db.Where("deleted_at IS NULL").First(&item, id); result.Error != nil {
+	if result := db.First(&item, id); result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Overhaul item not found"})
 		} else {
@@ -157,27 +258,180 @@ func updateOverhaul(c *gin.Context) {
 		return
 	}
 
+	previousStatus := item.Status
+
 	// Update fields (ini akan bekerja dengan *int)
 	item.Name = updatedItem.Name
 	item.Location = updatedItem.Location
 	item.Status = updatedItem.Status
 	item.Estimate = updatedItem.Estimate
-	item.Progress = updatedItem.Progress
+	item.EquipmentType = updatedItem.EquipmentType
+	// Progress dihitung dari langkah job card jika overhaul sudah memiliki job card
+	hasSteps, err := hasJobCardSteps(db, item.OverhaulID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job cards", "details": err.Error()})
+		return
+	}
+	if !hasSteps {
+		item.Progress = updatedItem.Progress
+	}
 	item.PersonaliaID = updatedItem.PersonaliaID
 	item.MaterialsID = updatedItem.MaterialsID
 	item.HistoryID = updatedItem.HistoryID
 	item.InventoryID = updatedItem.InventoryID
+	// Relasi aset tidak dilepas oleh PUT yang tidak mengirimnya; gunakan PATCH dengan null
+	if updatedItem.AssetID != nil {
+		item.AssetID = updatedItem.AssetID
+		item.IntervalID = updatedItem.IntervalID
+	}
+	if msg := validateAssetLink(db, &item); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	// started_at/completed_at/status_changed_at dari body diabaikan; diisi saat status berubah
+	applyStatusChange(&item, previousStatus, time.Now())
+
+	// Overhaul siklus aset yang selesai menjadwalkan overhaul berikutnya dalam transaksi yang sama
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		if err := recordStatusChange(tx, item, previousStatus); err != nil {
+			return err
+		}
+		if previousStatus != statusSelesai && item.Status == statusSelesai {
+			return completeInterval(tx, item)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error updating overhaul item with ID %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update overhaul item", "details": err.Error()})
+		return
+	}
+	items := []Overhaul{item}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching crew for overhaul %d: %v", id, err)
+	}
+	if err := attachSLA(items); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+	}
+
+	c.JSON(http.StatusOK, items[0])
+}
+
+// patchOverhaul memperbarui sebagian item overhaul (JSON Merge Patch, RFC 7396).
+// Foreign key yang tidak dikirim tidak berubah; kirim null untuk melepas relasi.
+func patchOverhaul(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid overhaul ID"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Invalid patch document", "details": err.Error()})
+		return
+	}
+
+	var item Overhaul
+	if result := db.First(&item, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Overhaul item not found"})
+		} else {
+			log.Printf("Error fetching overhaul item with ID %d for patch: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul data", "details": result.Error.Error()})
+		}
+		return
+	}
 
-	if result := db.Save(&item); result.Error != nil {
-		log.Printf("Error updating overhaul item with ID %d: %v", id, result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update overhaul item", "details": result.Error.Error()})
+	var merged Overhaul
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
 		return
 	}
+	merged.OverhaulID = item.OverhaulID
 
-	c.JSON(http.StatusOK, item)
+	if blank := patch.Blank(map[string]string{"name": merged.Name, "status": merged.Status}); len(blank) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields 'name' and 'status' cannot be empty.", "fields": blank})
+		return
+	}
+	if patch.Has("progress") && (merged.Progress < 0 || merged.Progress > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress must be between 0 and 100."})
+		return
+	}
+	if patch.Has("personalia_id") {
+		if msg := personaliaExists(merged.PersonaliaID); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if patch.Has("asset_id") || patch.Has("interval_id") {
+		if msg := validateAssetLink(db, &merged); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if patch.Has("progress") && merged.Progress != item.Progress {
+		hasSteps, err := hasJobCardSteps(db, item.OverhaulID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job cards", "details": err.Error()})
+			return
+		}
+		if hasSteps {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Progress is computed from job card steps and cannot be set manually"})
+			return
+		}
+	}
+
+	columns, rejected, err := patch.Columns(db, &Overhaul{}, "deleted_at", "started_at", "completed_at", "status_changed_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only fields", "fields": rejected})
+		return
+	}
+
+	if applyStatusChange(&merged, item.Status, time.Now()) {
+		columns = append(columns, "started_at", "completed_at", "status_changed_at")
+	}
+
+	if len(columns) > 0 {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+				return err
+			}
+			if err := recordStatusChange(tx, merged, item.Status); err != nil {
+				return err
+			}
+			if item.Status != statusSelesai && merged.Status == statusSelesai {
+				return completeInterval(tx, merged)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error patching overhaul item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update overhaul item", "details": err.Error()})
+			return
+		}
+	}
+	db.First(&item, id)
+	items := []Overhaul{item}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching crew for overhaul %d: %v", id, err)
+	}
+	if err := attachSLA(items); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+	}
+	c.JSON(http.StatusOK, items[0])
 }
 
-// deleteOverhaul menghapus item overhaul dari database (soft delete)
+// deleteOverhaul memindahkan item overhaul ke trash. Kru, part, job card dan riwayat status
+// tetap disimpan agar overhaul dapat dipulihkan utuh.
 func deleteOverhaul(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -197,9 +451,7 @@ func deleteOverhaul(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	item.DeletedAt = &now
-	if result := db.Save(&item); result.Error != nil {
+	if result := db.Delete(&item); result.Error != nil {
 		log.Printf("Error soft deleting overhaul item with ID %d: %v", id, result.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete overhaul item", "details": result.Error.Error()})
 		return
@@ -207,14 +459,139 @@ func deleteOverhaul(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// purgeOverhaul menghapus permanen overhaul beserta kru, part, job card dan riwayat statusnya.
+// Referensi dari siklus perawatan dan entri QC dilepas. File foto dihapus removeOverhaulPhotos
+// setelah transaksi berhasil.
+func purgeOverhaul(tx *gorm.DB, id int) error {
+	jobCards := tx.Model(&JobCard{}).Select("job_card_id").Where("overhaul_id = ?", id)
+	steps := tx.Model(&JobCardStep{}).Select("step_id").Where("job_card_id IN (?)", jobCards)
+	if err := tx.Where("step_id IN (?)", steps).Delete(&StepPhoto{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("job_card_id IN (?)", jobCards).Delete(&JobCardStep{}).Error; err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&JobCard{}, &CrewMember{}, &PartMovement{}, &Part{}, &StatusHistory{}} {
+		if err := tx.Where("overhaul_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&MaintenanceInterval{}).Where("last_overhaul_id = ?", id).Update("last_overhaul_id", nil).Error; err != nil {
+		return err
+	}
+	// Entri QC tetap tercatat, hanya referensinya yang dilepas
+	if err := tx.Table("quality_control").Where("overhaul_id = ?", id).Update("overhaul_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Overhaul{}, id).Error
+}
+
+// getOverhaulTrash mengambil item overhaul yang sedang berada di trash
+func getOverhaulTrash(c *gin.Context) {
+	items := []Overhaul{}
+	if err := trash.List(db, &items); err != nil {
+		log.Printf("Error fetching deleted overhaul items: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul trash", "details": err.Error()})
+		return
+	}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching overhaul crews: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreOverhaul memulihkan item overhaul dari trash
+func restoreOverhaul(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid overhaul ID"})
+		return
+	}
+	if err := trash.Restore(db, &Overhaul{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Overhaul item not found in trash"})
+		} else {
+			log.Printf("Error restoring overhaul item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore overhaul item", "details": err.Error()})
+		}
+		return
+	}
+	getOverhaulByID(c)
+}
+
 // RegisterRoutes mendaftarkan rute API untuk modul Overhaul
 func RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/", getAllOverhauls)
+
+	// Registri aset dan siklus perawatan
+	rg.GET("/assets", getAssets)
+	rg.POST("/assets", createAsset)
+	rg.GET("/assets/overdue", getOverdueAssets)
+	rg.GET("/assets/:assetId", getAsset)
+	rg.PUT("/assets/:assetId", updateAsset)
+	rg.DELETE("/assets/:assetId", deleteAsset)
+	rg.POST("/assets/:assetId/intervals", createInterval)
+	rg.PUT("/assets/:assetId/intervals/:intervalId", updateInterval)
+	rg.DELETE("/assets/:assetId/intervals/:intervalId", deleteInterval)
+
+	// Target SLA per jenis peralatan dan laporan turnaround
+	rg.GET("/sla-targets", getSLATargets)
+	rg.PUT("/sla-targets/:equipmentType", putSLATarget)
+	rg.DELETE("/sla-targets/:equipmentType", deleteSLATarget)
+	rg.GET("/reports/turnaround", getTurnaroundReport)
+
+	// Overhaul yang dihapus (trash)
+	rg.GET("/trash", getOverhaulTrash)
+
+	// Daftar overhaul terbuka per teknisi
+	rg.GET("/technicians/:personaliaId", getTechnicianOverhauls)
+
+	// Template checklist per jenis peralatan
+	rg.GET("/checklist-templates", getTemplates)
+	rg.POST("/checklist-templates", createTemplate)
+	rg.GET("/checklist-templates/:templateId", getTemplate)
+	rg.PUT("/checklist-templates/:templateId", updateTemplate)
+	rg.DELETE("/checklist-templates/:templateId", deleteTemplate)
+
 	rg.GET("", getAllOverhauls) // Untuk menangani /api/overhaul tanpa trailing slash
 	rg.GET("/:id", getOverhaulByID)
 	rg.POST("/", createOverhaul) // Sudah ada, ini untuk /api/overhaul/
 	rg.POST("", createOverhaul)  // <<< Ini yang ditambahkan untuk /api/overhaul
 	rg.PUT("/:id", updateOverhaul)
+	rg.PATCH("/:id", patchOverhaul)
 	rg.PUT("", updateOverhaul)
 	rg.DELETE("/:id", deleteOverhaul)
+	rg.POST("/:id/restore", restoreOverhaul)
+	rg.GET("/:id/status-history", getStatusHistory)
+
+	// Laporan penyelesaian overhaul untuk pemilik peralatan
+	rg.GET("/:id/report.pdf", exportReportPDF)
+
+	// Kru overhaul (banyak teknisi per pekerjaan)
+	rg.GET("/:id/crew", getCrew)
+	rg.POST("/:id/crew", addCrewMember)
+	rg.PUT("/:id/crew", replaceCrew)
+	rg.PUT("/:id/crew/:memberId", updateCrewMember)
+	rg.DELETE("/:id/crew/:memberId", removeCrewMember)
+
+	// Daftar part: rencana vs aktual, pengeluaran stok dan pengembalian part lepas
+	rg.GET("/:id/parts", getParts)
+	rg.POST("/:id/parts", createPart)
+	rg.PUT("/:id/parts/:partId", updatePart)
+	rg.DELETE("/:id/parts/:partId", deletePart)
+	rg.POST("/:id/parts/:partId/fit", fitPart)
+	rg.POST("/:id/parts/:partId/unfit", unfitPart)
+	rg.POST("/:id/parts/:partId/remove", removePart)
+
+	// Job card, tanda tangan langkah dan foto bukti
+	rg.GET("/:id/job-cards", getJobCards)
+	rg.POST("/:id/job-cards", createJobCards)
+	rg.DELETE("/:id/job-cards/:cardId", deleteJobCard)
+	rg.POST("/:id/job-cards/:cardId/steps/:stepId/sign-off", signOffStep)
+	rg.DELETE("/:id/job-cards/:cardId/steps/:stepId/sign-off", revokeSignOff)
+	rg.POST("/:id/job-cards/:cardId/steps/:stepId/photos", uploadStepPhoto)
+	rg.GET("/:id/job-cards/:cardId/steps/:stepId/photos/:photoId", getStepPhoto)
+	rg.DELETE("/:id/job-cards/:cardId/steps/:stepId/photos/:photoId", deleteStepPhoto)
 }
@@ -0,0 +1,704 @@
+package overhaul
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// Asset - Peralatan yang dirawat Balai Yasa (lokomotif, radio lokomotif, point machine, way station).
+// EquipmentType dipakai sebagai jenis peralatan overhaul dan pemilihan template checklist.
+type Asset struct {
+	AssetID        int       `json:"id" gorm:"column:asset_id;primaryKey;autoIncrement"`
+	AssetCode      string    `json:"assetCode" gorm:"column:asset_code"`
+	Name           string    `json:"name" gorm:"column:name"`
+	EquipmentType  string    `json:"equipmentType" gorm:"column:equipment_type"`
+	SerialNumber   string    `json:"serialNumber" gorm:"column:serial_number"`
+	Location       string    `json:"location" gorm:"column:location"`
+	InstalledDate  *string   `json:"installedDate" gorm:"column:installed_date"`
+	OperatingHours float64   `json:"operatingHours" gorm:"column:operating_hours"`
+	Active         bool      `json:"active" gorm:"column:active"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	Intervals []MaintenanceInterval `json:"intervals,omitempty" gorm:"-"`
+}
+
+func (Asset) TableName() string {
+	return "asset"
+}
+
+// MaintenanceInterval - Siklus overhaul aset berdasarkan waktu (hari) dan/atau jam operasi.
+// Saat overhaul siklus ini selesai, overhaul berikutnya dibuat otomatis.
+type MaintenanceInterval struct {
+	IntervalID         int        `json:"id" gorm:"column:interval_id;primaryKey;autoIncrement"`
+	AssetID            int        `json:"asset_id" gorm:"column:asset_id"`
+	Name               string     `json:"name" gorm:"column:name"`
+	IntervalDays       *int       `json:"intervalDays" gorm:"column:interval_days"`
+	IntervalHours      *float64   `json:"intervalHours" gorm:"column:interval_hours"`
+	LastCompletedAt    *time.Time `json:"lastCompletedAt" gorm:"column:last_completed_at"`
+	LastCompletedHours *float64   `json:"lastCompletedHours" gorm:"column:last_completed_hours"`
+	LastOverhaulID     *int       `json:"last_overhaul_id" gorm:"column:last_overhaul_id"`
+	Active             bool       `json:"active" gorm:"column:active"`
+
+	// Dihitung dari aset dan penyelesaian terakhir setiap kali dimuat
+	DueDate        string   `json:"dueDate,omitempty" gorm:"-"`
+	DaysRemaining  *int     `json:"daysRemaining,omitempty" gorm:"-"` // Negatif jika sudah lewat
+	DueHours       *float64 `json:"dueHours,omitempty" gorm:"-"`
+	HoursRemaining *float64 `json:"hoursRemaining,omitempty" gorm:"-"` // Negatif jika sudah lewat
+	Overdue        bool     `json:"overdue" gorm:"-"`
+	OpenOverhaulID *int     `json:"open_overhaul_id" gorm:"-"`
+}
+
+func (MaintenanceInterval) TableName() string {
+	return "asset_maintenance_interval"
+}
+
+// computeDue menghitung jatuh tempo interval. Titik awal adalah penyelesaian terakhir,
+// atau tanggal pasang aset (jam operasi 0) jika siklus belum pernah diselesaikan.
+func computeDue(iv *MaintenanceInterval, asset Asset, asOf time.Time) {
+	iv.DueDate, iv.DaysRemaining, iv.DueHours, iv.HoursRemaining, iv.Overdue = "", nil, nil, nil, false
+
+	if iv.IntervalDays != nil {
+		base, ok := time.Time{}, false
+		switch {
+		case iv.LastCompletedAt != nil:
+			base, ok = dateutil.Parse(iv.LastCompletedAt.Format(dateutil.Layout))
+		case asset.InstalledDate != nil:
+			base, ok = dateutil.Parse(*asset.InstalledDate)
+		}
+		if !ok {
+			base, _ = dateutil.Parse(asset.CreatedAt.Format(dateutil.Layout))
+		}
+		due := base.AddDate(0, 0, *iv.IntervalDays)
+		remaining := dateutil.DaysBetween(asOf, due)
+		iv.DueDate, iv.DaysRemaining = due.Format(dateutil.Layout), &remaining
+		iv.Overdue = remaining < 0
+	}
+	if iv.IntervalHours != nil {
+		due := *iv.IntervalHours
+		if iv.LastCompletedHours != nil {
+			due += *iv.LastCompletedHours
+		}
+		remaining := due - asset.OperatingHours
+		iv.DueHours, iv.HoursRemaining = &due, &remaining
+		iv.Overdue = iv.Overdue || remaining < 0
+	}
+}
+
+// openOverhaulFor mengambil overhaul siklus interval yang belum selesai (jika ada)
+func openOverhaulFor(tx *gorm.DB, intervalID int) (*Overhaul, error) {
+	var items []Overhaul
+	if err := tx.Where("interval_id = ? AND (status IS NULL OR status <> ?)", intervalID, statusSelesai).
+		Order("overhaul_id").Limit(1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+// loadIntervals memuat interval aset beserta jatuh tempo dan overhaul terbukanya
+func loadIntervals(tx *gorm.DB, asset Asset, asOf time.Time) ([]MaintenanceInterval, error) {
+	var intervals []MaintenanceInterval
+	if err := tx.Where("asset_id = ?", asset.AssetID).Order("interval_id").Find(&intervals).Error; err != nil {
+		return nil, err
+	}
+	for i := range intervals {
+		computeDue(&intervals[i], asset, asOf)
+		open, err := openOverhaulFor(tx, intervals[i].IntervalID)
+		if err != nil {
+			return nil, err
+		}
+		if open != nil {
+			intervals[i].OpenOverhaulID = &open.OverhaulID
+		}
+	}
+	return intervals, nil
+}
+
+// dueEstimate mengubah tanggal jatuh tempo interval menjadi estimasi selesai overhaul
+func dueEstimate(iv MaintenanceInterval) *time.Time {
+	due, ok := dateutil.Parse(iv.DueDate)
+	if !ok {
+		return nil
+	}
+	return &due
+}
+
+// scheduleNextOverhaul membuat overhaul berikutnya untuk interval aktif yang belum memiliki
+// overhaul terbuka. Estimate diisi tanggal jatuh tempo (khusus interval berbasis waktu).
+func scheduleNextOverhaul(tx *gorm.DB, asset Asset, iv MaintenanceInterval) (*Overhaul, error) {
+	if !asset.Active || !iv.Active {
+		return nil, nil
+	}
+	open, err := openOverhaulFor(tx, iv.IntervalID)
+	if err != nil || open != nil {
+		return nil, err
+	}
+
+	computeDue(&iv, asset, dateutil.Today())
+	location := asset.Location
+	assetID, intervalID := asset.AssetID, iv.IntervalID
+	item := Overhaul{
+		Name:          fmt.Sprintf("%s - %s", asset.Name, iv.Name),
+		EquipmentType: asset.EquipmentType,
+		Location:      &location,
+		Status:        statusBelumDimulai,
+		Estimate:      dueEstimate(iv),
+		AssetID:       &assetID,
+		IntervalID:    &intervalID,
+	}
+	applyStatusChange(&item, "", time.Now())
+	if err := tx.Create(&item).Error; err != nil {
+		return nil, err
+	}
+	if err := recordStatusChange(tx, item, ""); err != nil {
+		return nil, err
+	}
+	log.Printf("Scheduled overhaul %d for asset %s (%s), due %s", item.OverhaulID, asset.AssetCode, iv.Name, iv.DueDate)
+	return &item, nil
+}
+
+// completeInterval dipanggil saat overhaul berubah menjadi Selesai: penyelesaian siklus dicatat
+// (tanggal dan jam operasi aset saat ini) lalu overhaul siklus berikutnya dijadwalkan.
+func completeInterval(tx *gorm.DB, item Overhaul) error {
+	if item.IntervalID == nil {
+		return nil
+	}
+	var iv MaintenanceInterval
+	if err := tx.First(&iv, *item.IntervalID).Error; err != nil {
+		return err
+	}
+	var asset Asset
+	if err := tx.First(&asset, iv.AssetID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if item.CompletedAt != nil {
+		now = *item.CompletedAt
+	}
+	hours := asset.OperatingHours
+	iv.LastCompletedAt, iv.LastCompletedHours, iv.LastOverhaulID = &now, &hours, &item.OverhaulID
+	if err := tx.Model(&MaintenanceInterval{}).Where("interval_id = ?", iv.IntervalID).Updates(map[string]interface{}{
+		"last_completed_at":    now,
+		"last_completed_hours": hours,
+		"last_overhaul_id":     item.OverhaulID,
+	}).Error; err != nil {
+		return err
+	}
+	_, err := scheduleNextOverhaul(tx, asset, iv)
+	return err
+}
+
+// validateAssetLink memeriksa asset_id/interval_id overhaul. equipmentType dan lokasi yang
+// kosong diisi dari aset.
+func validateAssetLink(tx *gorm.DB, item *Overhaul) string {
+	if item.AssetID == nil {
+		if item.IntervalID != nil {
+			return "interval_id requires asset_id"
+		}
+		return ""
+	}
+	var asset Asset
+	if err := tx.First(&asset, *item.AssetID).Error; err != nil {
+		return fmt.Sprintf("Asset with ID %d not found", *item.AssetID)
+	}
+	if item.IntervalID != nil {
+		var iv MaintenanceInterval
+		if err := tx.Where("asset_id = ?", asset.AssetID).First(&iv, *item.IntervalID).Error; err != nil {
+			return fmt.Sprintf("Maintenance interval %d does not belong to asset %s", *item.IntervalID, asset.AssetCode)
+		}
+	}
+	if item.EquipmentType == "" {
+		item.EquipmentType = asset.EquipmentType
+	}
+	if item.Location == nil || *item.Location == "" {
+		location := asset.Location
+		item.Location = &location
+	}
+	return ""
+}
+
+// validateAsset memeriksa field wajib dan tanggal pasang aset
+func validateAsset(a *Asset) string {
+	a.AssetCode = strings.TrimSpace(a.AssetCode)
+	a.SerialNumber = strings.TrimSpace(a.SerialNumber)
+	a.Name = strings.TrimSpace(a.Name)
+	a.EquipmentType = strings.TrimSpace(a.EquipmentType)
+	if a.AssetCode == "" || a.Name == "" || a.EquipmentType == "" || a.SerialNumber == "" {
+		return "Fields 'assetCode', 'name', 'equipmentType' and 'serialNumber' are required."
+	}
+	if a.InstalledDate != nil && *a.InstalledDate == "" {
+		a.InstalledDate = nil
+	}
+	if a.InstalledDate != nil {
+		if _, err := time.Parse(dateutil.Layout, *a.InstalledDate); err != nil {
+			return fmt.Sprintf("Invalid installedDate '%s', use YYYY-MM-DD", *a.InstalledDate)
+		}
+	}
+	if a.OperatingHours < 0 {
+		return "operatingHours cannot be negative"
+	}
+	return ""
+}
+
+// assetIdentityTaken memeriksa keunikan kode aset dan nomor seri
+func assetIdentityTaken(a Asset) (string, error) {
+	var count int64
+	if err := db.Model(&Asset{}).Where("asset_code = ? AND asset_id <> ?", a.AssetCode, a.AssetID).Count(&count).Error; err != nil || count > 0 {
+		return fmt.Sprintf("Asset code '%s' is already registered", a.AssetCode), err
+	}
+	if err := db.Model(&Asset{}).Where("serial_number = ? AND asset_id <> ?", a.SerialNumber, a.AssetID).Count(&count).Error; err != nil || count > 0 {
+		return fmt.Sprintf("Serial number '%s' is already registered", a.SerialNumber), err
+	}
+	return "", nil
+}
+
+// findAsset mengambil aset berdasarkan parameter :assetId
+func findAsset(c *gin.Context) (Asset, bool) {
+	var asset Asset
+	assetID, err := strconv.Atoi(c.Param("assetId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID"})
+		return asset, false
+	}
+	if err := db.First(&asset, assetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch asset", "details": err.Error()})
+		}
+		return asset, false
+	}
+	if asset.InstalledDate != nil {
+		installed := dateutil.Format(*asset.InstalledDate)
+		asset.InstalledDate = &installed
+	}
+	return asset, true
+}
+
+// respondAsset mengirim aset beserta interval, jatuh tempo dan riwayat overhaulnya
+func respondAsset(c *gin.Context, status int, asset Asset) {
+	intervals, err := loadIntervals(db, asset, dateutil.Today())
+	if err != nil {
+		log.Printf("Error fetching intervals for asset %d: %v", asset.AssetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance intervals", "details": err.Error()})
+		return
+	}
+	asset.Intervals = intervals
+	if asset.Intervals == nil {
+		asset.Intervals = []MaintenanceInterval{}
+	}
+	var history []Overhaul
+	if err := db.Where("asset_id = ?", asset.AssetID).Order("overhaul_id DESC").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul history", "details": err.Error()})
+		return
+	}
+	if history == nil {
+		history = []Overhaul{}
+	}
+	c.JSON(status, gin.H{"asset": asset, "overhauls": history})
+}
+
+// getAssets menampilkan registri aset. Filter opsional: ?equipment_type=, ?location=, ?active=true|false
+func getAssets(c *gin.Context) {
+	query := db.Model(&Asset{})
+	if equipmentType := c.Query("equipment_type"); equipmentType != "" {
+		query = query.Where("equipment_type = ?", equipmentType)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active == "true")
+	}
+	var assets []Asset
+	if err := query.Order("equipment_type, asset_code").Find(&assets).Error; err != nil {
+		log.Printf("Error fetching assets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assets", "details": err.Error()})
+		return
+	}
+	for i := range assets {
+		if assets[i].InstalledDate != nil {
+			installed := dateutil.Format(*assets[i].InstalledDate)
+			assets[i].InstalledDate = &installed
+		}
+	}
+	if assets == nil {
+		assets = []Asset{}
+	}
+	c.JSON(http.StatusOK, assets)
+}
+
+func getAsset(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	respondAsset(c, http.StatusOK, asset)
+}
+
+// createAsset mendaftarkan aset baru (aktif secara default)
+func createAsset(c *gin.Context) {
+	asset := Asset{Active: true}
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	asset.AssetID = 0
+	if msg := validateAsset(&asset); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	msg, err := assetIdentityTaken(asset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check asset", "details": err.Error()})
+		return
+	}
+	if msg != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return
+	}
+
+	asset.CreatedAt = time.Now()
+	asset.Intervals = nil
+	if err := db.Create(&asset).Error; err != nil {
+		log.Printf("Error creating asset: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create asset", "details": err.Error()})
+		return
+	}
+	respondAsset(c, http.StatusCreated, asset)
+}
+
+// updateAsset memperbarui data aset. Jam operasi adalah pembacaan meter sehingga tidak boleh turun.
+func updateAsset(c *gin.Context) {
+	existing, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	var asset Asset
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	asset.AssetID = existing.AssetID
+	if msg := validateAsset(&asset); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if asset.OperatingHours < existing.OperatingHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operatingHours cannot decrease (current: %.1f)", existing.OperatingHours)})
+		return
+	}
+	msg, err := assetIdentityTaken(asset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check asset", "details": err.Error()})
+		return
+	}
+	if msg != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return
+	}
+
+	if err := db.Model(&Asset{}).Where("asset_id = ?", existing.AssetID).Updates(map[string]interface{}{
+		"asset_code":      asset.AssetCode,
+		"name":            asset.Name,
+		"equipment_type":  asset.EquipmentType,
+		"serial_number":   asset.SerialNumber,
+		"location":        asset.Location,
+		"installed_date":  asset.InstalledDate,
+		"operating_hours": asset.OperatingHours,
+		"active":          asset.Active,
+	}).Error; err != nil {
+		log.Printf("Error updating asset %d: %v", existing.AssetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update asset", "details": err.Error()})
+		return
+	}
+	if updated, ok := findAsset(c); ok {
+		respondAsset(c, http.StatusOK, updated)
+	}
+}
+
+// deleteAsset menghapus aset yang belum pernah dipakai overhaul; aset dengan riwayat dinonaktifkan saja
+func deleteAsset(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	var count int64
+	if err := db.Model(&Overhaul{}).Where("asset_id = ?", asset.AssetID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check overhaul history", "details": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Asset has overhaul history; set active to false instead of deleting"})
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("asset_id = ?", asset.AssetID).Delete(&MaintenanceInterval{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Asset{}, asset.AssetID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting asset %d: %v", asset.AssetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete asset", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// validateInterval memeriksa nama dan siklus (hari dan/atau jam operasi, minimal salah satu)
+func validateInterval(iv *MaintenanceInterval) string {
+	iv.Name = strings.TrimSpace(iv.Name)
+	if iv.Name == "" {
+		return "Interval name is required"
+	}
+	if iv.IntervalDays == nil && iv.IntervalHours == nil {
+		return "intervalDays or intervalHours is required"
+	}
+	if iv.IntervalDays != nil && *iv.IntervalDays <= 0 {
+		return "intervalDays must be greater than 0"
+	}
+	if iv.IntervalHours != nil && *iv.IntervalHours <= 0 {
+		return "intervalHours must be greater than 0"
+	}
+	return ""
+}
+
+// findInterval mengambil interval berdasarkan parameter :intervalId milik aset
+func findInterval(c *gin.Context, asset Asset) (MaintenanceInterval, bool) {
+	var iv MaintenanceInterval
+	intervalID, err := strconv.Atoi(c.Param("intervalId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval ID"})
+		return iv, false
+	}
+	if err := db.Where("asset_id = ?", asset.AssetID).First(&iv, intervalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance interval not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance interval", "details": err.Error()})
+		}
+		return iv, false
+	}
+	return iv, true
+}
+
+// createInterval menambahkan siklus perawatan aset dan langsung menjadwalkan overhaul pertamanya
+func createInterval(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	iv := MaintenanceInterval{Active: true}
+	if err := c.ShouldBindJSON(&iv); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateInterval(&iv); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	iv.IntervalID, iv.AssetID = 0, asset.AssetID
+	iv.LastOverhaulID = nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&iv).Error; err != nil {
+			return err
+		}
+		_, err := scheduleNextOverhaul(tx, asset, iv)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error creating maintenance interval for asset %d: %v", asset.AssetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create maintenance interval", "details": err.Error()})
+		return
+	}
+	respondAsset(c, http.StatusCreated, asset)
+}
+
+// updateInterval mengubah siklus perawatan. Estimate overhaul terjadwal yang belum dimulai
+// ikut disesuaikan dengan jatuh tempo baru.
+func updateInterval(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	iv, ok := findInterval(c, asset)
+	if !ok {
+		return
+	}
+	var req MaintenanceInterval
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateInterval(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	iv.Name, iv.IntervalDays, iv.IntervalHours, iv.Active = req.Name, req.IntervalDays, req.IntervalHours, req.Active
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&MaintenanceInterval{}).Where("interval_id = ?", iv.IntervalID).Updates(map[string]interface{}{
+			"name":           iv.Name,
+			"interval_days":  iv.IntervalDays,
+			"interval_hours": iv.IntervalHours,
+			"active":         iv.Active,
+		}).Error; err != nil {
+			return err
+		}
+		open, err := openOverhaulFor(tx, iv.IntervalID)
+		if err != nil {
+			return err
+		}
+		if open == nil {
+			_, err := scheduleNextOverhaul(tx, asset, iv)
+			return err
+		}
+		if open.Status != statusBelumDimulai {
+			return nil
+		}
+		computeDue(&iv, asset, dateutil.Today())
+		return tx.Model(&Overhaul{}).Where("overhaul_id = ?", open.OverhaulID).Update("estimate", dueEstimate(iv)).Error
+	})
+	if err != nil {
+		log.Printf("Error updating maintenance interval %d: %v", iv.IntervalID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance interval", "details": err.Error()})
+		return
+	}
+	respondAsset(c, http.StatusOK, asset)
+}
+
+// deleteInterval menghapus siklus yang belum pernah dipakai overhaul; siklus dengan riwayat dinonaktifkan saja
+func deleteInterval(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	iv, ok := findInterval(c, asset)
+	if !ok {
+		return
+	}
+	var count int64
+	if err := db.Model(&Overhaul{}).Where("interval_id = ?", iv.IntervalID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check overhaul history", "details": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Interval has overhauls; set active to false instead of deleting"})
+		return
+	}
+	if err := db.Delete(&MaintenanceInterval{}, iv.IntervalID).Error; err != nil {
+		log.Printf("Error deleting maintenance interval %d: %v", iv.IntervalID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete maintenance interval", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AssetDue - Satu siklus aset pada laporan jatuh tempo
+type AssetDue struct {
+	AssetID            int      `json:"asset_id"`
+	AssetCode          string   `json:"assetCode"`
+	Name               string   `json:"name"`
+	EquipmentType      string   `json:"equipmentType"`
+	Location           string   `json:"location"`
+	OperatingHours     float64  `json:"operatingHours"`
+	IntervalID         int      `json:"interval_id"`
+	IntervalName       string   `json:"intervalName"`
+	DueDate            string   `json:"dueDate,omitempty"`
+	DaysRemaining      *int     `json:"daysRemaining,omitempty"`
+	DueHours           *float64 `json:"dueHours,omitempty"`
+	HoursRemaining     *float64 `json:"hoursRemaining,omitempty"`
+	Overdue            bool     `json:"overdue"`
+	OpenOverhaulID     *int     `json:"open_overhaul_id"`
+	OpenOverhaulStatus string   `json:"openOverhaulStatus,omitempty"`
+}
+
+// getOverdueAssets menampilkan siklus aset aktif yang sudah lewat jatuh tempo (waktu atau jam operasi)
+// beserta overhaul terjadwalnya. ?due_soon_days= (default 30) dan ?due_soon_hours= (default 50)
+// menambahkan daftar siklus yang segera jatuh tempo.
+func getOverdueAssets(c *gin.Context) {
+	dueSoonDays := 30
+	if v, err := strconv.Atoi(c.Query("due_soon_days")); err == nil && v >= 0 {
+		dueSoonDays = v
+	}
+	dueSoonHours := 50.0
+	if v, err := strconv.ParseFloat(c.Query("due_soon_hours"), 64); err == nil && v >= 0 {
+		dueSoonHours = v
+	}
+
+	var assets []Asset
+	if err := db.Where("active = ?", true).Order("asset_code").Find(&assets).Error; err != nil {
+		log.Printf("Error fetching assets for overdue report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assets", "details": err.Error()})
+		return
+	}
+
+	asOf := dateutil.Today()
+	overdue, dueSoon := []AssetDue{}, []AssetDue{}
+	for _, asset := range assets {
+		intervals, err := loadIntervals(db, asset, asOf)
+		if err != nil {
+			log.Printf("Error fetching intervals for asset %d: %v", asset.AssetID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance intervals", "details": err.Error()})
+			return
+		}
+		for _, iv := range intervals {
+			if !iv.Active {
+				continue
+			}
+			row := AssetDue{
+				AssetID: asset.AssetID, AssetCode: asset.AssetCode, Name: asset.Name,
+				EquipmentType: asset.EquipmentType, Location: asset.Location, OperatingHours: asset.OperatingHours,
+				IntervalID: iv.IntervalID, IntervalName: iv.Name,
+				DueDate: iv.DueDate, DaysRemaining: iv.DaysRemaining, DueHours: iv.DueHours, HoursRemaining: iv.HoursRemaining,
+				Overdue: iv.Overdue, OpenOverhaulID: iv.OpenOverhaulID,
+			}
+			if iv.OpenOverhaulID != nil {
+				var open Overhaul
+				if err := db.Select("status").First(&open, *iv.OpenOverhaulID).Error; err == nil {
+					row.OpenOverhaulStatus = open.Status
+				}
+			}
+			switch {
+			case iv.Overdue:
+				overdue = append(overdue, row)
+			case (iv.DaysRemaining != nil && *iv.DaysRemaining <= dueSoonDays) || (iv.HoursRemaining != nil && *iv.HoursRemaining <= dueSoonHours):
+				dueSoon = append(dueSoon, row)
+			}
+		}
+	}
+	// Paling terlambat lebih dulu (berdasarkan hari; siklus jam saja diurutkan di belakang)
+	byUrgency := func(list []AssetDue) func(i, j int) bool {
+		return func(i, j int) bool {
+			di, dj := list[i].DaysRemaining, list[j].DaysRemaining
+			if di == nil || dj == nil {
+				return di != nil
+			}
+			return *di < *dj
+		}
+	}
+	sort.SliceStable(overdue, byUrgency(overdue))
+	sort.SliceStable(dueSoon, byUrgency(dueSoon))
+
+	c.JSON(http.StatusOK, gin.H{
+		"asOf":    asOf.Format(dateutil.Layout),
+		"overdue": overdue,
+		"dueSoon": dueSoon,
+	})
+}
@@ -0,0 +1,670 @@
+package overhaul
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Personalia - Referensi minimal ke tabel 'personalia' untuk kru dan teknisi penanda tangan langkah
+type Personalia struct {
+	PersonaliaID int    `json:"personalia_id" gorm:"column:personalia_id;primaryKey"`
+	NIP          string `json:"nip" gorm:"column:nip"`
+	Jabatan      string `json:"jabatan" gorm:"column:jabatan"`
+	Divisi       string `json:"divisi" gorm:"column:divisi"`
+	Status       string `json:"status" gorm:"column:status"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Personalia di trash dianggap tidak ada
+}
+
+func (Personalia) TableName() string {
+	return "personalia"
+}
+
+// ChecklistTemplate - Prosedur standar overhaul untuk satu jenis peralatan
+// (mis. point machine, radio unit). Dipakai sebagai cetakan job card.
+type ChecklistTemplate struct {
+	TemplateID    int            `json:"id" gorm:"column:template_id;primaryKey;autoIncrement"`
+	EquipmentType string         `json:"equipmentType" gorm:"column:equipment_type"`
+	Name          string         `json:"name" gorm:"column:name"`
+	Description   string         `json:"description" gorm:"column:description"`
+	Active        bool           `json:"active" gorm:"column:active"`
+	CreatedAt     time.Time      `json:"createdAt" gorm:"column:created_at"`
+	Steps         []TemplateStep `json:"steps" gorm:"foreignKey:TemplateID;references:TemplateID"`
+}
+
+func (ChecklistTemplate) TableName() string {
+	return "overhaul_checklist_template"
+}
+
+// TemplateStep - Satu langkah prosedur pada template. Batas min/max berlaku untuk
+// langkah yang mewajibkan nilai ukur.
+type TemplateStep struct {
+	TemplateStepID      int      `json:"id" gorm:"column:template_step_id;primaryKey;autoIncrement"`
+	TemplateID          int      `json:"template_id" gorm:"column:template_id"`
+	Sequence            int      `json:"sequence" gorm:"column:sequence"`
+	Description         string   `json:"description" gorm:"column:description"`
+	RequiresMeasurement bool     `json:"requiresMeasurement" gorm:"column:requires_measurement"`
+	Unit                string   `json:"unit" gorm:"column:unit"`
+	MinValue            *float64 `json:"minValue" gorm:"column:min_value"`
+	MaxValue            *float64 `json:"maxValue" gorm:"column:max_value"`
+	RequiresPhoto       bool     `json:"requiresPhoto" gorm:"column:requires_photo"`
+}
+
+func (TemplateStep) TableName() string {
+	return "overhaul_checklist_template_step"
+}
+
+// JobCard - Checklist yang diinstansiasi dari template pada satu overhaul.
+// Langkah disalin saat job card dibuat sehingga perubahan template tidak mengubah job card berjalan.
+type JobCard struct {
+	JobCardID  int           `json:"id" gorm:"column:job_card_id;primaryKey;autoIncrement"`
+	OverhaulID int           `json:"overhaul_id" gorm:"column:overhaul_id"`
+	TemplateID *int          `json:"template_id" gorm:"column:template_id"`
+	Title      string        `json:"title" gorm:"column:title"`
+	CreatedAt  time.Time     `json:"createdAt" gorm:"column:created_at"`
+	Steps      []JobCardStep `json:"steps" gorm:"foreignKey:JobCardID;references:JobCardID"`
+
+	// Dihitung dari langkah yang sudah ditandatangani setiap kali dimuat
+	Progress       int `json:"progress" gorm:"-"`
+	CompletedSteps int `json:"completedSteps" gorm:"-"`
+	TotalSteps     int `json:"totalSteps" gorm:"-"`
+}
+
+func (JobCard) TableName() string {
+	return "overhaul_job_card"
+}
+
+// JobCardStep - Langkah job card beserta hasil ukur dan tanda tangan teknisi
+type JobCardStep struct {
+	StepID              int        `json:"id" gorm:"column:step_id;primaryKey;autoIncrement"`
+	JobCardID           int        `json:"job_card_id" gorm:"column:job_card_id"`
+	Sequence            int        `json:"sequence" gorm:"column:sequence"`
+	Description         string     `json:"description" gorm:"column:description"`
+	RequiresMeasurement bool       `json:"requiresMeasurement" gorm:"column:requires_measurement"`
+	Unit                string     `json:"unit" gorm:"column:unit"`
+	MinValue            *float64   `json:"minValue" gorm:"column:min_value"`
+	MaxValue            *float64   `json:"maxValue" gorm:"column:max_value"`
+	RequiresPhoto       bool       `json:"requiresPhoto" gorm:"column:requires_photo"`
+	MeasuredValue       *float64   `json:"measuredValue" gorm:"column:measured_value"`
+	WithinLimits        *bool      `json:"withinLimits" gorm:"column:within_limits"`
+	SignedOffBy         *int       `json:"signed_off_by" gorm:"column:signed_off_by"`
+	SignedOffAt         *time.Time `json:"signedOffAt" gorm:"column:signed_off_at"`
+	Remarks             string     `json:"remarks" gorm:"column:remarks"`
+
+	Technician *Personalia `json:"technician,omitempty" gorm:"foreignKey:SignedOffBy;references:PersonaliaID"`
+	Photos     []StepPhoto `json:"photos" gorm:"foreignKey:StepID;references:StepID"`
+}
+
+func (JobCardStep) TableName() string {
+	return "overhaul_job_card_step"
+}
+
+// validateTemplate memeriksa template dan menomori ulang langkah sesuai urutan kiriman
+func validateTemplate(t *ChecklistTemplate) string {
+	t.EquipmentType = strings.TrimSpace(t.EquipmentType)
+	t.Name = strings.TrimSpace(t.Name)
+	if t.EquipmentType == "" || t.Name == "" {
+		return "Fields 'equipmentType' and 'name' are required."
+	}
+	if len(t.Steps) == 0 {
+		return "A checklist template needs at least one step."
+	}
+	for i := range t.Steps {
+		step := &t.Steps[i]
+		step.TemplateStepID = 0
+		step.Sequence = i + 1
+		if strings.TrimSpace(step.Description) == "" {
+			return fmt.Sprintf("Step %d requires a description.", step.Sequence)
+		}
+		if !step.RequiresMeasurement {
+			step.Unit, step.MinValue, step.MaxValue = "", nil, nil
+			continue
+		}
+		if step.MinValue != nil && step.MaxValue != nil && *step.MinValue > *step.MaxValue {
+			return fmt.Sprintf("Step %d: minValue cannot be greater than maxValue.", step.Sequence)
+		}
+	}
+	return ""
+}
+
+// loadTemplates memuat template beserta langkahnya sesuai urutan
+func loadTemplates(query *gorm.DB) ([]ChecklistTemplate, error) {
+	var templates []ChecklistTemplate
+	err := query.Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("sequence") }).
+		Order("equipment_type, name").Find(&templates).Error
+	return templates, err
+}
+
+// findTemplate mengambil template berdasarkan parameter :templateId
+func findTemplate(c *gin.Context) (ChecklistTemplate, bool) {
+	templateID, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return ChecklistTemplate{}, false
+	}
+	templates, err := loadTemplates(db.Where("template_id = ?", templateID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist template", "details": err.Error()})
+		return ChecklistTemplate{}, false
+	}
+	if len(templates) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+		return ChecklistTemplate{}, false
+	}
+	return templates[0], true
+}
+
+// getTemplates menampilkan template checklist. Filter opsional: ?equipment_type=, ?active=true
+func getTemplates(c *gin.Context) {
+	query := db.Model(&ChecklistTemplate{})
+	if equipmentType := c.Query("equipment_type"); equipmentType != "" {
+		query = query.Where("equipment_type = ?", equipmentType)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+	templates, err := loadTemplates(query)
+	if err != nil {
+		log.Printf("Error fetching checklist templates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist templates", "details": err.Error()})
+		return
+	}
+	if templates == nil {
+		templates = []ChecklistTemplate{}
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+func getTemplate(c *gin.Context) {
+	template, ok := findTemplate(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// createTemplate membuat template checklist beserta langkahnya (aktif secara default)
+func createTemplate(c *gin.Context) {
+	template := ChecklistTemplate{Active: true}
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateTemplate(&template); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	template.TemplateID = 0
+	template.CreatedAt = time.Now()
+
+	// GORM ikut menyimpan Steps sebagai asosiasi has-many
+	if err := db.Create(&template).Error; err != nil {
+		log.Printf("Error creating checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist template", "details": err.Error()})
+		return
+	}
+	c.Params = append(c.Params, gin.Param{Key: "templateId", Value: strconv.Itoa(template.TemplateID)})
+	if created, ok := findTemplate(c); ok {
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// updateTemplate mengganti isi template dan seluruh langkahnya.
+// Job card yang sudah dibuat tidak terpengaruh karena menyimpan salinan langkah.
+func updateTemplate(c *gin.Context) {
+	existing, ok := findTemplate(c)
+	if !ok {
+		return
+	}
+	var template ChecklistTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateTemplate(&template); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ChecklistTemplate{}).Where("template_id = ?", existing.TemplateID).Updates(map[string]interface{}{
+			"equipment_type": template.EquipmentType,
+			"name":           template.Name,
+			"description":    template.Description,
+			"active":         template.Active,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", existing.TemplateID).Delete(&TemplateStep{}).Error; err != nil {
+			return err
+		}
+		for i := range template.Steps {
+			template.Steps[i].TemplateID = existing.TemplateID
+		}
+		return tx.Create(&template.Steps).Error
+	})
+	if err != nil {
+		log.Printf("Error updating checklist template %d: %v", existing.TemplateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist template", "details": err.Error()})
+		return
+	}
+	if updated, ok := findTemplate(c); ok {
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// deleteTemplate menghapus template. Job card yang pernah dibuat darinya tetap utuh
+// (langkahnya sudah disalin), hanya referensi template-nya yang dilepas.
+func deleteTemplate(c *gin.Context) {
+	template, ok := findTemplate(c)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&JobCard{}).Where("template_id = ?", template.TemplateID).Update("template_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.TemplateID).Delete(&TemplateStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&ChecklistTemplate{}, template.TemplateID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting checklist template %d: %v", template.TemplateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist template", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findOverhaul mengambil overhaul (yang belum dihapus) berdasarkan parameter :id
+func findOverhaul(c *gin.Context) (Overhaul, bool) {
+	var item Overhaul
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid overhaul ID"})
+		return item, false
+	}
+	if err := db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Overhaul item not found"})
+		} else {
+			log.Printf("Error fetching overhaul item with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul data", "details": err.Error()})
+		}
+		return item, false
+	}
+	return item, true
+}
+
+// stepProgress menghitung persentase langkah yang sudah ditandatangani
+func stepProgress(done, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return int(math.Round(float64(done) / float64(total) * 100))
+}
+
+// loadJobCards memuat job card overhaul beserta langkah, teknisi dan foto, lalu menghitung progress
+func loadJobCards(tx *gorm.DB, overhaulID int) ([]JobCard, error) {
+	var cards []JobCard
+	err := tx.Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("sequence") }).
+		Preload("Steps.Technician").
+		Preload("Steps.Photos", func(tx *gorm.DB) *gorm.DB { return tx.Order("photo_id") }).
+		Where("overhaul_id = ?", overhaulID).Order("job_card_id").Find(&cards).Error
+	for i := range cards {
+		cards[i].TotalSteps, cards[i].CompletedSteps = len(cards[i].Steps), 0
+		for _, step := range cards[i].Steps {
+			if step.SignedOffAt != nil {
+				cards[i].CompletedSteps++
+			}
+		}
+		cards[i].Progress = stepProgress(cards[i].CompletedSteps, cards[i].TotalSteps)
+	}
+	return cards, err
+}
+
+// stepCounts menghitung jumlah langkah dan langkah yang sudah ditandatangani pada overhaul
+func stepCounts(tx *gorm.DB, overhaulID int) (done, total int, err error) {
+	var counts struct {
+		Total int
+		Done  int
+	}
+	err = tx.Table("overhaul_job_card_step s").
+		Joins("JOIN overhaul_job_card j ON j.job_card_id = s.job_card_id").
+		Where("j.overhaul_id = ?", overhaulID).
+		Select("COUNT(*) AS total, COUNT(s.signed_off_at) AS done").
+		Scan(&counts).Error
+	return counts.Done, counts.Total, err
+}
+
+// hasJobCardSteps menandakan progress overhaul dihitung dari langkah job card
+func hasJobCardSteps(tx *gorm.DB, overhaulID int) (bool, error) {
+	_, total, err := stepCounts(tx, overhaulID)
+	return total > 0, err
+}
+
+// recomputeOverhaulProgress menyimpan progress overhaul dari langkah job card yang selesai.
+// Overhaul tanpa job card tetap memakai progress yang diisi manual.
+func recomputeOverhaulProgress(tx *gorm.DB, overhaulID int) error {
+	done, total, err := stepCounts(tx, overhaulID)
+	if err != nil || total == 0 {
+		return err
+	}
+	return tx.Model(&Overhaul{}).Where("overhaul_id = ?", overhaulID).Update("progress", stepProgress(done, total)).Error
+}
+
+// respondJobCards mengirim seluruh job card overhaul beserta progress gabungannya
+func respondJobCards(c *gin.Context, status, overhaulID int) {
+	cards, err := loadJobCards(db, overhaulID)
+	if err != nil {
+		log.Printf("Error fetching job cards for overhaul %d: %v", overhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job cards", "details": err.Error()})
+		return
+	}
+	if cards == nil {
+		cards = []JobCard{}
+	}
+	done, total := 0, 0
+	for _, card := range cards {
+		done += card.CompletedSteps
+		total += card.TotalSteps
+	}
+	c.JSON(status, gin.H{
+		"overhaul_id":    overhaulID,
+		"progress":       stepProgress(done, total),
+		"completedSteps": done,
+		"totalSteps":     total,
+		"jobCards":       cards,
+	})
+}
+
+// getJobCards menampilkan job card overhaul
+func getJobCards(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	respondJobCards(c, http.StatusOK, item.OverhaulID)
+}
+
+// createJobCards membuat job card dari template. Tanpa template_id, semua template aktif untuk
+// equipmentType overhaul yang belum dipakai pada overhaul ini akan diinstansiasi.
+func createJobCards(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		TemplateID *int `json:"template_id"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+			return
+		}
+	}
+
+	var templates []ChecklistTemplate
+	var err error
+	if req.TemplateID != nil {
+		templates, err = loadTemplates(db.Where("template_id = ?", *req.TemplateID))
+		if err == nil && len(templates) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+			return
+		}
+		if err == nil && item.EquipmentType != "" && templates[0].EquipmentType != item.EquipmentType {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Template is for equipment type '%s', overhaul is '%s'", templates[0].EquipmentType, item.EquipmentType)})
+			return
+		}
+	} else {
+		if item.EquipmentType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set the overhaul equipmentType or pass template_id"})
+			return
+		}
+		used := db.Model(&JobCard{}).Select("template_id").Where("overhaul_id = ? AND template_id IS NOT NULL", item.OverhaulID)
+		templates, err = loadTemplates(db.Where("equipment_type = ? AND active = ? AND template_id NOT IN (?)", item.EquipmentType, true, used))
+		if err == nil && len(templates) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No unused active checklist template for equipment type '%s'", item.EquipmentType)})
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist templates", "details": err.Error()})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, template := range templates {
+			templateID := template.TemplateID
+			card := JobCard{OverhaulID: item.OverhaulID, TemplateID: &templateID, Title: template.Name, CreatedAt: time.Now()}
+			for _, step := range template.Steps {
+				card.Steps = append(card.Steps, JobCardStep{
+					Sequence:            step.Sequence,
+					Description:         step.Description,
+					RequiresMeasurement: step.RequiresMeasurement,
+					Unit:                step.Unit,
+					MinValue:            step.MinValue,
+					MaxValue:            step.MaxValue,
+					RequiresPhoto:       step.RequiresPhoto,
+				})
+			}
+			if err := tx.Create(&card).Error; err != nil {
+				return err
+			}
+		}
+		return recomputeOverhaulProgress(tx, item.OverhaulID)
+	})
+	if err != nil {
+		log.Printf("Error creating job cards for overhaul %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job cards", "details": err.Error()})
+		return
+	}
+	respondJobCards(c, http.StatusCreated, item.OverhaulID)
+}
+
+// findJobCard mengambil job card (beserta langkahnya) berdasarkan parameter :cardId milik overhaul
+func findJobCard(c *gin.Context, item Overhaul) (JobCard, bool) {
+	cardID, err := strconv.Atoi(c.Param("cardId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job card ID"})
+		return JobCard{}, false
+	}
+	cards, err := loadJobCards(db.Where("job_card_id = ?", cardID), item.OverhaulID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job card", "details": err.Error()})
+		return JobCard{}, false
+	}
+	if len(cards) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job card not found"})
+		return JobCard{}, false
+	}
+	return cards[0], true
+}
+
+// findStep mengambil langkah job card berdasarkan parameter :stepId
+func findStep(c *gin.Context, card JobCard) (JobCardStep, bool) {
+	stepID, err := strconv.Atoi(c.Param("stepId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step ID"})
+		return JobCardStep{}, false
+	}
+	for _, step := range card.Steps {
+		if step.StepID == stepID {
+			return step, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Job card step not found"})
+	return JobCardStep{}, false
+}
+
+// deleteJobCard menghapus job card yang belum memiliki langkah bertanda tangan
+func deleteJobCard(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	card, ok := findJobCard(c, item)
+	if !ok {
+		return
+	}
+	if card.CompletedSteps > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job card has signed-off steps and cannot be deleted"})
+		return
+	}
+
+	var photos []StepPhoto
+	stepIDs := make([]int, 0, len(card.Steps))
+	for _, step := range card.Steps {
+		stepIDs = append(stepIDs, step.StepID)
+		photos = append(photos, step.Photos...)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(stepIDs) > 0 {
+			if err := tx.Where("step_id IN ?", stepIDs).Delete(&StepPhoto{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("job_card_id = ?", card.JobCardID).Delete(&JobCardStep{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&JobCard{}, card.JobCardID).Error; err != nil {
+			return err
+		}
+		return recomputeOverhaulProgress(tx, item.OverhaulID)
+	})
+	if err != nil {
+		log.Printf("Error deleting job card %d: %v", card.JobCardID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job card", "details": err.Error()})
+		return
+	}
+	for _, photo := range photos {
+		removePhotoFile(photo)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// signOffStep menandatangani langkah job card oleh teknisi (personalia_id atau nip).
+// Langkah berukur wajib mengisi measuredValue; nilai di luar batas wajib disertai remarks.
+// Langkah yang mewajibkan foto harus sudah memiliki minimal satu foto.
+func signOffStep(c *gin.Context) {
+	item, step, ok := findStepFromPath(c)
+	if !ok {
+		return
+	}
+	if step.SignedOffAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Step has already been signed off"})
+		return
+	}
+
+	var req struct {
+		PersonaliaID  int      `json:"personalia_id"`
+		NIP           string   `json:"nip"`
+		MeasuredValue *float64 `json:"measuredValue"`
+		Remarks       string   `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+
+	var technician Personalia
+	switch {
+	case req.PersonaliaID > 0:
+		if err := db.First(&technician, req.PersonaliaID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Personalia with ID %d not found", req.PersonaliaID)})
+			return
+		}
+	case req.NIP != "":
+		if err := db.Where("nip = ?", req.NIP).First(&technician).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Personalia with NIP '%s' not found", req.NIP)})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Technician is required (personalia_id or nip)"})
+		return
+	}
+
+	var within *bool
+	if step.RequiresMeasurement {
+		if req.MeasuredValue == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This step requires a measuredValue"})
+			return
+		}
+		v := *req.MeasuredValue
+		ok := (step.MinValue == nil || v >= *step.MinValue) && (step.MaxValue == nil || v <= *step.MaxValue)
+		within = &ok
+		if !ok && strings.TrimSpace(req.Remarks) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Remarks are required when the measured value is outside the limits"})
+			return
+		}
+	} else {
+		req.MeasuredValue = nil
+	}
+	if step.RequiresPhoto && len(step.Photos) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload at least one photo before signing off this step"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&JobCardStep{}).Where("step_id = ?", step.StepID).Updates(map[string]interface{}{
+			"measured_value": req.MeasuredValue,
+			"within_limits":  within,
+			"signed_off_by":  technician.PersonaliaID,
+			"signed_off_at":  time.Now(),
+			"remarks":        req.Remarks,
+		}).Error; err != nil {
+			return err
+		}
+		return recomputeOverhaulProgress(tx, item.OverhaulID)
+	})
+	if err != nil {
+		log.Printf("Error signing off step %d: %v", step.StepID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign off step", "details": err.Error()})
+		return
+	}
+	if within != nil && !*within {
+		log.Printf("Overhaul %d step %d signed off outside limits: %v", item.OverhaulID, step.StepID, *req.MeasuredValue)
+	}
+	respondJobCards(c, http.StatusOK, item.OverhaulID)
+}
+
+// revokeSignOff membatalkan tanda tangan langkah (mis. pekerjaan harus diulang)
+func revokeSignOff(c *gin.Context) {
+	item, step, ok := findStepFromPath(c)
+	if !ok {
+		return
+	}
+	if step.SignedOffAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Step has not been signed off"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&JobCardStep{}).Where("step_id = ?", step.StepID).Updates(map[string]interface{}{
+			"measured_value": nil,
+			"within_limits":  nil,
+			"signed_off_by":  nil,
+			"signed_off_at":  nil,
+		}).Error; err != nil {
+			return err
+		}
+		return recomputeOverhaulProgress(tx, item.OverhaulID)
+	})
+	if err != nil {
+		log.Printf("Error revoking sign-off of step %d: %v", step.StepID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sign-off", "details": err.Error()})
+		return
+	}
+	respondJobCards(c, http.StatusOK, item.OverhaulID)
+}
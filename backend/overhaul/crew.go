@@ -0,0 +1,412 @@
+package overhaul
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Peran anggota kru overhaul
+const (
+	roleLead       = "lead"
+	roleTechnician = "technician"
+	roleInspector  = "inspector"
+)
+
+var validRoles = map[string]bool{roleLead: true, roleTechnician: true, roleInspector: true}
+
+// CrewMember - Penugasan personalia pada overhaul (tabel 'overhaul_crew')
+type CrewMember struct {
+	OverhaulCrewID int     `json:"id" gorm:"column:overhaul_crew_id;primaryKey;autoIncrement"`
+	OverhaulID     int     `json:"overhaul_id" gorm:"column:overhaul_id"`
+	PersonaliaID   int     `json:"personalia_id" gorm:"column:personalia_id"`
+	Role           string  `json:"role" gorm:"column:role"`
+	AssignedHours  float64 `json:"assignedHours" gorm:"column:assigned_hours"`
+
+	NIP        string      `json:"nip,omitempty" gorm:"-"` // Alternatif personalia_id saat input
+	Personalia *Personalia `json:"personalia,omitempty" gorm:"foreignKey:PersonaliaID;references:PersonaliaID"`
+}
+
+func (CrewMember) TableName() string {
+	return "overhaul_crew"
+}
+
+// resolveCrew memvalidasi daftar kru: personalia harus ada (via personalia_id atau nip),
+// peran valid, jam penugasan tidak negatif, tidak ada anggota ganda dan paling banyak satu lead
+func resolveCrew(tx *gorm.DB, members []CrewMember) ([]CrewMember, string) {
+	seen := make(map[int]bool)
+	leads := 0
+	resolved := make([]CrewMember, 0, len(members))
+	for _, m := range members {
+		if m.Role == "" {
+			m.Role = roleTechnician
+		}
+		if !validRoles[m.Role] {
+			return nil, fmt.Sprintf("Invalid role '%s' (lead, technician, inspector)", m.Role)
+		}
+		if m.AssignedHours < 0 {
+			return nil, "assignedHours cannot be negative"
+		}
+
+		var p Personalia
+		switch {
+		case m.PersonaliaID > 0:
+			if err := tx.First(&p, m.PersonaliaID).Error; err != nil {
+				return nil, fmt.Sprintf("Personalia with ID %d not found", m.PersonaliaID)
+			}
+		case m.NIP != "":
+			if err := tx.Where("nip = ?", m.NIP).First(&p).Error; err != nil {
+				return nil, fmt.Sprintf("Personalia with NIP '%s' not found", m.NIP)
+			}
+		default:
+			return nil, "Each crew member requires personalia_id or nip"
+		}
+
+		if seen[p.PersonaliaID] {
+			return nil, fmt.Sprintf("Personalia '%s' is listed more than once", p.NIP)
+		}
+		seen[p.PersonaliaID] = true
+		if m.Role == roleLead {
+			leads++
+		}
+		resolved = append(resolved, CrewMember{
+			OverhaulCrewID: m.OverhaulCrewID,
+			PersonaliaID:   p.PersonaliaID,
+			Role:           m.Role,
+			AssignedHours:  m.AssignedHours,
+			NIP:            p.NIP,
+		})
+	}
+	if leads > 1 {
+		return nil, "An overhaul can only have one lead"
+	}
+	return resolved, ""
+}
+
+// loadCrews memuat kru untuk beberapa overhaul sekaligus, lead lebih dulu
+func loadCrews(ids []int) (map[int][]CrewMember, error) {
+	crews := make(map[int][]CrewMember)
+	if len(ids) == 0 {
+		return crews, nil
+	}
+	var rows []CrewMember
+	if err := db.Preload("Personalia").Where("overhaul_id IN ?", ids).
+		Order("overhaul_id, FIELD(role, 'lead', 'technician', 'inspector'), overhaul_crew_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if r.Personalia != nil {
+			r.NIP = r.Personalia.NIP
+		}
+		crews[r.OverhaulID] = append(crews[r.OverhaulID], r)
+	}
+	return crews, nil
+}
+
+// attachCrews mengisi field Crew pada daftar overhaul
+func attachCrews(items []Overhaul) error {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.OverhaulID)
+	}
+	crews, err := loadCrews(ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Crew = crews[items[i].OverhaulID]
+		if items[i].Crew == nil {
+			items[i].Crew = []CrewMember{}
+		}
+	}
+	return nil
+}
+
+// personaliaExists memastikan personalia_id lama (penanggung jawab) merujuk ke personalia yang ada
+func personaliaExists(id *int) string {
+	if id == nil {
+		return ""
+	}
+	var p Personalia
+	if err := db.First(&p, *id).Error; err != nil {
+		return fmt.Sprintf("Personalia with ID %d not found", *id)
+	}
+	return ""
+}
+
+// migrateLegacyCrews memindahkan personalia_id overhaul (satu orang per pekerjaan) ke tabel
+// overhaul_crew sebagai lead, hanya untuk overhaul yang belum memiliki kru
+func migrateLegacyCrews() {
+	var items []Overhaul
+	if err := db.Where("personalia_id IS NOT NULL AND overhaul_id NOT IN (?)",
+		db.Model(&CrewMember{}).Select("overhaul_id").Where("overhaul_id IS NOT NULL")).Find(&items).Error; err != nil {
+		log.Printf("Error fetching legacy overhaul assignments: %v", err)
+		return
+	}
+	migrated := 0
+	for _, item := range items {
+		if msg := personaliaExists(item.PersonaliaID); msg != "" {
+			log.Printf("Overhaul %d: %s, assignment not migrated", item.OverhaulID, msg)
+			continue
+		}
+		member := CrewMember{OverhaulID: item.OverhaulID, PersonaliaID: *item.PersonaliaID, Role: roleLead}
+		if err := db.Create(&member).Error; err != nil {
+			log.Printf("Error migrating assignment for overhaul %d: %v", item.OverhaulID, err)
+			continue
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d legacy overhaul assignments into overhaul_crew.", migrated)
+	}
+}
+
+// findCrewMember mengambil anggota kru berdasarkan parameter :memberId milik overhaul
+func findCrewMember(c *gin.Context, item Overhaul) (CrewMember, bool) {
+	var member CrewMember
+	memberID, err := strconv.Atoi(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crew member ID"})
+		return member, false
+	}
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).First(&member, memberID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Crew member not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew member", "details": err.Error()})
+		}
+		return member, false
+	}
+	return member, true
+}
+
+// respondCrew mengirim kru overhaul terbaru beserta total jam penugasan
+func respondCrew(c *gin.Context, status, overhaulID int) {
+	crews, err := loadCrews([]int{overhaulID})
+	if err != nil {
+		log.Printf("Error fetching crew for overhaul %d: %v", overhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	members := crews[overhaulID]
+	if members == nil {
+		members = []CrewMember{}
+	}
+	total := 0.0
+	for _, m := range members {
+		total += m.AssignedHours
+	}
+	c.JSON(status, gin.H{"overhaul_id": overhaulID, "totalHours": total, "crew": members})
+}
+
+// getCrew menampilkan kru overhaul beserta data personalia
+func getCrew(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	respondCrew(c, http.StatusOK, item.OverhaulID)
+}
+
+// addCrewMember menambahkan personalia (personalia_id atau nip) ke kru overhaul
+func addCrewMember(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+
+	var req CrewMember
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	req.OverhaulCrewID = 0
+
+	var existing []CrewMember
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	resolved, msg := resolveCrew(db, append(existing, req))
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	added := resolved[len(resolved)-1]
+	member := CrewMember{OverhaulID: item.OverhaulID, PersonaliaID: added.PersonaliaID, Role: added.Role, AssignedHours: added.AssignedHours}
+	if err := db.Create(&member).Error; err != nil {
+		log.Printf("Error adding crew member to overhaul %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add crew member", "details": err.Error()})
+		return
+	}
+	respondCrew(c, http.StatusCreated, item.OverhaulID)
+}
+
+// replaceCrew mengganti seluruh kru overhaul dengan daftar pada body
+func replaceCrew(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+
+	var req []CrewMember
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	resolved, msg := resolveCrew(db, req)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("overhaul_id = ?", item.OverhaulID).Delete(&CrewMember{}).Error; err != nil {
+			return err
+		}
+		for _, m := range resolved {
+			row := CrewMember{OverhaulID: item.OverhaulID, PersonaliaID: m.PersonaliaID, Role: m.Role, AssignedHours: m.AssignedHours}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error replacing crew for overhaul %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update crew", "details": err.Error()})
+		return
+	}
+	respondCrew(c, http.StatusOK, item.OverhaulID)
+}
+
+// updateCrewMember mengubah peran dan/atau jam penugasan anggota kru
+func updateCrewMember(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	member, ok := findCrewMember(c, item)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role          *string  `json:"role"`
+		AssignedHours *float64 `json:"assignedHours"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if req.Role == nil && req.AssignedHours == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role or assignedHours is required"})
+		return
+	}
+
+	var existing []CrewMember
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	for i := range existing {
+		if existing[i].OverhaulCrewID != member.OverhaulCrewID {
+			continue
+		}
+		if req.Role != nil {
+			existing[i].Role = *req.Role
+		}
+		if req.AssignedHours != nil {
+			existing[i].AssignedHours = *req.AssignedHours
+		}
+		member = existing[i]
+	}
+	if _, msg := resolveCrew(db, existing); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := db.Model(&member).Updates(map[string]interface{}{"role": member.Role, "assigned_hours": member.AssignedHours}).Error; err != nil {
+		log.Printf("Error updating crew member %d: %v", member.OverhaulCrewID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update crew member", "details": err.Error()})
+		return
+	}
+	respondCrew(c, http.StatusOK, item.OverhaulID)
+}
+
+// removeCrewMember mengeluarkan anggota dari kru overhaul
+func removeCrewMember(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	member, ok := findCrewMember(c, item)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&member).Error; err != nil {
+		log.Printf("Error removing crew member %d: %v", member.OverhaulCrewID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove crew member", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// TechnicianAssignment - Satu overhaul terbuka pada daftar kerja teknisi
+type TechnicianAssignment struct {
+	OverhaulID    int        `json:"id"`
+	Name          string     `json:"name"`
+	EquipmentType string     `json:"equipmentType"`
+	Status        string     `json:"status"`
+	Estimate      *time.Time `json:"estimate,omitempty"`
+	Progress      int        `json:"progress"`
+	Role          string     `json:"role"`
+	AssignedHours float64    `json:"assignedHours"`
+}
+
+// getTechnicianOverhauls menampilkan overhaul terbuka (belum Selesai) yang ditugaskan ke satu teknisi
+func getTechnicianOverhauls(c *gin.Context) {
+	var p Personalia
+	id, err := strconv.Atoi(c.Param("personaliaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid personalia ID"})
+		return
+	}
+	if err := db.First(&p, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personalia not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch personalia", "details": err.Error()})
+		}
+		return
+	}
+
+	assignments := []TechnicianAssignment{}
+	err = db.Table("overhaul_crew oc").
+		Joins("JOIN overhaul o ON o.overhaul_id = oc.overhaul_id").
+		Where("oc.personalia_id = ? AND o.deleted_at IS NULL AND (o.status IS NULL OR o.status <> ?)", p.PersonaliaID, statusSelesai).
+		Select("o.overhaul_id, o.name, o.equipment_type, o.status, o.estimate, o.progress, oc.role, oc.assigned_hours").
+		Order("o.estimate IS NULL, o.estimate, o.overhaul_id").
+		Scan(&assignments).Error
+	if err != nil {
+		log.Printf("Error fetching open overhauls for personalia %d: %v", p.PersonaliaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhauls", "details": err.Error()})
+		return
+	}
+	total := 0.0
+	for _, a := range assignments {
+		total += a.AssignedHours
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"personalia": p,
+		"totalHours": total,
+		"overhauls":  assignments,
+	})
+}
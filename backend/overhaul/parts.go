@@ -0,0 +1,466 @@
+package overhaul
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inventoryUnserviceable - Status inventory untuk part bekas yang dilepas dari peralatan
+// dan belum/tidak dapat dipakai kembali. Dipisah dari stok layak pakai pada baris inventory sendiri.
+const inventoryUnserviceable = "Unserviceable"
+
+// Jenis pergerakan part overhaul
+const (
+	movementIssue   = "issue"   // Part baru dikeluarkan dari inventory dan dipasang
+	movementRemoved = "removed" // Part lama dilepas dan masuk inventory unserviceable
+	movementReturn  = "return"  // Pemasangan dibatalkan, part baru kembali ke stok sumber
+)
+
+// Inventory - Referensi minimal ke tabel 'inventory'
+type Inventory struct {
+	InventoryID int    `json:"id" gorm:"column:inventory_id;primaryKey;autoIncrement"`
+	Name        string `json:"name" gorm:"column:name"`
+	Quantity    int    `json:"quantity" gorm:"column:quantity"`
+	Location    string `json:"location" gorm:"column:location"`
+	Status      string `json:"status" gorm:"column:status"`
+	ItemCode    string `json:"itemCode" gorm:"column:itemCode"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
+}
+
+func (Inventory) TableName() string {
+	return "inventory"
+}
+
+// Part - Satu baris daftar part overhaul. item_name/item_code menyimpan salinan data inventory
+// agar riwayat tetap terbaca jika item inventory dihapus.
+type Part struct {
+	PartID      int       `json:"id" gorm:"column:part_id;primaryKey;autoIncrement"`
+	OverhaulID  int       `json:"overhaul_id" gorm:"column:overhaul_id"`
+	InventoryID *int      `json:"inventory_id" gorm:"column:inventory_id"`
+	ItemName    string    `json:"itemName" gorm:"column:item_name"`
+	ItemCode    string    `json:"itemCode" gorm:"column:item_code"`
+	PlannedQty  int       `json:"plannedQty" gorm:"column:planned_qty"`
+	FittedQty   int       `json:"fittedQty" gorm:"column:fitted_qty"`   // Aktual terpasang (keluar dari inventory)
+	RemovedQty  int       `json:"removedQty" gorm:"column:removed_qty"` // Part lama yang dilepas
+	Notes       string    `json:"notes" gorm:"column:notes"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
+}
+
+func (Part) TableName() string {
+	return "overhaul_part"
+}
+
+// PartMovement - Catatan pergerakan stok untuk part overhaul (pengeluaran atau pengembalian part lepas)
+type PartMovement struct {
+	MovementID   int       `json:"id" gorm:"column:movement_id;primaryKey;autoIncrement"`
+	PartID       int       `json:"part_id" gorm:"column:part_id"`
+	OverhaulID   int       `json:"overhaul_id" gorm:"column:overhaul_id"`
+	InventoryID  *int      `json:"inventory_id" gorm:"column:inventory_id"`
+	MovementType string    `json:"type" gorm:"column:movement_type"`
+	Qty          int       `json:"qty" gorm:"column:qty"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (PartMovement) TableName() string {
+	return "overhaul_part_movement"
+}
+
+var errInventoryGone = errors.New("inventory item for this part no longer exists")
+
+// FittedError dikembalikan jika jumlah yang dibatalkan melebihi jumlah part terpasang
+type FittedError struct {
+	Fitted int
+}
+
+func (e *FittedError) Error() string {
+	return fmt.Sprintf("only %d fitted", e.Fitted)
+}
+
+// StockError dikembalikan jika stok inventory tidak cukup untuk part yang dipasang
+type StockError struct {
+	Available int
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("insufficient stock, %d available", e.Available)
+}
+
+// loadParts memuat daftar part overhaul beserta item inventory-nya
+func loadParts(tx *gorm.DB, overhaulID int) ([]Part, error) {
+	var parts []Part
+	err := tx.Preload("Inventory").Where("overhaul_id = ?", overhaulID).Order("part_id").Find(&parts).Error
+	return parts, err
+}
+
+// findPart mengambil baris part berdasarkan parameter :partId milik overhaul
+func findPart(c *gin.Context, item Overhaul) (Part, bool) {
+	var part Part
+	partID, err := strconv.Atoi(c.Param("partId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part ID"})
+		return part, false
+	}
+	if err := db.Preload("Inventory").Where("overhaul_id = ?", item.OverhaulID).First(&part, partID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Part not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch part", "details": err.Error()})
+		}
+		return part, false
+	}
+	return part, true
+}
+
+// respondParts mengirim daftar part overhaul beserta riwayat pergerakan stoknya
+func respondParts(c *gin.Context, status, overhaulID int) {
+	parts, err := loadParts(db, overhaulID)
+	if err != nil {
+		log.Printf("Error fetching parts for overhaul %d: %v", overhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parts", "details": err.Error()})
+		return
+	}
+	var movements []PartMovement
+	if err := db.Where("overhaul_id = ?", overhaulID).Order("created_at, movement_id").Find(&movements).Error; err != nil {
+		log.Printf("Error fetching part movements for overhaul %d: %v", overhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch part movements", "details": err.Error()})
+		return
+	}
+	if parts == nil {
+		parts = []Part{}
+	}
+	if movements == nil {
+		movements = []PartMovement{}
+	}
+	c.JSON(status, gin.H{"overhaul_id": overhaulID, "parts": parts, "movements": movements})
+}
+
+// getParts menampilkan daftar part overhaul (rencana vs aktual)
+func getParts(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	respondParts(c, http.StatusOK, item.OverhaulID)
+}
+
+// createPart menambahkan item inventory ke daftar part overhaul dengan jumlah rencana
+func createPart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		InventoryID int    `json:"inventory_id"`
+		PlannedQty  int    `json:"plannedQty"`
+		Notes       string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if req.InventoryID <= 0 || req.PlannedQty <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "inventory_id and plannedQty (> 0) are required"})
+		return
+	}
+
+	var inv Inventory
+	if err := db.First(&inv, req.InventoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Inventory item with ID %d not found", req.InventoryID)})
+		return
+	}
+	if inv.Status == inventoryUnserviceable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unserviceable inventory cannot be planned as a replacement part"})
+		return
+	}
+	var count int64
+	if err := db.Model(&Part{}).Where("overhaul_id = ? AND inventory_id = ?", item.OverhaulID, inv.InventoryID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parts", "details": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Inventory item is already on the parts list; update its planned quantity instead"})
+		return
+	}
+
+	part := Part{
+		OverhaulID:  item.OverhaulID,
+		InventoryID: &inv.InventoryID,
+		ItemName:    inv.Name,
+		ItemCode:    inv.ItemCode,
+		PlannedQty:  req.PlannedQty,
+		Notes:       req.Notes,
+		CreatedAt:   time.Now(),
+	}
+	if err := db.Create(&part).Error; err != nil {
+		log.Printf("Error creating part for overhaul %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save part", "details": err.Error()})
+		return
+	}
+	respondParts(c, http.StatusCreated, item.OverhaulID)
+}
+
+// updatePart mengubah jumlah rencana dan catatan baris part
+func updatePart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	part, ok := findPart(c, item)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PlannedQty int    `json:"plannedQty"`
+		Notes      string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if req.PlannedQty <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plannedQty must be greater than 0"})
+		return
+	}
+
+	if err := db.Model(&Part{}).Where("part_id = ?", part.PartID).
+		Updates(map[string]interface{}{"planned_qty": req.PlannedQty, "notes": req.Notes}).Error; err != nil {
+		log.Printf("Error updating part %d: %v", part.PartID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update part", "details": err.Error()})
+		return
+	}
+	respondParts(c, http.StatusOK, item.OverhaulID)
+}
+
+// deletePart menghapus baris part yang belum memiliki pergerakan stok
+func deletePart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	part, ok := findPart(c, item)
+	if !ok {
+		return
+	}
+	if part.FittedQty != 0 || part.RemovedQty != 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Part already has stock movements and cannot be deleted"})
+		return
+	}
+	if err := db.Delete(&Part{}, part.PartID).Error; err != nil {
+		log.Printf("Error deleting part %d: %v", part.PartID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete part", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindMovementQty membaca body pergerakan part {"qty": n, "location": "..."}
+func bindMovementQty(c *gin.Context) (int, string, bool) {
+	var req struct {
+		Qty      int    `json:"qty"`
+		Location string `json:"location"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return 0, "", false
+	}
+	if req.Qty <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "qty must be greater than 0"})
+		return 0, "", false
+	}
+	return req.Qty, strings.TrimSpace(req.Location), true
+}
+
+// fitPart mencatat part baru yang dipasang: stok dikeluarkan dari inventory sumber
+// dan jumlah aktual terpasang bertambah. Body: {"qty": n}
+func fitPart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	part, ok := findPart(c, item)
+	if !ok {
+		return
+	}
+	qty, _, ok := bindMovementQty(c)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if part.InventoryID == nil {
+			return errInventoryGone
+		}
+		var inv Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, *part.InventoryID).Error; err != nil {
+			return err
+		}
+		if qty > inv.Quantity {
+			return &StockError{Available: inv.Quantity}
+		}
+		if err := tx.Model(&Inventory{}).Where("inventory_id = ?", inv.InventoryID).
+			Update("quantity", gorm.Expr("quantity - ?", qty)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Part{}).Where("part_id = ?", part.PartID).
+			Update("fitted_qty", gorm.Expr("fitted_qty + ?", qty)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&PartMovement{
+			PartID: part.PartID, OverhaulID: item.OverhaulID, InventoryID: &inv.InventoryID,
+			MovementType: movementIssue, Qty: qty, CreatedAt: time.Now(),
+		}).Error
+	})
+	var stockErr *StockError
+	switch {
+	case errors.As(err, &stockErr):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Not enough stock for %s: requested %d, available %d", part.ItemName, qty, stockErr.Available)})
+		return
+	case errors.Is(err, errInventoryGone):
+		c.JSON(http.StatusConflict, gin.H{"error": "Inventory item for this part no longer exists"})
+		return
+	case err != nil:
+		log.Printf("Error issuing part %d for overhaul %d: %v", part.PartID, item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue part from inventory", "details": err.Error()})
+		return
+	}
+	respondParts(c, http.StatusOK, item.OverhaulID)
+}
+
+// unfitPart membatalkan pemasangan part yang salah dicatat: stok dikembalikan ke inventory
+// sumber dan jumlah aktual terpasang berkurang. Body: {"qty": n}
+func unfitPart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	part, ok := findPart(c, item)
+	if !ok {
+		return
+	}
+	qty, _, ok := bindMovementQty(c)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked Part
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, part.PartID).Error; err != nil {
+			return err
+		}
+		if qty > locked.FittedQty {
+			return &FittedError{Fitted: locked.FittedQty}
+		}
+		if locked.InventoryID == nil {
+			return errInventoryGone
+		}
+		var inv Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, *locked.InventoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInventoryGone
+			}
+			return err
+		}
+		if err := tx.Model(&Inventory{}).Where("inventory_id = ?", inv.InventoryID).
+			Update("quantity", gorm.Expr("COALESCE(quantity, 0) + ?", qty)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Part{}).Where("part_id = ?", part.PartID).
+			Update("fitted_qty", gorm.Expr("fitted_qty - ?", qty)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&PartMovement{
+			PartID: part.PartID, OverhaulID: item.OverhaulID, InventoryID: &inv.InventoryID,
+			MovementType: movementReturn, Qty: qty, CreatedAt: time.Now(),
+		}).Error
+	})
+	var fittedErr *FittedError
+	switch {
+	case errors.As(err, &fittedErr):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot unfit %d x %s: only %d fitted", qty, part.ItemName, fittedErr.Fitted)})
+		return
+	case errors.Is(err, errInventoryGone):
+		c.JSON(http.StatusConflict, gin.H{"error": "Inventory item for this part no longer exists"})
+		return
+	case err != nil:
+		log.Printf("Error unfitting part %d for overhaul %d: %v", part.PartID, item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to return fitted part to inventory", "details": err.Error()})
+		return
+	}
+	respondParts(c, http.StatusOK, item.OverhaulID)
+}
+
+// unserviceableInventory mencari (atau membuat) baris inventory unserviceable untuk item_code dan
+// lokasi yang sama dengan part, sehingga part bekas tidak tercampur dengan stok layak pakai
+func unserviceableInventory(tx *gorm.DB, part Part, location string) (Inventory, error) {
+	if location == "" && part.Inventory != nil {
+		location = part.Inventory.Location
+	}
+	var inv Inventory
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("itemCode = ? AND status = ? AND location = ?", part.ItemCode, inventoryUnserviceable, location).
+		First(&inv).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return inv, err
+	}
+	inv = Inventory{Name: part.ItemName, ItemCode: part.ItemCode, Location: location, Status: inventoryUnserviceable}
+	return inv, tx.Create(&inv).Error
+}
+
+// removePart mencatat part lama yang dilepas dari peralatan. Part tersebut dikembalikan ke
+// inventory dengan status Unserviceable (lokasi default sama dengan item sumber).
+// Body: {"qty": n, "location": "opsional"}
+func removePart(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	part, ok := findPart(c, item)
+	if !ok {
+		return
+	}
+	qty, location, ok := bindMovementQty(c)
+	if !ok {
+		return
+	}
+
+	var target Inventory
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if target, err = unserviceableInventory(tx, part, location); err != nil {
+			return err
+		}
+		if err := tx.Model(&Inventory{}).Where("inventory_id = ?", target.InventoryID).
+			Update("quantity", gorm.Expr("COALESCE(quantity, 0) + ?", qty)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Part{}).Where("part_id = ?", part.PartID).
+			Update("removed_qty", gorm.Expr("removed_qty + ?", qty)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&PartMovement{
+			PartID: part.PartID, OverhaulID: item.OverhaulID, InventoryID: &target.InventoryID,
+			MovementType: movementRemoved, Qty: qty, CreatedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Error returning removed part %d for overhaul %d: %v", part.PartID, item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to return removed part to inventory", "details": err.Error()})
+		return
+	}
+	log.Printf("Overhaul %d: %d x %s returned as unserviceable to inventory %d", item.OverhaulID, qty, part.ItemCode, target.InventoryID)
+	respondParts(c, http.StatusOK, item.OverhaulID)
+}
@@ -0,0 +1,234 @@
+package overhaul
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kai-backend/upload"
+)
+
+// StepPhoto - Foto bukti pengerjaan pada langkah job card
+type StepPhoto struct {
+	PhotoID     int       `json:"id" gorm:"column:photo_id;primaryKey;autoIncrement"`
+	StepID      int       `json:"step_id" gorm:"column:step_id"`
+	FileName    string    `json:"fileName" gorm:"column:file_name"`
+	StoredPath  string    `json:"-" gorm:"column:stored_path"`
+	ContentType string    `json:"contentType" gorm:"column:content_type"`
+	Size        int64     `json:"size" gorm:"column:size"`
+	UploadedAt  time.Time `json:"uploadedAt" gorm:"column:uploaded_at"`
+}
+
+func (StepPhoto) TableName() string {
+	return "overhaul_step_photo"
+}
+
+// photoRoot mengembalikan direktori penyimpanan foto (env OVERHAUL_PHOTO_ROOT)
+func photoRoot() string {
+	if root := os.Getenv("OVERHAUL_PHOTO_ROOT"); root != "" {
+		return root
+	}
+	return filepath.Join("storage", "overhaul")
+}
+
+// maxPhotoBytes mengembalikan batas ukuran foto (env OVERHAUL_PHOTO_MAX_MB, default 10 MB)
+func maxPhotoBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("OVERHAUL_PHOTO_MAX_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 10 << 20
+}
+
+// storePhotoFile menyimpan foto ke <root>/<overhaul_id>/<step_id>/. Jenis file diperiksa dari
+// isinya (bukan dari header) sehingga hanya gambar yang diterima.
+func storePhotoFile(fh *multipart.FileHeader, overhaulID, stepID int) (StepPhoto, error) {
+	photo := StepPhoto{StepID: stepID, FileName: filepath.Base(fh.Filename)}
+
+	src, err := fh.Open()
+	if err != nil {
+		return photo, err
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return photo, err
+	}
+	photo.ContentType = http.DetectContentType(head[:n])
+	if !strings.HasPrefix(photo.ContentType, "image/") {
+		return photo, errNotImage
+	}
+
+	relDir := filepath.Join(strconv.Itoa(overhaulID), strconv.Itoa(stepID))
+	dir := filepath.Join(photoRoot(), relDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return photo, err
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return photo, err
+	}
+	defer os.Remove(tmp.Name()) // Tidak berpengaruh setelah file di-rename
+
+	size, err := io.Copy(tmp, io.MultiReader(bytes.NewReader(head[:n]), src))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return photo, err
+	}
+
+	photo.UploadedAt = time.Now()
+	photo.StoredPath = filepath.Join(relDir, strconv.FormatInt(photo.UploadedAt.UnixNano(), 10)+"_"+upload.SafeFileName(fh.Filename, "photo"))
+	if err := os.Rename(tmp.Name(), filepath.Join(photoRoot(), photo.StoredPath)); err != nil {
+		return photo, err
+	}
+	photo.Size = size
+	return photo, nil
+}
+
+var errNotImage = errors.New("uploaded file is not an image")
+
+// removePhotoFile menghapus file foto dari disk; kegagalan hanya dicatat di log
+func removePhotoFile(photo StepPhoto) {
+	if photo.StoredPath == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(photoRoot(), photo.StoredPath)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing photo file %s: %v", photo.StoredPath, err)
+	}
+}
+
+// removeOverhaulPhotos menghapus direktori foto overhaul dari disk; dipanggil setelah purge berhasil
+func removeOverhaulPhotos(overhaulID int) {
+	if err := os.RemoveAll(filepath.Join(photoRoot(), strconv.Itoa(overhaulID))); err != nil {
+		log.Printf("Error removing photo files for overhaul %d: %v", overhaulID, err)
+	}
+}
+
+// findPhoto mengambil foto langkah berdasarkan parameter :photoId
+func findPhoto(c *gin.Context, step JobCardStep) (StepPhoto, bool) {
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return StepPhoto{}, false
+	}
+	for _, photo := range step.Photos {
+		if photo.PhotoID == photoID {
+			return photo, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+	return StepPhoto{}, false
+}
+
+// findStepFromPath mengambil overhaul, job card dan langkah dari parameter URL
+func findStepFromPath(c *gin.Context) (Overhaul, JobCardStep, bool) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return item, JobCardStep{}, false
+	}
+	card, ok := findJobCard(c, item)
+	if !ok {
+		return item, JobCardStep{}, false
+	}
+	step, ok := findStep(c, card)
+	return item, step, ok
+}
+
+// uploadStepPhoto mengunggah foto (multipart field 'photo') ke langkah yang belum ditandatangani
+func uploadStepPhoto(c *gin.Context) {
+	item, step, ok := findStepFromPath(c)
+	if !ok {
+		return
+	}
+	if step.SignedOffAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Step has been signed off; revoke the sign-off before adding photos"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPhotoBytes())
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Photo exceeds the %d MB limit", maxPhotoBytes()>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photo is required (multipart field 'photo')"})
+		return
+	}
+
+	photo, err := storePhotoFile(fh, item.OverhaulID, step.StepID)
+	if errors.Is(err, errNotImage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only image files can be uploaded", "details": photo.ContentType})
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing photo for step %d: %v", step.StepID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store photo", "details": err.Error()})
+		return
+	}
+	if err := db.Create(&photo).Error; err != nil {
+		removePhotoFile(photo)
+		log.Printf("Error saving photo for step %d: %v", step.StepID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, photo)
+}
+
+// getStepPhoto mengirim file foto langkah
+func getStepPhoto(c *gin.Context) {
+	_, step, ok := findStepFromPath(c)
+	if !ok {
+		return
+	}
+	photo, ok := findPhoto(c, step)
+	if !ok {
+		return
+	}
+	path := filepath.Join(photoRoot(), photo.StoredPath)
+	if _, err := os.Stat(path); err != nil {
+		log.Printf("Error reading photo file %s: %v", photo.StoredPath, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Photo file is missing from storage"})
+		return
+	}
+	c.Header("Content-Type", photo.ContentType)
+	c.File(path)
+}
+
+// deleteStepPhoto menghapus foto dari langkah yang belum ditandatangani
+func deleteStepPhoto(c *gin.Context) {
+	_, step, ok := findStepFromPath(c)
+	if !ok {
+		return
+	}
+	photo, ok := findPhoto(c, step)
+	if !ok {
+		return
+	}
+	if step.SignedOffAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Step has been signed off; revoke the sign-off before removing photos"})
+		return
+	}
+	if err := db.Delete(&StepPhoto{}, photo.PhotoID).Error; err != nil {
+		log.Printf("Error deleting photo %d: %v", photo.PhotoID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete photo", "details": err.Error()})
+		return
+	}
+	removePhotoFile(photo)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,295 @@
+package overhaul
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/pdfdoc"
+)
+
+// reportTimeLayout - Format tanggal dan jam pada laporan cetak
+const reportTimeLayout = "02-01-2006 15:04"
+
+// QualityControl - Referensi minimal ke tabel 'quality_control' untuk hasil QC pada laporan overhaul
+type QualityControl struct {
+	QcID        int       `gorm:"column:qc_id;primaryKey"`
+	ProductName string    `gorm:"column:product_name"`
+	BatchCode   string    `gorm:"column:batch_code"`
+	Status      string    `gorm:"column:status"`
+	TestedCount int       `gorm:"column:tested_count"`
+	PassedCount int       `gorm:"column:passed_count"`
+	QcDate      time.Time `gorm:"column:qc_date"`
+	OverhaulID  *int      `gorm:"column:overhaul_id"`
+
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"` // Entri QC di trash tidak dilaporkan
+}
+
+func (QualityControl) TableName() string {
+	return "quality_control"
+}
+
+// reportNumber membentuk nomor laporan penyelesaian dari ID overhaul
+func reportNumber(id int) string {
+	return fmt.Sprintf("RPT-OVH-%05d", id)
+}
+
+// formatTime menampilkan timestamp laporan, "-" jika belum terisi
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format(reportTimeLayout)
+}
+
+// stepLimits menampilkan batas ukur langkah (min - max satuan)
+func stepLimits(step JobCardStep) string {
+	if !step.RequiresMeasurement {
+		return "-"
+	}
+	limits := pdfdoc.FormatValue(step.MinValue) + " - " + pdfdoc.FormatValue(step.MaxValue)
+	if step.Unit != "" {
+		limits += " " + step.Unit
+	}
+	return limits
+}
+
+// stepResult menampilkan hasil langkah: belum dikerjakan, OK, atau di luar batas
+func stepResult(step JobCardStep) string {
+	switch {
+	case step.SignedOffAt == nil:
+		return "Belum"
+	case step.WithinLimits != nil && !*step.WithinLimits:
+		return "Di luar batas"
+	default:
+		return "OK"
+	}
+}
+
+// crewName menampilkan anggota kru sebagai NIP beserta jabatannya
+func crewName(m CrewMember) string {
+	if m.Personalia == nil {
+		return m.NIP
+	}
+	if m.Personalia.Jabatan == "" {
+		return m.Personalia.NIP
+	}
+	return m.Personalia.NIP + " (" + m.Personalia.Jabatan + ")"
+}
+
+// exportReportPDF mencetak laporan penyelesaian overhaul untuk pemilik peralatan: data peralatan,
+// kru, hasil checklist beserta nilai ukur, part yang diganti, hasil QC dan timeline status.
+// Laporan hanya diterbitkan untuk overhaul berstatus Selesai.
+func exportReportPDF(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	if item.Status != statusSelesai {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completion report is only available once the overhaul is " + statusSelesai})
+		return
+	}
+	items := []Overhaul{item}
+	if err := attachCrews(items); err != nil {
+		log.Printf("Error fetching crew for overhaul report %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crew", "details": err.Error()})
+		return
+	}
+	if err := attachSLA(items); err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA targets", "details": err.Error()})
+		return
+	}
+	item = items[0]
+
+	var asset *Asset
+	if item.AssetID != nil {
+		var found Asset
+		if err := db.First(&found, *item.AssetID).Error; err == nil {
+			asset = &found
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error fetching asset for overhaul report %d: %v", item.OverhaulID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch asset", "details": err.Error()})
+			return
+		}
+	}
+	cards, err := loadJobCards(db, item.OverhaulID)
+	if err != nil {
+		log.Printf("Error fetching job cards for overhaul report %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job cards", "details": err.Error()})
+		return
+	}
+	parts, err := loadParts(db, item.OverhaulID)
+	if err != nil {
+		log.Printf("Error fetching parts for overhaul report %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parts", "details": err.Error()})
+		return
+	}
+	var qcEntries []QualityControl
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).Order("qc_date, qc_id").Find(&qcEntries).Error; err != nil {
+		log.Printf("Error fetching QC results for overhaul report %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch QC results", "details": err.Error()})
+		return
+	}
+	var history []StatusHistory
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).Order("changed_at, status_history_id").Find(&history).Error; err != nil {
+		log.Printf("Error fetching status history for overhaul report %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history", "details": err.Error()})
+		return
+	}
+
+	number := reportNumber(item.OverhaulID)
+	doc := pdfdoc.New("P", "Laporan Penyelesaian Overhaul", number)
+
+	doc.Section("Data Peralatan")
+	location := "-"
+	if item.Location != nil && *item.Location != "" {
+		location = *item.Location
+	}
+	fields := []pdfdoc.Field{
+		{Label: "No. Laporan", Value: number},
+		{Label: "Status", Value: item.Status},
+		{Label: "Pekerjaan", Value: item.Name},
+		{Label: "Jenis Peralatan", Value: item.EquipmentType},
+		{Label: "Lokasi", Value: location},
+		{Label: "Progress", Value: fmt.Sprintf("%d%%", item.Progress)},
+	}
+	if asset != nil {
+		fields = append(fields,
+			pdfdoc.Field{Label: "Kode Aset", Value: asset.AssetCode},
+			pdfdoc.Field{Label: "Nama Aset", Value: asset.Name},
+			pdfdoc.Field{Label: "No. Seri", Value: asset.SerialNumber},
+			pdfdoc.Field{Label: "Jam Operasi", Value: strconv.FormatFloat(asset.OperatingHours, 'f', -1, 64)},
+		)
+	}
+	doc.Fields(fields)
+
+	doc.Section("Kru Pelaksana")
+	crewRows := make([][]string, 0, len(item.Crew))
+	for i, m := range item.Crew {
+		jabatan, divisi := "", ""
+		if m.Personalia != nil {
+			jabatan, divisi = m.Personalia.Jabatan, m.Personalia.Divisi
+		}
+		crewRows = append(crewRows, []string{
+			strconv.Itoa(i + 1), m.NIP, jabatan, divisi, m.Role, strconv.FormatFloat(m.AssignedHours, 'f', -1, 64),
+		})
+	}
+	doc.Table([]string{"No", "NIP", "Jabatan", "Divisi", "Peran", "Jam"}, []float64{8, 30, 40, 35, 25, 15}, crewRows)
+
+	doc.Section("Hasil Checklist")
+	if len(cards) == 0 {
+		doc.Paragraph("Overhaul ini tidak memiliki job card.")
+	}
+	for _, card := range cards {
+		doc.Paragraph(fmt.Sprintf("%s (%d/%d langkah, %d%%)", card.Title, card.CompletedSteps, card.TotalSteps, card.Progress))
+		stepRows := make([][]string, 0, len(card.Steps))
+		for _, step := range card.Steps {
+			measured := "-"
+			if step.MeasuredValue != nil {
+				measured = pdfdoc.FormatValue(step.MeasuredValue)
+				if step.Unit != "" {
+					measured += " " + step.Unit
+				}
+			}
+			technician := "-"
+			if step.Technician != nil {
+				technician = step.Technician.NIP
+			}
+			stepRows = append(stepRows, []string{
+				strconv.Itoa(step.Sequence), step.Description, stepLimits(step), measured, stepResult(step),
+				technician, formatTime(step.SignedOffAt), step.Remarks,
+			})
+		}
+		doc.Table([]string{"No", "Langkah", "Batas", "Hasil Ukur", "Hasil", "Teknisi", "Tanda Tangan", "Catatan"},
+			[]float64{7, 45, 22, 18, 17, 20, 25, 30}, stepRows)
+	}
+
+	doc.Section("Part Diganti")
+	partRows := make([][]string, 0, len(parts))
+	for _, part := range parts {
+		if part.FittedQty <= 0 {
+			continue // Part yang hanya direncanakan tidak ikut dilaporkan sebagai diganti
+		}
+		partRows = append(partRows, []string{
+			strconv.Itoa(len(partRows) + 1), part.ItemCode, part.ItemName, strconv.Itoa(part.PlannedQty),
+			strconv.Itoa(part.FittedQty), strconv.Itoa(part.RemovedQty), part.Notes,
+		})
+	}
+	doc.Table([]string{"No", "Kode", "Part", "Rencana", "Dipasang", "Dilepas", "Catatan"}, []float64{8, 25, 45, 16, 16, 16, 40}, partRows)
+
+	doc.Section("Hasil Quality Control")
+	qcRows := make([][]string, 0, len(qcEntries))
+	for _, qc := range qcEntries {
+		passRate := "-"
+		if qc.TestedCount > 0 {
+			passRate = fmt.Sprintf("%.0f%%", float64(qc.PassedCount)/float64(qc.TestedCount)*100)
+		}
+		date := "-"
+		if !qc.QcDate.IsZero() {
+			date = qc.QcDate.Format("02-01-2006")
+		}
+		qcRows = append(qcRows, []string{
+			strconv.Itoa(qc.QcID), date, qc.ProductName, qc.BatchCode, qc.Status,
+			fmt.Sprintf("%d / %d", qc.PassedCount, qc.TestedCount), passRate,
+		})
+	}
+	doc.Table([]string{"ID QC", "Tanggal", "Produk", "Batch", "Status", "Lulus / Diuji", "Pass Rate"}, []float64{12, 20, 40, 25, 20, 20, 17}, qcRows)
+
+	doc.Section("Timeline")
+	timeline := []pdfdoc.Field{
+		{Label: "Mulai", Value: formatTime(item.StartedAt)},
+		{Label: "Selesai", Value: formatTime(item.CompletedAt)},
+		{Label: "Estimasi Selesai", Value: formatTime(item.Estimate)},
+		{Label: "Batas SLA", Value: formatTime(item.SLADueAt)},
+	}
+	if item.TurnaroundDays != nil {
+		timeline = append(timeline, pdfdoc.Field{Label: "Turnaround", Value: fmt.Sprintf("%.1f hari", *item.TurnaroundDays)})
+	}
+	sla := "Memenuhi"
+	if item.SLADueAt == nil {
+		sla = "-"
+	} else if item.SLABreached {
+		sla = "Terlambat"
+	}
+	timeline = append(timeline, pdfdoc.Field{Label: "Status SLA", Value: sla})
+	doc.Fields(timeline)
+	historyRows := make([][]string, 0, len(history))
+	for i, h := range history {
+		from := "-"
+		if h.FromStatus != nil {
+			from = *h.FromStatus
+		}
+		historyRows = append(historyRows, []string{strconv.Itoa(i + 1), h.ChangedAt.Format(reportTimeLayout), from, h.ToStatus})
+	}
+	doc.Table([]string{"No", "Waktu", "Dari Status", "Ke Status"}, []float64{8, 35, 45, 45}, historyRows)
+
+	// Lead kru menandatangani sebagai pelaksana; inspector (jika ada) sebagai pemeriksa
+	var lead, inspector []string
+	for _, m := range item.Crew {
+		switch m.Role {
+		case roleLead:
+			lead = append(lead, crewName(m))
+		case roleInspector:
+			inspector = append(inspector, crewName(m))
+		}
+	}
+	doc.Signatures([]pdfdoc.Signature{
+		{Role: "Pelaksana", Name: strings.Join(lead, ", ")},
+		{Role: "Diperiksa oleh (QC)", Name: strings.Join(inspector, ", ")},
+		{Role: "Diterima oleh (Pemilik)"},
+	})
+
+	if err := doc.Send(c, fmt.Sprintf("overhaul_report_%s.pdf", number)); err != nil {
+		log.Printf("Error writing overhaul report PDF to response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write PDF file"})
+		return
+	}
+}
@@ -0,0 +1,386 @@
+package overhaul
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// StatusHistory - Riwayat perubahan status overhaul
+type StatusHistory struct {
+	StatusHistoryID int       `json:"id" gorm:"column:status_history_id;primaryKey;autoIncrement"`
+	OverhaulID      int       `json:"overhaul_id" gorm:"column:overhaul_id"`
+	FromStatus      *string   `json:"fromStatus" gorm:"column:from_status"` // null untuk status awal saat overhaul dibuat
+	ToStatus        string    `json:"toStatus" gorm:"column:to_status"`
+	ChangedAt       time.Time `json:"changedAt" gorm:"column:changed_at"`
+}
+
+func (StatusHistory) TableName() string {
+	return "overhaul_status_history"
+}
+
+// SLATarget - Target turnaround (dalam hari sejak mulai dikerjakan) per jenis peralatan
+type SLATarget struct {
+	SLATargetID   int    `json:"id" gorm:"column:sla_target_id;primaryKey;autoIncrement"`
+	EquipmentType string `json:"equipmentType" gorm:"column:equipment_type"`
+	TargetDays    int    `json:"targetDays" gorm:"column:target_days"`
+}
+
+func (SLATarget) TableName() string {
+	return "overhaul_sla_target"
+}
+
+// applyStatusChange mengisi timestamp status saat status berbeda dari previous (kosong untuk
+// overhaul baru). started_at diisi sekali saat pertama masuk Dalam Proses, atau saat langsung
+// diselesaikan tanpa pernah dimulai (turnaround 0 hari, bukan tidak terukur); completed_at hanya
+// berisi nilai selama status Selesai.
+func applyStatusChange(item *Overhaul, previous string, now time.Time) bool {
+	if item.Status == previous {
+		return false
+	}
+	item.StatusChangedAt = &now
+	if (item.Status == statusDalamProses || item.Status == statusSelesai) && item.StartedAt == nil {
+		item.StartedAt = &now
+	}
+	if item.Status == statusSelesai {
+		item.CompletedAt = &now
+	} else {
+		item.CompletedAt = nil
+	}
+	return true
+}
+
+// estimateLayouts - Format nilai estimate lama saat kolom masih varchar
+var estimateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", dateutil.Layout}
+
+// migrateEstimateColumn mengubah kolom overhaul.estimate dari varchar (skema lama) menjadi
+// datetime. Nilai lama dinormalkan dulu; nilai yang bukan tanggal dicatat di log lalu dikosongkan
+// agar ALTER tidak gagal. Harus dijalankan sebelum query lain membaca Overhaul.
+func migrateEstimateColumn() {
+	var dataType string
+	if err := db.Raw("SELECT DATA_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		Overhaul{}.TableName(), "estimate").Scan(&dataType).Error; err != nil {
+		log.Printf("Error checking overhaul.estimate column type: %v", err)
+		return
+	}
+	if dataType == "" || dataType == "datetime" {
+		return
+	}
+
+	var rows []struct {
+		OverhaulID int
+		Estimate   string
+	}
+	if err := db.Table(Overhaul{}.TableName()).Select("overhaul_id, estimate").
+		Where("estimate IS NOT NULL").Scan(&rows).Error; err != nil {
+		log.Printf("Error fetching legacy overhaul estimates: %v", err)
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			var value interface{}
+			if t, ok := parseEstimate(row.Estimate); ok {
+				value = t.Format("2006-01-02 15:04:05")
+			} else {
+				log.Printf("Overhaul %d: estimate %q is not a date, cleared", row.OverhaulID, row.Estimate)
+			}
+			if err := tx.Table(Overhaul{}.TableName()).Where("overhaul_id = ?", row.OverhaulID).
+				Update("estimate", value).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error normalizing legacy overhaul estimates: %v", err)
+		return
+	}
+	if err := db.Exec("ALTER TABLE `overhaul` MODIFY `estimate` datetime DEFAULT NULL").Error; err != nil {
+		log.Printf("Error converting overhaul.estimate to datetime: %v", err)
+		return
+	}
+	log.Printf("Converted overhaul.estimate to datetime (%d values normalized).", len(rows))
+}
+
+// parseEstimate membaca nilai estimate lama dalam salah satu estimateLayouts
+func parseEstimate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range estimateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// recordStatusChange mencatat perubahan status ke overhaul_status_history
+func recordStatusChange(tx *gorm.DB, item Overhaul, previous string) error {
+	if item.Status == previous || item.StatusChangedAt == nil {
+		return nil
+	}
+	row := StatusHistory{OverhaulID: item.OverhaulID, ToStatus: item.Status, ChangedAt: *item.StatusChangedAt}
+	if previous != "" {
+		row.FromStatus = &previous
+	}
+	return tx.Create(&row).Error
+}
+
+// roundDays mengubah durasi menjadi hari dengan satu angka desimal
+func roundDays(d time.Duration) float64 {
+	return math.Round(d.Hours()/24*10) / 10
+}
+
+// computeSLA mengisi turnaround dan status SLA. Untuk overhaul terbuka turnaround berisi lama
+// pengerjaan sampai now. Batas SLA = started_at + target jenis peralatan; tanpa target (atau
+// belum dimulai) estimasi selesai dipakai sebagai batas.
+func computeSLA(item *Overhaul, targets map[string]int, now time.Time) {
+	item.TurnaroundDays, item.SLATargetDays, item.SLADueAt, item.SLABreached = nil, nil, nil, false
+
+	end := now
+	if item.CompletedAt != nil {
+		end = *item.CompletedAt
+	}
+	if item.StartedAt != nil {
+		days := roundDays(end.Sub(*item.StartedAt))
+		item.TurnaroundDays = &days
+	}
+	if target, ok := targets[item.EquipmentType]; ok {
+		item.SLATargetDays = &target
+		if item.StartedAt != nil {
+			due := item.StartedAt.AddDate(0, 0, target)
+			item.SLADueAt = &due
+		}
+	}
+	if item.SLADueAt == nil && item.Estimate != nil {
+		due := *item.Estimate
+		item.SLADueAt = &due
+	}
+	if item.SLADueAt != nil {
+		item.SLABreached = end.After(*item.SLADueAt)
+	}
+}
+
+// loadSLATargets mengembalikan target hari per jenis peralatan
+func loadSLATargets() (map[string]int, error) {
+	var rows []SLATarget
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	targets := make(map[string]int, len(rows))
+	for _, t := range rows {
+		targets[t.EquipmentType] = t.TargetDays
+	}
+	return targets, nil
+}
+
+// attachSLA mengisi turnaround dan status SLA untuk daftar overhaul
+func attachSLA(items []Overhaul) error {
+	targets, err := loadSLATargets()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range items {
+		computeSLA(&items[i], targets, now)
+	}
+	return nil
+}
+
+// getSLATargets menampilkan semua target SLA
+func getSLATargets(c *gin.Context) {
+	targets := []SLATarget{}
+	if err := db.Order("equipment_type").Find(&targets).Error; err != nil {
+		log.Printf("Error fetching SLA targets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA targets", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, targets)
+}
+
+// putSLATarget membuat atau mengubah target SLA untuk jenis peralatan pada URL
+func putSLATarget(c *gin.Context) {
+	equipmentType := strings.TrimSpace(c.Param("equipmentType"))
+	var input struct {
+		TargetDays int `json:"targetDays"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if equipmentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment type is required"})
+		return
+	}
+	if input.TargetDays <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetDays must be greater than 0"})
+		return
+	}
+
+	var target SLATarget
+	err := db.Where("equipment_type = ?", equipmentType).First(&target).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA target", "details": err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		target, status = SLATarget{EquipmentType: equipmentType}, http.StatusCreated
+	}
+	target.TargetDays = input.TargetDays
+	if err := db.Save(&target).Error; err != nil {
+		log.Printf("Error saving SLA target for %s: %v", equipmentType, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save SLA target", "details": err.Error()})
+		return
+	}
+	c.JSON(status, target)
+}
+
+// deleteSLATarget menghapus target SLA jenis peralatan
+func deleteSLATarget(c *gin.Context) {
+	result := db.Where("equipment_type = ?", c.Param("equipmentType")).Delete(&SLATarget{})
+	if result.Error != nil {
+		log.Printf("Error deleting SLA target %s: %v", c.Param("equipmentType"), result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete SLA target", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SLA target not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// getStatusHistory menampilkan riwayat perubahan status satu overhaul
+func getStatusHistory(c *gin.Context) {
+	item, ok := findOverhaul(c)
+	if !ok {
+		return
+	}
+	history := []StatusHistory{}
+	if err := db.Where("overhaul_id = ?", item.OverhaulID).Order("changed_at, status_history_id").Find(&history).Error; err != nil {
+		log.Printf("Error fetching status history for overhaul %d: %v", item.OverhaulID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// TurnaroundSummary - Ringkasan turnaround satu jenis peralatan
+type TurnaroundSummary struct {
+	EquipmentType string `json:"equipmentType"`
+	Completed     int    `json:"completed"`
+	// Measured - Overhaul selesai yang memiliki started_at (dasar rata-rata/min/max)
+	Measured          int      `json:"measured"`
+	AvgTurnaroundDays *float64 `json:"avgTurnaroundDays"`
+	MinTurnaroundDays *float64 `json:"minTurnaroundDays"`
+	MaxTurnaroundDays *float64 `json:"maxTurnaroundDays"`
+	SLATargetDays     *int     `json:"slaTargetDays"`
+	Breached          int      `json:"breached"`
+	BreachRate        float64  `json:"breachRate"` // Persen dari overhaul selesai
+	OpenBreached      int      `json:"openBreached"`
+}
+
+// getTurnaroundReport menghitung rata-rata turnaround per jenis peralatan untuk overhaul yang
+// selesai pada rentang ?from=&to= (YYYY-MM-DD, opsional, berdasarkan completed_at), ditambah
+// jumlah overhaul terbuka yang sudah melewati batas SLA.
+func getTurnaroundReport(c *gin.Context) {
+	query := db.Where("status = ? AND completed_at IS NOT NULL", statusSelesai)
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(dateutil.Layout, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("completed_at >= ?", t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(dateutil.Layout, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("completed_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var completed, open []Overhaul
+	if err := query.Find(&completed).Error; err != nil {
+		log.Printf("Error fetching completed overhauls: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul data", "details": err.Error()})
+		return
+	}
+	if err := db.Where("status IS NULL OR status <> ?", statusSelesai).Find(&open).Error; err != nil {
+		log.Printf("Error fetching open overhauls: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overhaul data", "details": err.Error()})
+		return
+	}
+	targets, err := loadSLATargets()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA targets", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	byType := map[string]*TurnaroundSummary{}
+	totals := map[string]float64{}
+	summaryFor := func(equipmentType string) *TurnaroundSummary {
+		s, ok := byType[equipmentType]
+		if !ok {
+			s = &TurnaroundSummary{EquipmentType: equipmentType}
+			if target, ok := targets[equipmentType]; ok {
+				s.SLATargetDays = &target
+			}
+			byType[equipmentType] = s
+		}
+		return s
+	}
+	for i := range completed {
+		item := &completed[i]
+		computeSLA(item, targets, now)
+		s := summaryFor(item.EquipmentType)
+		s.Completed++
+		if item.SLABreached {
+			s.Breached++
+		}
+		if item.TurnaroundDays == nil {
+			continue
+		}
+		days := *item.TurnaroundDays
+		s.Measured++
+		totals[item.EquipmentType] += days
+		if s.MinTurnaroundDays == nil || days < *s.MinTurnaroundDays {
+			s.MinTurnaroundDays = &days
+		}
+		if s.MaxTurnaroundDays == nil || days > *s.MaxTurnaroundDays {
+			s.MaxTurnaroundDays = &days
+		}
+	}
+	for i := range open {
+		computeSLA(&open[i], targets, now)
+		if open[i].SLABreached {
+			summaryFor(open[i].EquipmentType).OpenBreached++
+		}
+	}
+
+	report := make([]TurnaroundSummary, 0, len(byType))
+	for equipmentType, s := range byType {
+		if s.Measured > 0 {
+			avg := math.Round(totals[equipmentType]/float64(s.Measured)*10) / 10
+			s.AvgTurnaroundDays = &avg
+		}
+		if s.Completed > 0 {
+			s.BreachRate = math.Round(float64(s.Breached)/float64(s.Completed)*1000) / 10
+		}
+		report = append(report, *s)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].EquipmentType < report[j].EquipmentType })
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,100 @@
+package overhaul
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyStatusChange(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	earlier := now.AddDate(0, 0, -4)
+	tests := []struct {
+		name          string
+		previous      string
+		status        string
+		startedAt     *time.Time
+		wantChanged   bool
+		wantStarted   *time.Time
+		wantCompleted bool
+	}{
+		{"unchanged", statusDalamProses, statusDalamProses, &earlier, false, &earlier, false},
+		{"start", statusBelumDimulai, statusDalamProses, nil, true, &now, false},
+		{"finish after start", statusDalamProses, statusSelesai, &earlier, true, &earlier, true},
+		{"finish without start", statusBelumDimulai, statusSelesai, nil, true, &now, true},
+		{"reopen", statusSelesai, statusDalamProses, &earlier, true, &earlier, false},
+		{"new overhaul", "", statusBelumDimulai, nil, true, nil, false},
+	}
+	for _, tt := range tests {
+		item := Overhaul{Status: tt.status, StartedAt: tt.startedAt}
+		if got := applyStatusChange(&item, tt.previous, now); got != tt.wantChanged {
+			t.Errorf("%s: changed = %v, want %v", tt.name, got, tt.wantChanged)
+		}
+		if (item.StartedAt == nil) != (tt.wantStarted == nil) || (item.StartedAt != nil && !item.StartedAt.Equal(*tt.wantStarted)) {
+			t.Errorf("%s: started_at = %v, want %v", tt.name, item.StartedAt, tt.wantStarted)
+		}
+		if tt.wantChanged && (item.CompletedAt != nil) != tt.wantCompleted {
+			t.Errorf("%s: completed_at = %v, want set %v", tt.name, item.CompletedAt, tt.wantCompleted)
+		}
+	}
+}
+
+func TestComputeSLA(t *testing.T) {
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	at := func(day int) *time.Time {
+		t := time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+		return &t
+	}
+	targets := map[string]int{"Lokomotif": 10}
+	tests := []struct {
+		name           string
+		item           Overhaul
+		wantTurnaround *float64
+		wantDue        *time.Time
+		wantBreached   bool
+	}{
+		{"open within target", Overhaul{EquipmentType: "Lokomotif", StartedAt: at(15)}, floatPtr(5), at(25), false},
+		{"open past target", Overhaul{EquipmentType: "Lokomotif", StartedAt: at(5)}, floatPtr(15), at(15), true},
+		{"completed on time", Overhaul{EquipmentType: "Lokomotif", StartedAt: at(1), CompletedAt: at(8)}, floatPtr(7), at(11), false},
+		{"no target uses estimate", Overhaul{EquipmentType: "Radio", StartedAt: at(1), Estimate: at(18)}, floatPtr(19), at(18), true},
+		{"not started uses estimate", Overhaul{EquipmentType: "Lokomotif", Estimate: at(25)}, nil, at(25), false},
+		{"no target or estimate", Overhaul{EquipmentType: "Radio"}, nil, nil, false},
+	}
+	for _, tt := range tests {
+		item := tt.item
+		computeSLA(&item, targets, now)
+		if (item.TurnaroundDays == nil) != (tt.wantTurnaround == nil) || (item.TurnaroundDays != nil && *item.TurnaroundDays != *tt.wantTurnaround) {
+			t.Errorf("%s: turnaround = %v, want %v", tt.name, item.TurnaroundDays, tt.wantTurnaround)
+		}
+		if (item.SLADueAt == nil) != (tt.wantDue == nil) || (item.SLADueAt != nil && !item.SLADueAt.Equal(*tt.wantDue)) {
+			t.Errorf("%s: due = %v, want %v", tt.name, item.SLADueAt, tt.wantDue)
+		}
+		if item.SLABreached != tt.wantBreached {
+			t.Errorf("%s: breached = %v, want %v", tt.name, item.SLABreached, tt.wantBreached)
+		}
+	}
+}
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"2024-03-05", "2024-03-05 00:00:00", true},
+		{" 2024-03-05 ", "2024-03-05 00:00:00", true},
+		{"2024-03-05 14:30:00", "2024-03-05 14:30:00", true},
+		{"2024-03-05T14:30:00Z", "2024-03-05 14:30:00", true},
+		{"2 minggu", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseEstimate(tt.value)
+		if ok != tt.wantOK || (ok && got.Format("2006-01-02 15:04:05") != tt.want) {
+			t.Errorf("parseEstimate(%q) = %v, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,240 @@
+// Package pdfdoc menyediakan layout PDF bersama (kop, judul, tabel, kotak tanda tangan
+// dan nomor halaman) agar dokumen cetak dari setiap modul berpenampilan seragam.
+package pdfdoc
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Organization ditampilkan pada kop setiap halaman
+const Organization = "PT Kereta Api Indonesia (Persero) - Balai Yasa"
+
+const (
+	margin     = 15.0
+	lineHeight = 6.0
+	rowHeight  = 7.0
+)
+
+// Document membungkus gofpdf.Fpdf dengan layout standar. Fpdf tetap diekspos
+// untuk kebutuhan gambar khusus di luar helper yang tersedia.
+type Document struct {
+	*gofpdf.Fpdf
+	tr func(string) string
+}
+
+// New membuat dokumen A4 baru dengan kop berisi judul dan nomor dokumen.
+// orientation: "P" (portrait) atau "L" (landscape).
+func New(orientation, title, docNumber string) *Document {
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	d := &Document{Fpdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin+5)
+	pdf.AliasNbPages("")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(Organization, true)
+
+	printed := time.Now().Format("02-01-2006 15:04")
+	pdf.SetHeaderFunc(func() {
+		width := d.contentWidth()
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(width/2, 5, d.tr(Organization), "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(width/2, 5, d.tr(docNumber), "", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Arial", "B", 14)
+		pdf.CellFormat(width, 9, d.tr(title), "B", 1, "L", false, 0, "")
+		pdf.Ln(4)
+	})
+	pdf.SetFooterFunc(func() {
+		width := d.contentWidth()
+		pdf.SetY(-margin)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(width/2, 5, d.tr("Dicetak "+printed), "T", 0, "L", false, 0, "")
+		pdf.CellFormat(width/2, 5, fmt.Sprintf("Halaman %d/{nb}", pdf.PageNo()), "T", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	return d
+}
+
+// contentWidth mengembalikan lebar area tulis di antara margin kiri dan kanan
+func (d *Document) contentWidth() float64 {
+	w, _ := d.GetPageSize()
+	left, _, right, _ := d.GetMargins()
+	return w - left - right
+}
+
+// ensureSpace pindah ke halaman baru jika sisa ruang kurang dari h milimeter
+func (d *Document) ensureSpace(h float64) bool {
+	_, pageH := d.GetPageSize()
+	_, _, _, bottom := d.GetMargins()
+	if d.GetY()+h > pageH-bottom {
+		d.AddPage()
+		return true
+	}
+	return false
+}
+
+// fit memotong teks dengan elipsis agar muat pada lebar kolom
+func (d *Document) fit(text string, width float64) string {
+	text = d.tr(text)
+	if d.GetStringWidth(text) <= width-2 {
+		return text
+	}
+	for len(text) > 0 && d.GetStringWidth(text+"...") > width-2 {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+// FormatValue menampilkan angka tanpa nol desimal yang tidak perlu, "-" jika kosong
+func FormatValue(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// Section menulis sub-judul bagian dokumen
+func (d *Document) Section(title string) {
+	d.ensureSpace(20)
+	d.Ln(2)
+	d.SetFont("Arial", "B", 11)
+	d.SetFillColor(230, 230, 230)
+	d.CellFormat(d.contentWidth(), rowHeight, d.tr(title), "", 1, "L", true, 0, "")
+	d.Ln(1)
+}
+
+// Field - Pasangan label dan nilai untuk blok informasi
+type Field struct {
+	Label string
+	Value string
+}
+
+// Fields menulis blok "label : nilai" dalam dua kolom
+func (d *Document) Fields(fields []Field) {
+	half := d.contentWidth() / 2
+	labelW := 35.0
+	d.SetFont("Arial", "", 9)
+	for i, f := range fields {
+		if i%2 == 0 {
+			d.ensureSpace(lineHeight)
+		}
+		d.SetFont("Arial", "B", 9)
+		d.CellFormat(labelW, lineHeight, d.fit(f.Label, labelW), "", 0, "L", false, 0, "")
+		d.SetFont("Arial", "", 9)
+		ln := 0
+		if i%2 == 1 || i == len(fields)-1 {
+			ln = 1
+		}
+		d.CellFormat(half-labelW, lineHeight, d.fit(": "+f.Value, half-labelW), "", ln, "L", false, 0, "")
+	}
+	d.Ln(1)
+}
+
+// Paragraph menulis teks bebas yang dibungkus otomatis
+func (d *Document) Paragraph(text string) {
+	d.SetFont("Arial", "", 9)
+	d.MultiCell(d.contentWidth(), 5, d.tr(text), "", "L", false)
+	d.Ln(1)
+}
+
+// Table menulis tabel berborder. widths adalah bobot relatif kolom yang diskalakan ke
+// lebar halaman. Header diulang setiap kali tabel berlanjut ke halaman berikutnya.
+func (d *Document) Table(headers []string, widths []float64, rows [][]string) {
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	cols := make([]float64, len(widths))
+	for i, w := range widths {
+		cols[i] = w / total * d.contentWidth()
+	}
+
+	header := func() {
+		d.SetFont("Arial", "B", 9)
+		d.SetFillColor(240, 240, 240)
+		for i, h := range headers {
+			d.CellFormat(cols[i], rowHeight, d.fit(h, cols[i]), "1", 0, "C", true, 0, "")
+		}
+		d.Ln(-1)
+		d.SetFont("Arial", "", 9)
+	}
+
+	d.ensureSpace(rowHeight * 2)
+	header()
+	if len(rows) == 0 {
+		d.SetFont("Arial", "I", 9)
+		d.CellFormat(d.contentWidth(), rowHeight, "Tidak ada data", "1", 1, "C", false, 0, "")
+		d.SetFont("Arial", "", 9)
+	}
+	for _, row := range rows {
+		if d.ensureSpace(rowHeight) {
+			header()
+		}
+		for i := range cols {
+			value := ""
+			if i < len(row) {
+				value = row[i]
+			}
+			d.CellFormat(cols[i], rowHeight, d.fit(value, cols[i]), "1", 0, "L", false, 0, "")
+		}
+		d.Ln(-1)
+	}
+	d.Ln(2)
+}
+
+// Signature - Satu kotak tanda tangan/persetujuan
+type Signature struct {
+	Role string // mis. "Dibuat oleh"
+	Name string // Nama/NIP penanda tangan; kosong untuk diisi manual
+}
+
+// Signatures menulis kotak tanda tangan berdampingan dalam satu baris
+func (d *Document) Signatures(signatures []Signature) {
+	if len(signatures) == 0 {
+		return
+	}
+	const boxH = 32.0
+	d.ensureSpace(boxH + 10)
+	d.Ln(4)
+
+	width := d.contentWidth() / float64(len(signatures))
+	x, y := d.GetX(), d.GetY()
+	for i, s := range signatures {
+		left := x + float64(i)*width
+		d.Rect(left, y, width, boxH, "D")
+		d.SetXY(left, y+1)
+		d.SetFont("Arial", "B", 9)
+		d.CellFormat(width, 5, d.fit(s.Role, width), "", 0, "C", false, 0, "")
+		d.SetXY(left, y+boxH-12)
+		d.SetFont("Arial", "", 9)
+		name := s.Name
+		if name == "" {
+			name = "(...............................)"
+		}
+		d.CellFormat(width, 5, d.fit(name, width), "", 0, "C", false, 0, "")
+		d.SetXY(left, y+boxH-6)
+		d.SetFont("Arial", "", 8)
+		d.CellFormat(width, 5, "Tanggal: ....................", "", 0, "C", false, 0, "")
+	}
+	d.SetXY(x, y+boxH+2)
+}
+
+// Send menulis dokumen ke response HTTP sebagai file unduhan
+func (d *Document) Send(c *gin.Context, filename string) error {
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Cache-Control", "no-cache")
+	return d.Output(c.Writer)
+}
@@ -2,6 +2,7 @@ package personalia
 
 import (
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,10 +12,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"kai-backend/mergepatch"
+
 	// Import untuk Excel
 	"github.com/xuri/excelize/v2"
-	// Import untuk PDF
-	"github.com/jung-kurt/gofpdf"
+	// Layout PDF bersama
+	"kai-backend/pdfdoc"
+	"kai-backend/trash"
 )
 
 // DateOnly struct dan metode-metodenya sudah benar,
@@ -75,6 +79,8 @@ type Profile struct {
 	PhoneNumber  string `json:"phone_number" gorm:"column:phone_number"`
 	EducationID  int    `json:"education_id" gorm:"column:education_id"`
 	ExperienceID int    `json:"experience_id" gorm:"column:experience_id"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Profile di trash tidak ditampilkan
 }
 
 // Struct Personalia (Perubahan di sini: Lokasi dihapus)
@@ -87,8 +93,11 @@ type Personalia struct {
 	JoinDate     string  `json:"joinDate" gorm:"column:join_date;type:date"`
 	PhoneNumber  string  `json:"phoneNumber" gorm:"column:phone_number;type:varchar(50)"`
 	UrgentNumber string  `json:"urgentNumber" gorm:"column:urgent_number;type:varchar(50)"`
+	HourlyRate   float64 `json:"hourlyRate" gorm:"column:hourly_rate"` // Tarif tenaga kerja per jam untuk costing produksi
 	ProfileID    *int    `json:"profile_id" gorm:"column:profile_id"`
 	Profile      Profile `json:"profile,omitempty" gorm:"foreignKey:ProfileID"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
 func (Profile) TableName() string {
@@ -102,6 +111,8 @@ var db *gorm.DB
 
 func Init(database *gorm.DB) {
 	db = database
+	// Personalia yang masih direferensikan modul lain (kru, tim, tugas) dilewati oleh purge
+	trash.Register(trash.Purger{Module: "personalia", Model: &Personalia{}})
 	log.Println("Personalia module initialized. Auto-migration and constraint creation skipped as DB schema is fixed.")
 }
 
@@ -153,6 +164,10 @@ func createPersonalia(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "All fields are required (except ProfileID)"}) // Pesan error disesuaikan
 		return
 	}
+	if newItem.HourlyRate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hourly rate cannot be negative"})
+		return
+	}
 
 	log.Printf("Attempting to create personalia in DB: %+v", newItem) // Log item sebelum disimpan
 	if result := db.Create(&newItem); result.Error != nil {
@@ -197,6 +212,10 @@ func updatePersonalia(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "All fields are required"})
 		return
 	}
+	if updatedItem.HourlyRate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hourly rate cannot be negative"})
+		return
+	}
 
 	var item Personalia
 	if result := db.First(&item, id); result.Error != nil {
@@ -217,6 +236,7 @@ func updatePersonalia(c *gin.Context) {
 	item.JoinDate = updatedItem.JoinDate
 	item.PhoneNumber = updatedItem.PhoneNumber
 	item.UrgentNumber = updatedItem.UrgentNumber
+	item.HourlyRate = updatedItem.HourlyRate
 	item.ProfileID = updatedItem.ProfileID // Sekarang bisa menerima nil/pointer
 
 	log.Printf("Attempting to update personalia ID %d in DB: %+v", id, item) // Log item sebelum disimpan
@@ -235,6 +255,76 @@ func updatePersonalia(c *gin.Context) {
 	c.JSON(http.StatusOK, savedItem)
 }
 
+// patchPersonalia memperbarui sebagian data personalia (JSON Merge Patch, RFC 7396).
+// Hanya field yang dikirim yang divalidasi dan disimpan.
+func patchPersonalia(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid personalia ID"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Invalid patch document", "details": err.Error()})
+		return
+	}
+
+	var item Personalia
+	if result := db.First(&item, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personalia not found"})
+		} else {
+			log.Printf("Error finding personalia with ID %d: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find personalia"})
+		}
+		return
+	}
+
+	var merged Personalia
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	merged.PersonaliaID = item.PersonaliaID
+
+	blank := patch.Blank(map[string]string{
+		"nip": merged.NIP, "jabatan": merged.Jabatan, "divisi": merged.Divisi, "status": merged.Status,
+		"joinDate": merged.JoinDate, "phoneNumber": merged.PhoneNumber, "urgentNumber": merged.UrgentNumber,
+	})
+	if len(blank) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Required fields cannot be empty", "fields": blank})
+		return
+	}
+	if patch.Has("hourlyRate") && merged.HourlyRate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hourly rate cannot be negative"})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &Personalia{}, "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only fields", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		if result := db.Model(&item).Select(columns).Updates(&merged); result.Error != nil {
+			log.Printf("Error patching personalia with ID %d: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update personalia"})
+			return
+		}
+	}
+
+	var savedItem Personalia
+	db.Preload("Profile").First(&savedItem, item.PersonaliaID)
+	c.JSON(http.StatusOK, savedItem)
+}
+
 func deletePersonalia(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -263,6 +353,38 @@ func deletePersonalia(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// getPersonaliaTrash menampilkan personalia yang sedang berada di trash
+func getPersonaliaTrash(c *gin.Context) {
+	items := []Personalia{}
+	if err := trash.List(db.Preload("Profile"), &items); err != nil {
+		log.Printf("Error fetching deleted personalia: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted personalia"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restorePersonalia memulihkan personalia dari trash
+func restorePersonalia(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid personalia ID"})
+		return
+	}
+	if err := trash.Restore(db, &Personalia{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personalia not found in trash"})
+		} else {
+			log.Printf("Error restoring personalia with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore personalia"})
+		}
+		return
+	}
+	var item Personalia
+	db.Preload("Profile").First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
 func AssignProfileToPersonalia(c *gin.Context) {
 	idStr := c.Param("id") // Ambil ID dari URL
 	id, err := strconv.Atoi(idStr)
@@ -380,25 +502,13 @@ func exportPersonaliaToPDF(c *gin.Context) {
 		return
 	}
 
-	pdf := gofpdf.New("P", "mm", "A4", "") // Portrait, milimeter, A4
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(40, 10, "Data Personalia PT Kereta Api Indonesia")
-	pdf.Ln(12) // Line break
+	// Layout PDF bersama (kop, tabel, nomor halaman); landscape agar 10 kolom muat
+	doc := pdfdoc.New("L", "Data Personalia PT Kereta Api Indonesia", "")
 
-	pdf.SetFont("Arial", "B", 10)
-	// Header Tabel
 	header := []string{"ID", "NIP", "Jabatan", "Divisi", "Status", "Join Date", "Phone", "Urgent Phone", "Email", "Address"}
-	colWidths := []float64{10, 25, 25, 25, 15, 25, 25, 25, 30, 40} // Sesuaikan lebar kolom
+	colWidths := []float64{10, 25, 25, 25, 15, 25, 25, 25, 30, 40} // Bobot relatif lebar kolom
 
-	// Print header
-	for i, h := range header {
-		pdf.Cell(colWidths[i], 7, h)
-	}
-	pdf.Ln(-1) // New line
-
-	pdf.SetFont("Arial", "", 8) // Font untuk data
-	// Data Tabel
+	rows := make([][]string, 0, len(personaliaItems))
 	for _, item := range personaliaItems {
 		profileEmail := ""
 		profileAddress := ""
@@ -407,7 +517,7 @@ func exportPersonaliaToPDF(c *gin.Context) {
 			profileAddress = item.Profile.Address
 		}
 
-		row := []string{
+		rows = append(rows, []string{
 			strconv.Itoa(item.PersonaliaID),
 			item.NIP,
 			item.Jabatan,
@@ -418,34 +528,13 @@ func exportPersonaliaToPDF(c *gin.Context) {
 			item.UrgentNumber,
 			profileEmail,
 			profileAddress,
-		}
-
-		// Jika baris terlalu panjang, wrap teks atau sesuaikan lebar kolom.
-		// Untuk contoh ini, kita asumsikan teks cukup pendek atau lebar kolom sudah sesuai.
-		for i, data := range row {
-			// Jika teks terlalu panjang untuk kolom, potong teks
-			if pdf.GetStringWidth(data) > colWidths[i] {
-				// Memotong teks jika terlalu panjang
-				for len(data) > 0 && pdf.GetStringWidth(data) > colWidths[i] {
-					data = data[:len(data)-1]
-				}
-				// Tambahkan elipsis jika teks dipotong
-				if len(data) > 3 { // pastikan ada cukup ruang untuk elipsis
-					data = data[:len(data)-3] + "..."
-				}
-			}
-			pdf.Cell(colWidths[i], 7, data)
-		}
-		pdf.Ln(-1) // New line for next row
+		})
 	}
+	// Teks yang terlalu panjang untuk kolom dipotong dengan elipsis oleh pdfdoc
+	doc.Table(header, colWidths, rows)
 
-	// Set header HTTP untuk download file
-	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=personalia_data_%s.pdf", time.Now().Format("20060102")))
-	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Cache-Control", "no-cache")
-
-	if err := pdf.Output(c.Writer); err != nil {
+	// Set header HTTP untuk download file dan tulis PDF
+	if err := doc.Send(c, fmt.Sprintf("personalia_data_%s.pdf", time.Now().Format("20060102"))); err != nil {
 		log.Printf("Error writing PDF file to response: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write PDF file to response"})
 		return
@@ -458,13 +547,16 @@ func RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("", getAllPersonalia)  // Menangani /api/personalia
 	r.GET("/", getAllPersonalia) // Menangani /api/personalia/
 
+	r.GET("/trash", getPersonaliaTrash)
 	r.GET("/:id", getPersonaliaByID)
 
 	r.POST("", createPersonalia)  // Menangani /api/personalia
 	r.POST("/", createPersonalia) // Menangani /api/personalia/
 
 	r.PUT("/:id", updatePersonalia)
+	r.PATCH("/:id", patchPersonalia)
 	r.DELETE("/:id", deletePersonalia)
+	r.POST("/:id/restore", restorePersonalia)
 	r.PUT("/:id/assign-profile", AssignProfileToPersonalia)
 
 	// NEW: Endpoint untuk export Excel
@@ -0,0 +1,420 @@
+package produksi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Inventory - Referensi minimal ke tabel 'inventory' untuk cek ketersediaan stok BOM
+type Inventory struct {
+	InventoryID int    `json:"id" gorm:"column:inventory_id;primaryKey"`
+	Name        string `json:"name" gorm:"column:name"`
+	Quantity    int    `json:"quantity" gorm:"column:quantity"`
+	Location    string `json:"location" gorm:"column:location"`
+	Status      string `json:"status" gorm:"column:status"`
+	ItemCode    string `json:"itemCode" gorm:"column:itemCode"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
+}
+
+func (Inventory) TableName() string {
+	return "inventory"
+}
+
+// ProduksiMaterial - Satu baris BOM (bill of materials) pada job produksi.
+// Kuantitas disimpan per unit produk; kebutuhan total = QtyPerUnit x Produksi.Target.
+type ProduksiMaterial struct {
+	ProduksiMaterialID int     `json:"id" gorm:"column:produksi_materials_id;primaryKey;autoIncrement"`
+	ProduksiID         int     `json:"produksi_id" gorm:"column:produksi_id"`
+	MaterialsID        int     `json:"materials_id" gorm:"column:materials_id"`
+	InventoryID        *int    `json:"inventory_id,omitempty" gorm:"column:inventory_id"`
+	QtyPerUnit         float64 `json:"qty_per_unit" gorm:"column:qty_per_unit"`
+	Notes              string  `json:"notes" gorm:"column:notes"`
+
+	Material  *Materials `json:"material,omitempty" gorm:"foreignKey:MaterialsID;references:MaterialsID"`
+	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
+}
+
+func (ProduksiMaterial) TableName() string {
+	return "produksi_materials"
+}
+
+// BOMLine - Baris BOM beserta perbandingan kebutuhan vs stok tersedia
+type BOMLine struct {
+	ProduksiMaterial
+	RequiredQty  float64 `json:"required_qty"`
+	AvailableQty *int    `json:"available_qty"` // nil jika baris tidak terhubung ke inventory
+	Shortage     float64 `json:"shortage"`
+	Sufficient   bool    `json:"sufficient"`
+}
+
+// requiredQty menghitung kebutuhan total material untuk target produksi
+func requiredQty(qtyPerUnit float64, target int) float64 {
+	return math.Round(qtyPerUnit*float64(target)*1000) / 1000
+}
+
+// buildBOMLine menghitung kebutuhan dan kekurangan stok untuk satu baris BOM
+func buildBOMLine(line ProduksiMaterial, target int) BOMLine {
+	bl := BOMLine{ProduksiMaterial: line, RequiredQty: requiredQty(line.QtyPerUnit, target)}
+	if line.Inventory != nil {
+		available := line.Inventory.Quantity
+		bl.AvailableQty = &available
+		if shortage := bl.RequiredQty - float64(available); shortage > 0 {
+			bl.Shortage = shortage
+		}
+	} else {
+		// Tanpa referensi inventory stok tidak dapat diverifikasi, seluruh kebutuhan dianggap kurang
+		bl.Shortage = bl.RequiredQty
+	}
+	bl.Sufficient = bl.Shortage == 0
+	return bl
+}
+
+// loadBOM mengambil semua baris BOM untuk satu job produksi beserta master material dan inventory
+func loadBOM(tx *gorm.DB, produksiID int) ([]ProduksiMaterial, error) {
+	var lines []ProduksiMaterial
+	err := tx.Preload("Material").Preload("Inventory").
+		Where("produksi_id = ?", produksiID).
+		Order("produksi_materials_id").
+		Find(&lines).Error
+	return lines, err
+}
+
+// attachMaterials mengisi field `materials` (format lama frontend) dari baris BOM.
+// Qty pada format lama adalah kebutuhan total untuk seluruh target.
+func attachMaterials(items []Produksi) {
+	if len(items) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProduksiID)
+	}
+
+	var lines []ProduksiMaterial
+	if err := db.Preload("Material").Where("produksi_id IN ?", ids).Order("produksi_materials_id").Find(&lines).Error; err != nil {
+		log.Printf("Error fetching BOM lines: %v", err)
+		return
+	}
+
+	byProduksi := make(map[int][]ProduksiMaterial)
+	for _, line := range lines {
+		byProduksi[line.ProduksiID] = append(byProduksi[line.ProduksiID], line)
+	}
+	for i := range items {
+		items[i].MaterialsData = nil
+		for _, line := range byProduksi[items[i].ProduksiID] {
+			if line.Material == nil {
+				continue
+			}
+			items[i].MaterialsData = append(items[i].MaterialsData, Materials{
+				MaterialsID:   line.MaterialsID,
+				MaterialsName: line.Material.MaterialsName,
+				Qty:           int(math.Ceil(requiredQty(line.QtyPerUnit, items[i].Target))),
+				Price:         line.Material.Price,
+				Satuan:        line.Material.Satuan,
+			})
+		}
+	}
+}
+
+// findOrCreateMaterial mencari master material berdasarkan ID atau nama,
+// dan membuat master baru jika belum ada.
+func findOrCreateMaterial(tx *gorm.DB, m Materials) (Materials, error) {
+	var master Materials
+	if m.MaterialsID > 0 {
+		if err := tx.First(&master, m.MaterialsID).Error; err == nil {
+			return master, nil
+		} else if err != gorm.ErrRecordNotFound {
+			return master, err
+		}
+	}
+	err := tx.Where("materials_name = ?", m.MaterialsName).First(&master).Error
+	if err == nil {
+		return master, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return master, err
+	}
+	master = Materials{MaterialsName: m.MaterialsName, Price: m.Price, Satuan: m.Satuan}
+	err = tx.Create(&master).Error
+	return master, err
+}
+
+// seedBOMFromMaterials membuat baris BOM dari array `materials` format lama (qty total per job)
+func seedBOMFromMaterials(tx *gorm.DB, produksiID, target int, items []Materials) error {
+	for _, m := range items {
+		if m.MaterialsName == "" && m.MaterialsID == 0 {
+			continue
+		}
+		master, err := findOrCreateMaterial(tx, m)
+		if err != nil {
+			return err
+		}
+		qtyPerUnit := float64(m.Qty)
+		if target > 0 {
+			qtyPerUnit = math.Round(float64(m.Qty)/float64(target)*1000) / 1000
+		}
+		line := ProduksiMaterial{ProduksiID: produksiID, MaterialsID: master.MaterialsID, QtyPerUnit: qtyPerUnit}
+		if err := tx.Create(&line).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyMaterials memindahkan isi kolom materials_data ke tabel produksi_materials
+// untuk job yang belum memiliki baris BOM, lalu mengosongkan kolom lama.
+func migrateLegacyMaterials() {
+	var legacy []Produksi
+	if err := db.Where("materials_data IS NOT NULL AND materials_data <> ''").Find(&legacy).Error; err != nil {
+		log.Printf("Error fetching legacy materials_data: %v", err)
+		return
+	}
+	for _, item := range legacy {
+		var existing int64
+		db.Model(&ProduksiMaterial{}).Where("produksi_id = ?", item.ProduksiID).Count(&existing)
+
+		var legacyItems []Materials
+		if err := json.Unmarshal([]byte(item.MaterialsJSON), &legacyItems); err != nil {
+			log.Printf("Error unmarshalling materials_data for produksi %d: %v", item.ProduksiID, err)
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if existing == 0 {
+				if err := seedBOMFromMaterials(tx, item.ProduksiID, item.Target, legacyItems); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&Produksi{}).Where("produksi_id = ?", item.ProduksiID).Update("materials_data", "").Error
+		})
+		if err != nil {
+			log.Printf("Error migrating materials_data for produksi %d: %v", item.ProduksiID, err)
+			continue
+		}
+		log.Printf("Migrated %d legacy materials for produksi %d into BOM.", len(legacyItems), item.ProduksiID)
+	}
+}
+
+// validateBOMLine memeriksa referensi material dan inventory pada baris BOM
+func validateBOMLine(line ProduksiMaterial) (int, string) {
+	if line.MaterialsID <= 0 || line.QtyPerUnit <= 0 {
+		return http.StatusBadRequest, "Field materials_id dan qty_per_unit (> 0) wajib diisi"
+	}
+	var master Materials
+	if err := db.First(&master, line.MaterialsID).Error; err != nil {
+		return http.StatusBadRequest, "Material tidak ditemukan"
+	}
+	if line.InventoryID != nil {
+		var inv Inventory
+		if err := db.First(&inv, *line.InventoryID).Error; err != nil {
+			return http.StatusBadRequest, "Item inventory tidak ditemukan"
+		}
+	}
+	return 0, ""
+}
+
+// findProduksi mengambil job produksi berdasarkan parameter :id dan menulis respons error jika gagal
+func findProduksi(c *gin.Context) (Produksi, bool) {
+	var item Produksi
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return item, false
+	}
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data produksi tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return item, false
+	}
+	return item, true
+}
+
+// getBOM menampilkan BOM job produksi: kebutuhan vs stok tersedia per baris
+func getBOM(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	lines, err := loadBOM(db, item.ProduksiID)
+	if err != nil {
+		log.Printf("Error fetching BOM for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data BOM", "details": err.Error()})
+		return
+	}
+
+	bomLines := make([]BOMLine, 0, len(lines))
+	allAvailable := true
+	for _, line := range lines {
+		bl := buildBOMLine(line, item.Target)
+		if !bl.Sufficient {
+			allAvailable = false
+		}
+		bomLines = append(bomLines, bl)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"produksi_id":   item.ProduksiID,
+		"name":          item.Name,
+		"target":        item.Target,
+		"lines":         bomLines,
+		"all_available": allAvailable,
+	})
+}
+
+// createBOMLine menambahkan baris material ke BOM job produksi
+func createBOMLine(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	var line ProduksiMaterial
+	if err := c.ShouldBindJSON(&line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if status, msg := validateBOMLine(line); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	line.ProduksiMaterialID = 0
+	line.ProduksiID = item.ProduksiID
+	line.Material = nil
+	line.Inventory = nil
+	if err := db.Create(&line).Error; err != nil {
+		log.Printf("Error creating BOM line for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan baris BOM", "details": err.Error()})
+		return
+	}
+
+	db.Preload("Material").Preload("Inventory").First(&line, line.ProduksiMaterialID)
+	c.JSON(http.StatusCreated, buildBOMLine(line, item.Target))
+}
+
+// updateBOMLine memperbarui baris material pada BOM job produksi. Setelah ada pemakaian,
+// material dan item inventory baris tidak dapat diganti (409).
+func updateBOMLine(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID baris BOM tidak valid"})
+		return
+	}
+
+	var input ProduksiMaterial
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if status, msg := validateBOMLine(input); msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	// Job dikunci seperti saat progress menyimpan pemakaian material, sehingga pengecekan
+	// riwayat pemakaian tidak bersaing dengan pengeluaran stok yang sedang berjalan
+	var line ProduksiMaterial
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Produksi{}, item.ProduksiID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("produksi_id = ?", item.ProduksiID).First(&line, lineID).Error; err != nil {
+			return err
+		}
+
+		// Baris yang sudah memiliki riwayat pemakaian tetap menunjuk material dan item inventory
+		// yang sama agar pengeluaran dan pengembalian stok berikutnya tidak salah item
+		if line.MaterialsID != input.MaterialsID || !sameInventory(line.InventoryID, input.InventoryID) {
+			var used int64
+			if err := tx.Model(&Consumption{}).Where("produksi_materials_id = ?", lineID).Count(&used).Error; err != nil {
+				return err
+			}
+			if used > 0 {
+				return errBOMLineConsumed
+			}
+		}
+
+		line.MaterialsID = input.MaterialsID
+		line.InventoryID = input.InventoryID
+		line.QtyPerUnit = input.QtyPerUnit
+		line.Notes = input.Notes
+		return tx.Save(&line).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Baris BOM tidak ditemukan"})
+		return
+	case errors.Is(err, errBOMLineConsumed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Error updating BOM line %d: %v", lineID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update baris BOM", "details": err.Error()})
+		return
+	}
+
+	db.Preload("Material").Preload("Inventory").First(&line, line.ProduksiMaterialID)
+	c.JSON(http.StatusOK, buildBOMLine(line, item.Target))
+}
+
+// errBOMLineConsumed dikembalikan jika material atau item inventory baris BOM diganti setelah dipakai
+var errBOMLineConsumed = errors.New("Baris BOM sudah memiliki riwayat pemakaian material; hanya qty_per_unit dan notes yang dapat diubah")
+
+// sameInventory membandingkan dua referensi inventory yang boleh kosong
+func sameInventory(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// deleteBOMLine menghapus baris material dari BOM job produksi
+func deleteBOMLine(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID baris BOM tidak valid"})
+		return
+	}
+
+	// Baris yang sudah memiliki riwayat pemakaian material tidak boleh dihapus
+	var used int64
+	db.Model(&Consumption{}).Where("produksi_materials_id = ?", lineID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Baris BOM sudah memiliki riwayat pemakaian material"})
+		return
+	}
+
+	result := db.Where("produksi_id = ?", item.ProduksiID).Delete(&ProduksiMaterial{}, lineID)
+	if result.Error != nil {
+		log.Printf("Error deleting BOM line %d: %v", lineID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus baris BOM", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Baris BOM tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package produksi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Consumption - Catatan pengeluaran material dari inventory untuk job produksi.
+// Qty negatif berarti material dikembalikan ke gudang (mis. progress dikoreksi/dihapus).
+type Consumption struct {
+	ConsumptionID      int       `json:"id" gorm:"column:consumption_id;primaryKey;autoIncrement"`
+	ProduksiID         int       `json:"produksi_id" gorm:"column:produksi_id"`
+	ProduksiMaterialID int       `json:"produksi_materials_id" gorm:"column:produksi_materials_id"`
+	ProgressID         *int      `json:"progress_id,omitempty" gorm:"column:progress_id"`
+	InventoryID        int       `json:"inventory_id" gorm:"column:inventory_id"`
+	Qty                int       `json:"qty" gorm:"column:qty"`
+	Shortage           int       `json:"shortage" gorm:"column:shortage"`     // Kekurangan stok saat pengeluaran
+	UnitPrice          float64   `json:"unit_price" gorm:"column:unit_price"` // Harga material saat dikeluarkan
+	CreatedAt          time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Consumption) TableName() string {
+	return "produksi_consumption"
+}
+
+// ShortageError dikembalikan jika stok inventory tidak cukup untuk progress yang dicatat
+type ShortageError struct {
+	Lines []string
+}
+
+func (e *ShortageError) Error() string {
+	return "Stok material tidak mencukupi: " + strings.Join(e.Lines, "; ")
+}
+
+// plannedIssue menghitung jumlah material (bulat ke atas) yang seharusnya sudah keluar untuk unit selesai
+func plannedIssue(qtyPerUnit float64, completed int) int {
+	return int(math.Ceil(requiredQty(qtyPerUnit, completed)))
+}
+
+// consumeMaterials menyelaraskan pengeluaran material dengan jumlah unit selesai.
+// Untuk setiap baris BOM yang terhubung ke inventory, selisih antara rencana keluar
+// (QtyPerUnit x Completed) dan yang sudah keluar diambil dari (atau dikembalikan ke) inventory.
+// Harus dipanggil di dalam transaksi yang sama dengan perubahan progress, setelah recomputeProduksi.
+// Jika allowShortage bernilai false, kekurangan stok membatalkan transaksi dengan ShortageError;
+// jika true, stok yang ada dikeluarkan dan kekurangannya dicatat.
+func consumeMaterials(tx *gorm.DB, produksiID int, progressID *int, allowShortage bool) ([]Consumption, error) {
+	var item Produksi
+	if err := tx.First(&item, produksiID).Error; err != nil {
+		return nil, err
+	}
+
+	var lines []ProduksiMaterial
+	if err := tx.Preload("Material").Where("produksi_id = ? AND inventory_id IS NOT NULL", produksiID).Find(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	var issued []Consumption
+	shortage := &ShortageError{}
+	for _, line := range lines {
+		var alreadyIssued int
+		if err := tx.Model(&Consumption{}).Where("produksi_materials_id = ?", line.ProduksiMaterialID).
+			Select("COALESCE(SUM(qty), 0)").Scan(&alreadyIssued).Error; err != nil {
+			return nil, err
+		}
+		delta := plannedIssue(line.QtyPerUnit, item.Completed) - alreadyIssued
+		if delta == 0 {
+			continue
+		}
+
+		var inv Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, *line.InventoryID).Error; err != nil {
+			return nil, err
+		}
+
+		record := Consumption{
+			ProduksiID:         produksiID,
+			ProduksiMaterialID: line.ProduksiMaterialID,
+			ProgressID:         progressID,
+			InventoryID:        inv.InventoryID,
+			Qty:                delta,
+			CreatedAt:          time.Now(),
+		}
+		if line.Material != nil {
+			record.UnitPrice = line.Material.Price
+		}
+		if delta > inv.Quantity {
+			name := inv.Name
+			if line.Material != nil {
+				name = line.Material.MaterialsName
+			}
+			shortage.Lines = append(shortage.Lines, fmt.Sprintf("%s butuh %d, tersedia %d", name, delta, inv.Quantity))
+			if !allowShortage {
+				continue
+			}
+			record.Qty = inv.Quantity
+			record.Shortage = delta - inv.Quantity
+		}
+
+		if err := tx.Model(&Inventory{}).Where("inventory_id = ?", inv.InventoryID).
+			Update("quantity", gorm.Expr("quantity - ?", record.Qty)).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return nil, err
+		}
+		issued = append(issued, record)
+	}
+
+	if len(shortage.Lines) > 0 && !allowShortage {
+		return nil, shortage
+	}
+	return issued, nil
+}
+
+// ConsumptionLine - Perbandingan pemakaian material rencana vs aktual per baris BOM
+type ConsumptionLine struct {
+	ProduksiMaterialID int     `json:"produksi_materials_id"`
+	MaterialsID        int     `json:"materials_id"`
+	MaterialName       string  `json:"material_name"`
+	Satuan             string  `json:"satuan"`
+	InventoryID        *int    `json:"inventory_id,omitempty"`
+	PlannedTotal       float64 `json:"planned_total"`   // Kebutuhan untuk seluruh target
+	PlannedToDate      int     `json:"planned_to_date"` // Kebutuhan untuk unit yang sudah selesai
+	Actual             int     `json:"actual"`          // Total yang benar-benar keluar dari inventory
+	Shortage           int     `json:"shortage"`        // Kekurangan yang belum terpenuhi
+	Variance           int     `json:"variance"`        // Actual - PlannedToDate
+}
+
+// getConsumption menampilkan pemakaian material rencana vs aktual untuk job produksi
+func getConsumption(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	lines, err := loadBOM(db, item.ProduksiID)
+	if err != nil {
+		log.Printf("Error fetching BOM for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data BOM", "details": err.Error()})
+		return
+	}
+
+	var records []Consumption
+	if err := db.Where("produksi_id = ?", item.ProduksiID).Order("created_at, consumption_id").Find(&records).Error; err != nil {
+		log.Printf("Error fetching consumption for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pemakaian material", "details": err.Error()})
+		return
+	}
+	actual := make(map[int]int)
+	for _, r := range records {
+		actual[r.ProduksiMaterialID] += r.Qty
+	}
+
+	summary := make([]ConsumptionLine, 0, len(lines))
+	for _, line := range lines {
+		cl := ConsumptionLine{
+			ProduksiMaterialID: line.ProduksiMaterialID,
+			MaterialsID:        line.MaterialsID,
+			InventoryID:        line.InventoryID,
+			PlannedTotal:       requiredQty(line.QtyPerUnit, item.Target),
+			PlannedToDate:      plannedIssue(line.QtyPerUnit, item.Completed),
+			Actual:             actual[line.ProduksiMaterialID],
+		}
+		if line.Material != nil {
+			cl.MaterialName = line.Material.MaterialsName
+			cl.Satuan = line.Material.Satuan
+		}
+		cl.Variance = cl.Actual - cl.PlannedToDate
+		if cl.Variance < 0 {
+			cl.Shortage = -cl.Variance
+		}
+		summary = append(summary, cl)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"produksi_id": item.ProduksiID,
+		"target":      item.Target,
+		"completed":   item.Completed,
+		"lines":       summary,
+		"history":     records,
+	})
+}
@@ -0,0 +1,403 @@
+package produksi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// Labour - Catatan jam kerja personel pada job produksi.
+// Rate disalin dari personalia.hourly_rate saat dicatat agar biaya historis tidak berubah.
+type Labour struct {
+	LabourID     int     `json:"id" gorm:"column:labour_id;primaryKey;autoIncrement"`
+	ProduksiID   int     `json:"produksi_id" gorm:"column:produksi_id"`
+	PersonaliaID int     `json:"personalia_id" gorm:"column:personalia_id"`
+	Date         string  `json:"date" gorm:"column:date"`
+	Hours        float64 `json:"hours" gorm:"column:hours"`
+	Rate         float64 `json:"rate" gorm:"column:rate"`
+	Notes        string  `json:"notes" gorm:"column:notes"`
+
+	NIP        string      `json:"nip,omitempty" gorm:"-"` // Alternatif personalia_id saat input
+	Personalia *Personalia `json:"personalia,omitempty" gorm:"foreignKey:PersonaliaID;references:PersonaliaID"`
+}
+
+func (Labour) TableName() string {
+	return "produksi_labour"
+}
+
+// CostSummary - Rekap biaya satu job produksi terhadap anggarannya
+type CostSummary struct {
+	ProduksiID        int     `json:"produksi_id"`
+	Name              string  `json:"name"`
+	Status            string  `json:"status"`
+	Month             string  `json:"month"` // YYYY-MM dari startDate
+	Budget            float64 `json:"budget"`
+	PlannedMaterial   float64 `json:"planned_material"` // Kebutuhan BOM x harga master untuk seluruh target
+	ActualMaterial    float64 `json:"actual_material"`  // Material yang benar-benar keluar x harga saat keluar
+	Labour            float64 `json:"labour"`           // Jam kerja x tarif
+	LabourHours       float64 `json:"labour_hours"`
+	ActualTotal       float64 `json:"actual_total"`    // ActualMaterial + Labour
+	ProjectedTotal    float64 `json:"projected_total"` // PlannedMaterial + Labour tercatat (belum ada rencana tenaga kerja)
+	Remaining         float64 `json:"remaining"`       // Budget - ActualTotal
+	BudgetUsedPct     float64 `json:"budget_used_pct"`
+	OverBudget        bool    `json:"over_budget"`
+	PlannedOverBudget bool    `json:"planned_over_budget"` // Rencana material BOM saja sudah melebihi budget
+}
+
+// roundMoney membulatkan nilai uang ke 2 desimal
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// computeCost menghitung rekap biaya satu job dari BOM, catatan pemakaian dan jam kerja
+func computeCost(item Produksi) (CostSummary, error) {
+	summary := CostSummary{
+		ProduksiID: item.ProduksiID,
+		Name:       item.Name,
+		Status:     item.Status,
+		Budget:     item.Budget,
+	}
+	if len(item.StartDate) >= 7 {
+		summary.Month = item.StartDate[:7]
+	}
+
+	lines, err := loadBOM(db, item.ProduksiID)
+	if err != nil {
+		return summary, err
+	}
+	for _, line := range lines {
+		if line.Material != nil {
+			summary.PlannedMaterial += requiredQty(line.QtyPerUnit, item.Target) * line.Material.Price
+		}
+	}
+
+	if err := db.Model(&Consumption{}).Where("produksi_id = ?", item.ProduksiID).
+		Select("COALESCE(SUM(qty * unit_price), 0)").Scan(&summary.ActualMaterial).Error; err != nil {
+		return summary, err
+	}
+
+	var labour struct {
+		Hours float64
+		Cost  float64
+	}
+	if err := db.Model(&Labour{}).Where("produksi_id = ?", item.ProduksiID).
+		Select("COALESCE(SUM(hours), 0) AS hours, COALESCE(SUM(hours * rate), 0) AS cost").Scan(&labour).Error; err != nil {
+		return summary, err
+	}
+	summary.LabourHours = labour.Hours
+	summary.Labour = labour.Cost
+
+	summary.PlannedMaterial = roundMoney(summary.PlannedMaterial)
+	summary.ActualMaterial = roundMoney(summary.ActualMaterial)
+	summary.Labour = roundMoney(summary.Labour)
+	summary.ActualTotal = roundMoney(summary.ActualMaterial + summary.Labour)
+	summary.ProjectedTotal = roundMoney(summary.PlannedMaterial + summary.Labour)
+	summary.Remaining = roundMoney(summary.Budget - summary.ActualTotal)
+	if summary.Budget > 0 {
+		summary.BudgetUsedPct = math.Round(summary.ActualTotal/summary.Budget*1000) / 10
+		summary.OverBudget = summary.ActualTotal > summary.Budget
+		summary.PlannedOverBudget = summary.PlannedMaterial > summary.Budget
+	}
+	return summary, nil
+}
+
+// getLabour menampilkan catatan jam kerja job produksi
+func getLabour(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	var entries []Labour
+	if err := db.Preload("Personalia").Where("produksi_id = ?", item.ProduksiID).Order("date, labour_id").Find(&entries).Error; err != nil {
+		log.Printf("Error fetching labour for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data jam kerja", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// createLabour mencatat jam kerja personel (berdasarkan personalia_id atau nip) pada job produksi
+func createLabour(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	var entry Labour
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	if entry.Hours <= 0 || entry.Hours > 24 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jam kerja harus antara 0 dan 24"})
+		return
+	}
+	if msg := validateProgressDate(item, entry.Date); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var query *gorm.DB
+	entry.NIP = strings.TrimSpace(entry.NIP)
+	switch {
+	case entry.PersonaliaID > 0:
+		query = db.Where("personalia_id = ?", entry.PersonaliaID)
+	case entry.NIP != "":
+		query = db.Where("nip = ?", entry.NIP)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "personalia_id atau nip wajib diisi"})
+		return
+	}
+
+	var person Personalia
+	if err := query.First(&person).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Personalia tidak ditemukan"})
+		return
+	}
+
+	entry.LabourID = 0
+	entry.ProduksiID = item.ProduksiID
+	entry.PersonaliaID = person.PersonaliaID
+	entry.Rate = person.HourlyRate
+	entry.Personalia = nil
+
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("Error creating labour for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan jam kerja", "details": err.Error()})
+		return
+	}
+	entry.Personalia = &person
+	c.JSON(http.StatusCreated, entry)
+}
+
+// deleteLabour menghapus catatan jam kerja
+func deleteLabour(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	labourID, err := strconv.Atoi(c.Param("labourId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID jam kerja tidak valid"})
+		return
+	}
+
+	result := db.Where("produksi_id = ?", item.ProduksiID).Delete(&Labour{}, labourID)
+	if result.Error != nil {
+		log.Printf("Error deleting labour %d: %v", labourID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus jam kerja", "details": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jam kerja tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// getProduksiCost menampilkan rekap biaya satu job produksi
+func getProduksiCost(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	summary, err := computeCost(item)
+	if err != nil {
+		log.Printf("Error computing cost for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung biaya produksi", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// CostGroup - Rekap biaya portofolio per bulan dan status
+type CostGroup struct {
+	Month           string  `json:"month"`
+	Status          string  `json:"status"`
+	Jobs            int     `json:"jobs"`
+	Budget          float64 `json:"budget"`
+	PlannedMaterial float64 `json:"planned_material"`
+	ActualMaterial  float64 `json:"actual_material"`
+	Labour          float64 `json:"labour"`
+	ActualTotal     float64 `json:"actual_total"`
+	Remaining       float64 `json:"remaining"`
+	OverBudgetJobs  int     `json:"over_budget_jobs"`
+}
+
+// monthLayout - Format bulan YYYY-MM pada filter laporan biaya
+const monthLayout = "2006-01"
+
+// costFilterError menandai parameter filter laporan biaya yang tidak valid (dijawab 400)
+type costFilterError struct{ msg string }
+
+func (e *costFilterError) Error() string {
+	return e.msg
+}
+
+// costReportError menulis respons error dari buildCostReport
+func costReportError(c *gin.Context, err error) {
+	var invalid *costFilterError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Error building produksi cost report: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat laporan biaya produksi", "details": err.Error()})
+}
+
+// buildCostReport menghitung rekap biaya semua job sesuai filter query
+// (?from=YYYY-MM&to=YYYY-MM&status=...) lalu mengelompokkan per bulan dan status
+func buildCostReport(c *gin.Context) ([]CostSummary, []CostGroup, error) {
+	query := db.Model(&Produksi{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if from := c.Query("from"); from != "" {
+		month, err := time.Parse(monthLayout, from)
+		if err != nil {
+			return nil, nil, &costFilterError{fmt.Sprintf("Parameter from '%s' tidak valid, gunakan format YYYY-MM", from)}
+		}
+		query = query.Where("start_date >= ?", month.Format(dateutil.Layout))
+	}
+	if to := c.Query("to"); to != "" {
+		month, err := time.Parse(monthLayout, to)
+		if err != nil {
+			return nil, nil, &costFilterError{fmt.Sprintf("Parameter to '%s' tidak valid, gunakan format YYYY-MM", to)}
+		}
+		// start_date disimpan sebagai YYYY-MM-DD; batas atas adalah awal bulan berikutnya
+		query = query.Where("start_date < ?", month.AddDate(0, 1, 0).Format(dateutil.Layout))
+	}
+
+	var items []Produksi
+	if err := query.Order("start_date, produksi_id").Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	jobs := make([]CostSummary, 0, len(items))
+	groups := make(map[string]*CostGroup)
+	for _, item := range items {
+		summary, err := computeCost(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		jobs = append(jobs, summary)
+
+		key := summary.Month + "|" + summary.Status
+		g, exists := groups[key]
+		if !exists {
+			g = &CostGroup{Month: summary.Month, Status: summary.Status}
+			groups[key] = g
+		}
+		g.Jobs++
+		g.Budget += summary.Budget
+		g.PlannedMaterial += summary.PlannedMaterial
+		g.ActualMaterial += summary.ActualMaterial
+		g.Labour += summary.Labour
+		g.ActualTotal += summary.ActualTotal
+		if summary.OverBudget {
+			g.OverBudgetJobs++
+		}
+	}
+
+	grouped := make([]CostGroup, 0, len(groups))
+	for _, g := range groups {
+		g.Budget = roundMoney(g.Budget)
+		g.PlannedMaterial = roundMoney(g.PlannedMaterial)
+		g.ActualMaterial = roundMoney(g.ActualMaterial)
+		g.Labour = roundMoney(g.Labour)
+		g.ActualTotal = roundMoney(g.ActualTotal)
+		g.Remaining = roundMoney(g.Budget - g.ActualTotal)
+		grouped = append(grouped, *g)
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		if grouped[i].Month != grouped[j].Month {
+			return grouped[i].Month < grouped[j].Month
+		}
+		return grouped[i].Status < grouped[j].Status
+	})
+	return jobs, grouped, nil
+}
+
+// getCostReport menampilkan laporan biaya portofolio job produksi per bulan dan status
+func getCostReport(c *gin.Context) {
+	jobs, grouped, err := buildCostReport(c)
+	if err != nil {
+		costReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"summary": grouped, "jobs": jobs})
+}
+
+// exportCostReportToExcel menggenerasi laporan biaya portofolio dalam format Excel
+func exportCostReportToExcel(c *gin.Context) {
+	jobs, grouped, err := buildCostReport(c)
+	if err != nil {
+		costReportError(c, err)
+		return
+	}
+
+	f := excelize.NewFile()
+	summarySheet := "Ringkasan"
+	index, err := f.NewSheet(summarySheet)
+	if err != nil {
+		log.Printf("Error creating new Excel sheet: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat file Excel"})
+		return
+	}
+	f.DeleteSheet("Sheet1")
+
+	summaryHeaders := []string{"Bulan", "Status", "Jumlah Job", "Budget", "Rencana Material", "Aktual Material", "Tenaga Kerja", "Total Aktual", "Sisa Budget", "Job Over Budget"}
+	for i, header := range summaryHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(summarySheet, cell, header)
+	}
+	for i, g := range grouped {
+		row := []interface{}{g.Month, g.Status, g.Jobs, g.Budget, g.PlannedMaterial, g.ActualMaterial, g.Labour, g.ActualTotal, g.Remaining, g.OverBudgetJobs}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow(summarySheet, cell, &row)
+	}
+
+	detailSheet := "Detail Job"
+	f.NewSheet(detailSheet)
+	detailHeaders := []string{"ID", "Nama", "Bulan", "Status", "Budget", "Rencana Material", "Aktual Material", "Jam Kerja", "Tenaga Kerja", "Total Aktual", "Sisa Budget", "Pemakaian Budget (%)", "Over Budget"}
+	for i, header := range detailHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(detailSheet, cell, header)
+	}
+	for i, j := range jobs {
+		overBudget := "Tidak"
+		if j.OverBudget {
+			overBudget = "Ya"
+		}
+		row := []interface{}{j.ProduksiID, j.Name, j.Month, j.Status, j.Budget, j.PlannedMaterial, j.ActualMaterial, j.LabourHours, j.Labour, j.ActualTotal, j.Remaining, j.BudgetUsedPct, overBudget}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow(detailSheet, cell, &row)
+	}
+
+	f.SetActiveSheet(index)
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=biaya_produksi_%s.xlsx", time.Now().Format("20060102")))
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Cache-Control", "no-cache")
+
+	if err := f.Write(c.Writer); err != nil {
+		log.Printf("Error writing Excel file to response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menulis file Excel"})
+		return
+	}
+}
@@ -0,0 +1,145 @@
+package produksi
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kai-backend/dateutil"
+)
+
+// Flag risiko penyelesaian job produksi
+const (
+	riskOnTrack = "on_track" // Proyeksi selesai sebelum/tepat endDate
+	riskAtRisk  = "at_risk"  // Proyeksi melewati endDate, atau sudah berjalan tanpa progres
+	riskLate    = "late"     // endDate sudah lewat dan target belum tercapai (atau selesai terlambat)
+)
+
+// Forecast - Proyeksi penyelesaian job dari entri progress yang tercatat.
+// Selalu dihitung dari data progress terbaru sehingga ikut berubah setiap kali progress diubah.
+type Forecast struct {
+	Velocity         float64 `json:"velocity"`                    // Rata-rata unit selesai per hari sejak startDate
+	RequiredVelocity float64 `json:"required_velocity,omitempty"` // Unit per hari yang dibutuhkan agar selesai tepat endDate
+	Remaining        int     `json:"remaining"`
+	ProjectedDate    string  `json:"projected_date,omitempty"` // Kosong jika belum ada progres untuk diproyeksikan
+	DaysBehind       int     `json:"days_behind"`              // Selisih hari proyeksi/penyelesaian terhadap endDate
+	Risk             string  `json:"risk"`
+}
+
+// computeForecast menghitung kecepatan produksi, proyeksi tanggal selesai dan flag risiko per tanggal asOf.
+// ProgressData job harus sudah dimuat.
+func computeForecast(item Produksi, asOf time.Time) *Forecast {
+	start, okStart := dateutil.Parse(item.StartDate)
+	end, okEnd := dateutil.Parse(item.EndDate)
+	if !okStart || !okEnd {
+		return nil
+	}
+	asOf = dateutil.DateOnly(asOf)
+
+	f := &Forecast{Remaining: item.Target - item.Completed, Risk: riskOnTrack}
+	if f.Remaining < 0 {
+		f.Remaining = 0
+	}
+
+	// Tanggal progress terakhir dipakai sebagai tanggal selesai untuk job yang sudah mencapai target
+	var lastDate time.Time
+	for _, p := range item.ProgressData {
+		if d, ok := dateutil.Parse(p.Date); ok && d.After(lastDate) {
+			lastDate = d
+		}
+	}
+	if lastDate.After(asOf) {
+		asOf = lastDate
+	}
+
+	elapsed := dateutil.DaysBetween(start, asOf) + 1
+	if elapsed > 0 && item.Completed > 0 {
+		f.Velocity = math.Round(float64(item.Completed)/float64(elapsed)*100) / 100
+	}
+
+	if f.Remaining == 0 {
+		f.ProjectedDate = lastDate.Format(dateutil.Layout)
+		if lastDate.IsZero() {
+			f.ProjectedDate = asOf.Format(dateutil.Layout)
+		}
+		if finished, _ := dateutil.Parse(f.ProjectedDate); finished.After(end) {
+			f.DaysBehind = dateutil.DaysBetween(end, finished)
+			f.Risk = riskLate
+		}
+		return f
+	}
+
+	if daysLeft := dateutil.DaysBetween(asOf, end) + 1; daysLeft > 0 {
+		f.RequiredVelocity = math.Round(float64(f.Remaining)/float64(daysLeft)*100) / 100
+	}
+
+	switch {
+	case asOf.After(end):
+		f.Risk = riskLate
+	case asOf.Before(start):
+		// Job belum dimulai; dianggap sesuai jadwal
+		return f
+	case item.Completed == 0:
+		f.Risk = riskAtRisk
+	}
+
+	if item.Completed > 0 {
+		rate := float64(item.Completed) / float64(elapsed)
+		projected := asOf.AddDate(0, 0, int(math.Ceil(float64(f.Remaining)/rate)))
+		f.ProjectedDate = projected.Format(dateutil.Layout)
+		if projected.After(end) {
+			f.DaysBehind = dateutil.DaysBetween(end, projected)
+			if f.Risk == riskOnTrack {
+				f.Risk = riskAtRisk
+			}
+		}
+	} else if asOf.After(end) {
+		f.DaysBehind = dateutil.DaysBetween(end, asOf)
+	}
+	return f
+}
+
+// attachForecast mengisi proyeksi penyelesaian untuk job yang progress-nya sudah dimuat
+func attachForecast(items []Produksi) {
+	now := time.Now()
+	for i := range items {
+		items[i].Forecast = computeForecast(items[i], now)
+	}
+}
+
+// riskOrder mengurutkan job terlambat sebelum job berisiko
+var riskOrder = map[string]int{riskLate: 0, riskAtRisk: 1, riskOnTrack: 2}
+
+// getAtRiskProduksi menampilkan job yang belum selesai dengan flag at_risk atau late,
+// diurutkan dari yang paling mendesak
+func getAtRiskProduksi(c *gin.Context) {
+	var items []Produksi
+	if err := db.Where("status IS NULL OR status <> ?", statusSelesai).Find(&items).Error; err != nil {
+		log.Printf("Error fetching produksi for forecast: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data produksi", "details": err.Error()})
+		return
+	}
+	hydrateProduksi(items)
+
+	atRisk := []Produksi{}
+	for _, item := range items {
+		if item.Forecast != nil && item.Forecast.Risk != riskOnTrack {
+			atRisk = append(atRisk, item)
+		}
+	}
+	sort.SliceStable(atRisk, func(i, j int) bool {
+		ri, rj := riskOrder[atRisk[i].Forecast.Risk], riskOrder[atRisk[j].Forecast.Risk]
+		if ri != rj {
+			return ri < rj
+		}
+		if atRisk[i].Forecast.DaysBehind != atRisk[j].Forecast.DaysBehind {
+			return atRisk[i].Forecast.DaysBehind > atRisk[j].Forecast.DaysBehind
+		}
+		return atRisk[i].EndDate < atRisk[j].EndDate
+	})
+	c.JSON(http.StatusOK, atRisk)
+}
@@ -0,0 +1,111 @@
+package produksi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NewJob - Data job produksi yang dibuat dari modul lain (order pelanggan, serah terima rekayasa)
+type NewJob struct {
+	Name          string
+	Target        int
+	StartDate     string
+	EndDate       string
+	Budget        float64
+	RekayasaID    *int
+	Personnel     []string           // NIP personel
+	Materials     []ProduksiMaterial // Baris BOM (qty per unit); produksi_id diisi otomatis
+	AllowConflict bool               // Tetap disimpan walaupun personel sudah ditugaskan di pekerjaan lain
+}
+
+// JobError - Job produksi ditolak validasi; Status adalah HTTP status yang sesuai
+type JobError struct {
+	Status    int
+	Message   string
+	Conflicts []Conflict
+}
+
+func (e *JobError) Error() string {
+	return e.Message
+}
+
+// Body mengembalikan isi respons error yang sama dengan POST /api/produksi
+func (e *JobError) Body() gin.H {
+	body := gin.H{"error": e.Message}
+	if len(e.Conflicts) > 0 {
+		body["conflicts"] = e.Conflicts
+	}
+	return body
+}
+
+// validateJob memeriksa field wajib, budget dan rentang tanggal job produksi
+func validateJob(item Produksi) string {
+	if strings.TrimSpace(item.Name) == "" || item.Target <= 0 || item.StartDate == "" || item.EndDate == "" {
+		return "Field name, target, startDate, endDate wajib diisi"
+	}
+	if item.Budget < 0 {
+		return "Budget tidak boleh negatif"
+	}
+	return validateSchedule(item)
+}
+
+// CreateJob membuat job produksi berstatus Belum Dimulai di dalam transaksi pemanggil dengan
+// validasi yang sama seperti POST /api/produksi: field wajib, jadwal, baris BOM dan bentrok
+// jadwal personel. Kegagalan validasi dikembalikan sebagai *JobError.
+func CreateJob(tx *gorm.DB, job NewJob) (Produksi, error) {
+	item := Produksi{
+		Name:          job.Name,
+		Target:        job.Target,
+		Status:        statusBelumDimulai,
+		StartDate:     job.StartDate,
+		EndDate:       job.EndDate,
+		Budget:        job.Budget,
+		RekayasaID:    job.RekayasaID,
+		PersonnelNIPs: job.Personnel,
+	}
+	if msg := validateJob(item); msg != "" {
+		return item, &JobError{Status: http.StatusBadRequest, Message: msg}
+	}
+	for _, line := range job.Materials {
+		if status, msg := validateBOMLine(line); msg != "" {
+			return item, &JobError{Status: status, Message: msg}
+		}
+	}
+	conflicts, err := checkPersonnelConflicts(item)
+	if err != nil {
+		return item, err
+	}
+	if len(conflicts) > 0 && !job.AllowConflict {
+		return item, &JobError{
+			Status:    http.StatusConflict,
+			Message:   "Personel sudah ditugaskan pada pekerjaan lain di rentang tanggal yang sama",
+			Conflicts: conflicts,
+		}
+	}
+	if len(item.PersonnelNIPs) > 0 {
+		personnel, err := json.Marshal(item.PersonnelNIPs)
+		if err != nil {
+			return item, err
+		}
+		item.PersonnelJSON = string(personnel)
+	}
+
+	if err := tx.Create(&item).Error; err != nil {
+		return item, err
+	}
+	for _, line := range job.Materials {
+		line.ProduksiMaterialID = 0
+		line.ProduksiID = item.ProduksiID
+		line.Material, line.Inventory = nil, nil
+		if err := tx.Create(&line).Error; err != nil {
+			return item, err
+		}
+	}
+	log.Printf("Created produksi %d (%s) with %d BOM lines", item.ProduksiID, item.Name, len(job.Materials))
+	return item, nil
+}
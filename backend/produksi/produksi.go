@@ -2,63 +2,83 @@ package produksi
 
 import (
 	"encoding/json" // Import untuk JSON encoding/decoding
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Personalia - Hanya untuk referensi NIP, tidak ada relasi GORM langsung di sini
 type Personalia struct {
-	PersonaliaID int    `json:"personalia_id" gorm:"column:personalia_id;primaryKey"`
-	NIP          string `json:"nip" gorm:"column:nip"`
-	Jabatan      string `json:"jabatan" gorm:"column:jabatan"`
-	Divisi       string `json:"divisi" gorm:"column:divisi"`
+	PersonaliaID int     `json:"personalia_id" gorm:"column:personalia_id;primaryKey"`
+	NIP          string  `json:"nip" gorm:"column:nip"`
+	Jabatan      string  `json:"jabatan" gorm:"column:jabatan"`
+	Divisi       string  `json:"divisi" gorm:"column:divisi"`
+	Status       string  `json:"status" gorm:"column:status"`
+	PhoneNumber  string  `json:"phoneNumber" gorm:"column:phone_number"`
+	HourlyRate   float64 `json:"hourlyRate" gorm:"column:hourly_rate"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Personalia di trash dianggap tidak ada
 }
 
-// Materials - Struct ini akan digunakan untuk binding JSON dari frontend
-// dan kemudian di-encode ke string JSON untuk disimpan di DB.
+// Materials - Master material pada tabel 'materials' (dikelola lewat modul materials).
+// Struct yang sama dipakai untuk field `materials` format lama dari/ke frontend.
 type Materials struct {
-	MaterialsID   int     `json:"id"` // ID ini mungkin tidak digunakan jika disimpan sebagai JSON
-	MaterialsName string  `json:"name"`
-	Qty           int     `json:"qty"`
-	Price         float64 `json:"harga"`
-	Satuan        string  `json:"satuan"`
+	MaterialsID   int     `json:"id" gorm:"column:materials_id;primaryKey"`
+	MaterialsName string  `json:"name" gorm:"column:materials_name"`
+	Qty           int     `json:"qty" gorm:"column:qty"`
+	Price         float64 `json:"harga" gorm:"column:price"`
+	Satuan        string  `json:"satuan" gorm:"column:satuan"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Material di trash dianggap tidak ada
 }
 
-// Progress - Struct ini akan digunakan untuk binding JSON dari frontend
-// dan kemudian di-encode ke string JSON untuk disimpan di DB.
+// Progress - Catatan progres harian job produksi pada tabel 'progress'
 type Progress struct {
-	ProgressID int    `json:"id"` // ID ini mungkin tidak digunakan jika disimpan sebagai JSON
-	Date       string `json:"date"`
-	Completed  int    `json:"completed"`
-	Notes      string `json:"notes"`
+	ProgressID int    `json:"id" gorm:"column:progress_id;primaryKey;autoIncrement"`
+	ProduksiID int    `json:"produksi_id" gorm:"column:produksi_id"`
+	Date       string `json:"date" gorm:"column:date"`
+	Completed  int    `json:"completed" gorm:"column:completed"`
+	Notes      string `json:"notes" gorm:"column:notes"`
 }
 
 // Produksi - Model utama untuk tabel 'produksi'
 type Produksi struct {
-	ProduksiID int    `json:"id" gorm:"column:produksi_id;primaryKey;autoIncrement"`
-	Name       string `json:"name"`
-	Target     int    `json:"target"`
-	Completed  int    `json:"completed"`
-	Status     string `json:"status"`
-	StartDate  string `json:"startDate"`
-	EndDate    string `json:"endDate"`
+	ProduksiID int     `json:"id" gorm:"column:produksi_id;primaryKey;autoIncrement"`
+	Name       string  `json:"name"`
+	Target     int     `json:"target"`
+	Completed  int     `json:"completed"` // Selalu dihitung ulang dari jumlah entri progress
+	Status     string  `json:"status"`
+	StartDate  string  `json:"startDate"`
+	EndDate    string  `json:"endDate"`
+	Budget     float64 `json:"budget" gorm:"column:budget"`           // Anggaran biaya job (material + tenaga kerja)
+	RekayasaID *int    `json:"rekayasa_id" gorm:"column:rekayasa_id"` // Proyek rekayasa asal jika job dibuat lewat release-to-production
 
 	// Menyimpan Personnel (array of NIP strings) sebagai JSON string
 	PersonnelJSON string `json:"-" gorm:"column:personnel_data;type:text"` // `json:"-"` agar tidak di-bind/marshal otomatis
-	// Menyimpan Materials (array of Materials objects) sebagai JSON string
+	// Kolom lama materials_data; isinya dipindahkan ke tabel produksi_materials saat Init
 	MaterialsJSON string `json:"-" gorm:"column:materials_data;type:text"` // `json:"-"` agar tidak di-bind/marshal otomatis
-	// Menyimpan Progress (array of Progress objects) sebagai JSON string
+	// Kolom lama progress_data; isinya dipindahkan ke tabel progress saat Init
 	ProgressJSON string `json:"-" gorm:"column:progress_data;type:text"` // `json:"-"` agar tidak di-bind/marshal otomatis
 
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
+
 	// Field-field ini hanya untuk menerima/mengirim JSON dari/ke frontend
-	// `gorm:"-"` memberitahu GORM untuk mengabaikan field ini saat interaksi DB
+	// `gorm:"-"` memberitahu GORM untuk mengabaikan field ini saat interaksi DB.
+	// MaterialsData diisi dari BOM (produksi_materials) dengan qty total untuk seluruh target,
+	// ProgressData diisi dari tabel progress.
 	PersonnelNIPs []string    `json:"personnel" gorm:"-"`
 	MaterialsData []Materials `json:"materials" gorm:"-"`
 	ProgressData  []Progress  `json:"progress" gorm:"-"`
+
+	// Proyeksi penyelesaian, dihitung dari ProgressData setiap kali job dimuat
+	Forecast *Forecast `json:"forecast,omitempty" gorm:"-"`
 }
 
 // Definisikan nama tabel untuk GORM
@@ -66,48 +86,58 @@ func (Produksi) TableName() string {
 	return "produksi"
 }
 
-// Tidak perlu mendefinisikan TableName untuk Personalia, Materials, Progress
-// jika mereka hanya digunakan sebagai struct untuk JSON marshalling/unmarshalling
-// dan tidak dipetakan langsung ke tabel terpisah oleh GORM dalam konteks ini.
-// Namun, jika Anda memiliki tabel terpisah untuk mereka (seperti yang Anda tunjukkan sebelumnya),
-// maka definisi TableName mereka tetap diperlukan di package masing-masing.
-// Untuk konteks ini, kita hanya fokus pada bagaimana mereka di-handle di Produksi.
+func (Materials) TableName() string {
+	return "materials"
+}
+
+func (Personalia) TableName() string {
+	return "personalia"
+}
+
+func (Progress) TableName() string {
+	return "progress"
+}
 
 var db *gorm.DB
 
 func Init(database *gorm.DB) {
 	db = database
 	// db.AutoMigrate(&Produksi{}) // Hanya Produksi yang akan di-auto-migrate di sini
+	migrateLegacyMaterials()
+	migrateLegacyProgress()
+	trash.Register(trash.Purger{Module: "produksi", Model: &Produksi{}, Purge: purgeProduksi})
 	log.Println("Produksi module initialized.")
 }
 
+// personnelNIPs membaca daftar NIP personel dari kolom personnel_data
+func personnelNIPs(item Produksi) []string {
+	var nips []string
+	if item.PersonnelJSON != "" {
+		if err := json.Unmarshal([]byte(item.PersonnelJSON), &nips); err != nil {
+			log.Printf("Error unmarshalling personnel_data for ID %d: %v", item.ProduksiID, err)
+		}
+	}
+	return nips
+}
+
+// hydrateProduksi mengisi field-field frontend (personnel, materials, progress) dari DB
+func hydrateProduksi(items []Produksi) {
+	hydratePersonnel(items)
+	attachMaterials(items)
+	attachProgress(items)
+	attachForecast(items)
+}
+
 func getAllProduksi(c *gin.Context) {
 	var produksiItems []Produksi
-	result := db.Find(&produksiItems) // Tidak perlu Preload jika disimpan sebagai JSON string
+	result := db.Find(&produksiItems)
 	if result.Error != nil {
 		log.Printf("Error fetching all produksi items: %v", result.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	// Unmarshal JSON string kembali ke slice untuk respons frontend
-	for i := range produksiItems {
-		if produksiItems[i].PersonnelJSON != "" {
-			if err := json.Unmarshal([]byte(produksiItems[i].PersonnelJSON), &produksiItems[i].PersonnelNIPs); err != nil {
-				log.Printf("Error unmarshalling personnel_data: %v", err)
-			}
-		}
-		if produksiItems[i].MaterialsJSON != "" {
-			if err := json.Unmarshal([]byte(produksiItems[i].MaterialsJSON), &produksiItems[i].MaterialsData); err != nil {
-				log.Printf("Error unmarshalling materials_data: %v", err)
-			}
-		}
-		if produksiItems[i].ProgressJSON != "" {
-			if err := json.Unmarshal([]byte(produksiItems[i].ProgressJSON), &produksiItems[i].ProgressData); err != nil {
-				log.Printf("Error unmarshalling progress_data: %v", err)
-			}
-		}
-	}
+	hydrateProduksi(produksiItems)
 	c.JSON(http.StatusOK, produksiItems)
 }
 
@@ -119,30 +149,15 @@ func getProduksiByID(c *gin.Context) {
 	}
 
 	var item Produksi
-	result := db.First(&item, id) // Tidak perlu Preload
+	result := db.First(&item, id)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	// Unmarshal JSON string kembali ke slice untuk respons frontend
-	if item.PersonnelJSON != "" {
-		if err := json.Unmarshal([]byte(item.PersonnelJSON), &item.PersonnelNIPs); err != nil {
-			log.Printf("Error unmarshalling personnel_data for ID %d: %v", id, err)
-		}
-	}
-	if item.MaterialsJSON != "" {
-		if err := json.Unmarshal([]byte(item.MaterialsJSON), &item.MaterialsData); err != nil {
-			log.Printf("Error unmarshalling materials_data for ID %d: %v", id, err)
-		}
-	}
-	if item.ProgressJSON != "" {
-		if err := json.Unmarshal([]byte(item.ProgressJSON), &item.ProgressData); err != nil {
-			log.Printf("Error unmarshalling progress_data for ID %d: %v", id, err)
-		}
-	}
-
-	c.JSON(http.StatusOK, item)
+	items := []Produksi{item}
+	hydrateProduksi(items)
+	c.JSON(http.StatusOK, items[0])
 }
 
 func createProduksi(c *gin.Context) {
@@ -153,14 +168,32 @@ func createProduksi(c *gin.Context) {
 		return
 	}
 
-	if req.Name == "" || req.Target <= 0 || req.StartDate == "" || req.EndDate == "" {
+	if msg := validateJob(req); msg != "" {
 		log.Printf("Validation failed for createProduksi: %+v", req)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Field name, target, startDate, endDate wajib diisi"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Progress awal (jika ada) divalidasi terhadap target dan rentang tanggal
+	for _, p := range req.ProgressData {
+		if msg := validateProgressDate(req, p.Date); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if msg := validateProgressTotal(req, req.ProgressData); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
 	// Reset ID untuk auto increment
 	req.ProduksiID = 0
+	req.RekayasaID = nil // Tautan ke rekayasa hanya dibuat lewat release-to-production
+
+	// Personel tidak boleh ditugaskan ganda pada rentang tanggal yang sama (kecuali ?allow_conflict=true)
+	if !validatePersonnelSchedule(c, req) {
+		return
+	}
 
 	// Marshal PersonnelNIPs ke JSON string
 	if len(req.PersonnelNIPs) > 0 {
@@ -173,53 +206,47 @@ func createProduksi(c *gin.Context) {
 		req.PersonnelJSON = string(personnelBytes)
 	}
 
-	// Marshal MaterialsData ke JSON string
-	if len(req.MaterialsData) > 0 {
-		materialsBytes, err := json.Marshal(req.MaterialsData)
-		if err != nil {
-			log.Printf("Error marshalling MaterialsData: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses data material"})
-			return
+	// Simpan Produksi ke database, material dan progress dari frontend langsung
+	// dijadikan baris BOM dan entri progress
+	log.Printf("Attempting to create Produksi: %+v", req)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&req).Error; err != nil {
+			return err
 		}
-		req.MaterialsJSON = string(materialsBytes)
-	}
-
-	// Marshal ProgressData ke JSON string (jika ada)
-	if len(req.ProgressData) > 0 {
-		progressBytes, err := json.Marshal(req.ProgressData)
-		if err != nil {
-			log.Printf("Error marshalling ProgressData: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses data progress"})
-			return
+		if err := seedBOMFromMaterials(tx, req.ProduksiID, req.Target, req.MaterialsData); err != nil {
+			return err
+		}
+		for _, p := range req.ProgressData {
+			entry := Progress{ProduksiID: req.ProduksiID, Date: p.Date, Completed: p.Completed, Notes: p.Notes}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		if err := recomputeProduksi(tx, req.ProduksiID); err != nil {
+			return err
 		}
-		req.ProgressJSON = string(progressBytes)
+		_, err := consumeMaterials(tx, req.ProduksiID, nil, allowShortage(c))
+		return err
+	})
+	var shortage *ShortageError
+	if errors.As(err, &shortage) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "shortages": shortage.Lines})
+		return
 	}
-
-	// Simpan Produksi ke database
-	log.Printf("Attempting to create Produksi: %+v", req)
-	if err := db.Create(&req).Error; err != nil {
+	if err != nil {
 		log.Printf("Error creating Produksi in DB: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data produksi", "details": err.Error()})
 		return
 	}
 	log.Printf("Successfully created Produksi with ID: %d", req.ProduksiID)
 
-	// Muat ulang data yang baru dibuat untuk respons (dan unmarshal kembali JSON string)
+	// Muat ulang data yang baru dibuat untuk respons
 	var createdItem Produksi
 	db.First(&createdItem, req.ProduksiID) // Ambil dari DB lagi
 
-	// Unmarshal kembali untuk respons agar frontend menerima format aslinya
-	if createdItem.PersonnelJSON != "" {
-		json.Unmarshal([]byte(createdItem.PersonnelJSON), &createdItem.PersonnelNIPs)
-	}
-	if createdItem.MaterialsJSON != "" {
-		json.Unmarshal([]byte(createdItem.MaterialsJSON), &createdItem.MaterialsData)
-	}
-	if createdItem.ProgressJSON != "" {
-		json.Unmarshal([]byte(createdItem.ProgressJSON), &createdItem.ProgressData)
-	}
-
-	c.JSON(http.StatusCreated, createdItem)
+	createdItems := []Produksi{createdItem}
+	hydrateProduksi(createdItems)
+	c.JSON(http.StatusCreated, createdItems[0])
 }
 
 func updateProduksi(c *gin.Context) {
@@ -239,6 +266,14 @@ func updateProduksi(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Field name, target, startDate, endDate wajib diisi"})
 		return
 	}
+	if updatedItem.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget tidak boleh negatif"})
+		return
+	}
+	if msg := validateSchedule(updatedItem); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
 	var item Produksi
 	if result := db.First(&item, id); result.Error != nil {
@@ -246,13 +281,38 @@ func updateProduksi(c *gin.Context) {
 		return
 	}
 
-	// Perbarui field dasar
+	// Perbarui field dasar. Completed tidak diambil dari request karena
+	// dihitung ulang dari entri progress.
 	item.Name = updatedItem.Name
 	item.Target = updatedItem.Target
-	item.Completed = updatedItem.Completed
 	item.Status = updatedItem.Status
 	item.StartDate = updatedItem.StartDate
 	item.EndDate = updatedItem.EndDate
+	item.Budget = updatedItem.Budget
+
+	// Target dan rentang tanggal baru harus tetap mencakup progress yang sudah tercatat
+	var entries []Progress
+	if err := db.Where("produksi_id = ?", id).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, p := range entries {
+		if msg := validateProgressDate(item, p.Date); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if msg := validateProgressTotal(item, entries); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Personel tidak boleh ditugaskan ganda pada rentang tanggal yang sama (kecuali ?allow_conflict=true)
+	checkItem := item
+	checkItem.PersonnelNIPs = updatedItem.PersonnelNIPs
+	if item.Status != statusSelesai && !validatePersonnelSchedule(c, checkItem) {
+		return
+	}
 
 	// Marshal PersonnelNIPs ke JSON string untuk update
 	if len(updatedItem.PersonnelNIPs) > 0 {
@@ -267,54 +327,141 @@ func updateProduksi(c *gin.Context) {
 		item.PersonnelJSON = "" // Kosongkan jika tidak ada personel
 	}
 
-	// Marshal MaterialsData ke JSON string untuk update
-	if len(updatedItem.MaterialsData) > 0 {
-		materialsBytes, err := json.Marshal(updatedItem.MaterialsData)
-		if err != nil {
-			log.Printf("Error marshalling MaterialsData for update: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses data material untuk update"})
-			return
+	// Material dan progress tidak diubah di sini; keduanya dikelola lewat
+	// /api/produksi/:id/bom dan /api/produksi/:id/progress
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
 		}
-		item.MaterialsJSON = string(materialsBytes)
-	} else {
-		item.MaterialsJSON = "" // Kosongkan jika tidak ada material
+		return recomputeProduksi(tx, item.ProduksiID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Marshal ProgressData ke JSON string untuk update
-	if len(updatedItem.ProgressData) > 0 {
-		progressBytes, err := json.Marshal(updatedItem.ProgressData)
-		if err != nil {
-			log.Printf("Error marshalling ProgressData for update: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses data progress untuk update"})
+	var savedItem Produksi
+	db.First(&savedItem, item.ProduksiID)
+
+	savedItems := []Produksi{savedItem}
+	hydrateProduksi(savedItems)
+	c.JSON(http.StatusOK, savedItems[0])
+}
+
+// patchProduksi memperbarui sebagian job produksi (JSON Merge Patch, RFC 7396).
+// Hanya field yang dikirim yang divalidasi dan disimpan; personel, material dan progress
+// yang tidak dikirim tidak berubah. Material dan progress tetap dikelola lewat
+// /api/produksi/:id/bom dan /api/produksi/:id/progress.
+func patchProduksi(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	current := []Produksi{item}
+	hydrateProduksi(current)
+	var merged Produksi
+	if err := patch.ApplyTo(current[0], &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	merged.ProduksiID = item.ProduksiID
+	merged.Completed = item.Completed
+
+	if patch.Has("name") && merged.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field name tidak boleh kosong"})
+		return
+	}
+	if patch.Has("target") && merged.Target <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target harus lebih dari 0"})
+		return
+	}
+	if patch.Has("budget") && merged.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget tidak boleh negatif"})
+		return
+	}
+	if patch.HasAny("startDate", "endDate") {
+		if msg := validateSchedule(merged); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 			return
 		}
-		item.ProgressJSON = string(progressBytes)
-	} else {
-		item.ProgressJSON = "" // Kosongkan jika tidak ada progress
 	}
 
-	if result := db.Save(&item); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
-		return
+	// Target dan rentang tanggal baru harus tetap mencakup progress yang sudah tercatat
+	if patch.HasAny("target", "startDate", "endDate") {
+		for _, p := range current[0].ProgressData {
+			if msg := validateProgressDate(merged, p.Date); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+		}
+		if msg := validateProgressTotal(merged, current[0].ProgressData); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
 	}
 
-	var savedItem Produksi
-	db.First(&savedItem, item.ProduksiID)
+	// Personel disimpan sebagai JSON di kolom personnel_data
+	_, personnelChanged := patch.Take("personnel")
+	if (personnelChanged || patch.HasAny("startDate", "endDate")) && merged.Status != statusSelesai {
+		if !validatePersonnelSchedule(c, merged) {
+			return
+		}
+	}
 
-	// Unmarshal kembali untuk respons agar frontend menerima format aslinya
-	if savedItem.PersonnelJSON != "" {
-		json.Unmarshal([]byte(savedItem.PersonnelJSON), &savedItem.PersonnelNIPs)
+	columns, rejected, err := patch.Columns(db, &Produksi{}, "completed", "rekayasa_id", "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah (completed dihitung dari progress; material dan progress diubah lewat /bom dan /progress)", "fields": rejected})
+		return
 	}
-	if savedItem.MaterialsJSON != "" {
-		json.Unmarshal([]byte(savedItem.MaterialsJSON), &savedItem.MaterialsData)
+	if personnelChanged {
+		merged.PersonnelJSON = ""
+		if len(merged.PersonnelNIPs) > 0 {
+			personnelBytes, err := json.Marshal(merged.PersonnelNIPs)
+			if err != nil {
+				log.Printf("Error marshalling PersonnelNIPs for patch: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses data personel"})
+				return
+			}
+			merged.PersonnelJSON = string(personnelBytes)
+		}
+		columns = append(columns, "personnel_data")
 	}
-	if savedItem.ProgressJSON != "" {
-		json.Unmarshal([]byte(savedItem.ProgressJSON), &savedItem.ProgressData)
+
+	if len(columns) > 0 {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+				return err
+			}
+			return recomputeProduksi(tx, item.ProduksiID)
+		})
+		if err != nil {
+			log.Printf("Error patching Produksi %d: %v", item.ProduksiID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data produksi", "details": err.Error()})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, savedItem)
+	var savedItem Produksi
+	db.First(&savedItem, item.ProduksiID)
+	savedItems := []Produksi{savedItem}
+	hydrateProduksi(savedItems)
+	c.JSON(http.StatusOK, savedItems[0])
 }
 
+// deleteProduksi memindahkan job produksi ke trash. BOM, progress, pemakaian dan jam kerja
+// tetap disimpan agar job dapat dipulihkan utuh.
 func deleteProduksi(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -322,25 +469,130 @@ func deleteProduksi(c *gin.Context) {
 		return
 	}
 
-	var item Produksi
-	// Tidak perlu Preload atau Association.Clear() jika relasi disimpan sebagai JSON string.
-	// Cukup hapus entri Produksi itu sendiri.
-	if result := db.Delete(&item, id); result.Error != nil {
+	result := db.Delete(&Produksi{}, id)
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data produksi tidak ditemukan"})
+		return
+	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// purgeProduksi menghapus permanen job produksi. Catatan pemakaian, baris BOM dan progress
+// dihapus lebih dulu karena terikat foreign key ke produksi.
+func purgeProduksi(tx *gorm.DB, id int) error {
+	if err := tx.Where("produksi_id = ?", id).Delete(&Consumption{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("produksi_id = ?", id).Delete(&Labour{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("produksi_id = ?", id).Delete(&ProduksiMaterial{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("produksi_id = ?", id).Delete(&Progress{}).Error; err != nil {
+		return err
+	}
+	// Baris order pelanggan yang dipenuhi job ini dilepas, bukan dihapus
+	if err := tx.Table("customer_order_line").Where("produksi_id = ?", id).Update("produksi_id", nil).Error; err != nil {
+		return err
+	}
+	// Proyek rekayasa asal dilepas agar bisa dirilis ulang ke produksi
+	if err := tx.Table("rekayasa").Where("produksi_id = ?", id).Update("produksi_id", nil).Error; err != nil {
+		return err
+	}
+	// Dampak ECR tetap tercatat lewat item_name, hanya referensinya yang dilepas
+	if err := tx.Table("rekayasa_ecr_impact").Where("produksi_id = ?", id).Update("produksi_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Produksi{}, id).Error
+}
+
+// getProduksiTrash menampilkan job produksi yang sedang berada di trash
+func getProduksiTrash(c *gin.Context) {
+	items := []Produksi{}
+	if err := trash.List(db, &items); err != nil {
+		log.Printf("Error fetching deleted produksi items: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash produksi", "details": err.Error()})
+		return
+	}
+	hydrateProduksi(items)
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreProduksi memulihkan job produksi dari trash
+func restoreProduksi(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &Produksi{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data produksi tidak ditemukan di trash"})
+		} else {
+			log.Printf("Error restoring produksi %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan data produksi", "details": err.Error()})
+		}
+		return
+	}
+
+	var item Produksi
+	db.First(&item, id)
+	items := []Produksi{item}
+	hydrateProduksi(items)
+	c.JSON(http.StatusOK, items[0])
+}
+
 func RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("", getAllProduksi)
 	rg.GET("/", getAllProduksi)
 
+	// Feed Gantt, beban workshop per minggu dan double-booking personel
+	rg.GET("/schedule", getSchedule)
+
+	// Job yang diproyeksikan terlambat
+	rg.GET("/at-risk", getAtRiskProduksi)
+
+	// Job produksi yang dihapus (trash)
+	rg.GET("/trash", getProduksiTrash)
+
+	// Cetak work order untuk lantai produksi
+	rg.GET("/:id/work-order.pdf", exportWorkOrderPDF)
+
 	rg.GET("/:id", getProduksiByID)
 
 	rg.POST("", createProduksi)
 	rg.POST("/", createProduksi)
 	rg.PUT("/:id", updateProduksi)
+	rg.PATCH("/:id", patchProduksi)
 	rg.DELETE("/:id", deleteProduksi)
+	rg.POST("/:id/restore", restoreProduksi)
+
+	// Bill of materials per job produksi
+	rg.GET("/:id/bom", getBOM)
+	rg.POST("/:id/bom", createBOMLine)
+	rg.PUT("/:id/bom/:lineId", updateBOMLine)
+	rg.DELETE("/:id/bom/:lineId", deleteBOMLine)
+
+	// Log progres produksi
+	rg.GET("/:id/progress", getProgress)
+	rg.POST("/:id/progress", createProgress)
+	rg.PUT("/:id/progress/:progressId", updateProgress)
+	rg.DELETE("/:id/progress/:progressId", deleteProgress)
+
+	// Pemakaian material aktual vs rencana
+	rg.GET("/:id/consumption", getConsumption)
+
+	// Jam kerja, costing per job dan laporan biaya portofolio
+	rg.GET("/:id/labour", getLabour)
+	rg.POST("/:id/labour", createLabour)
+	rg.DELETE("/:id/labour/:labourId", deleteLabour)
+	rg.GET("/:id/cost", getProduksiCost)
+	rg.GET("/cost-report", getCostReport)
+	rg.GET("/cost-report/excel", exportCostReportToExcel)
 }
@@ -0,0 +1,349 @@
+package produksi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dateutil"
+)
+
+// Status job produksi yang dipakai frontend
+const (
+	statusBelumDimulai = "Belum Dimulai"
+	statusDalamProses  = "Dalam Proses"
+	statusSelesai      = "Selesai"
+)
+
+// validateProgressDate memastikan tanggal progress valid dan berada di antara startDate dan endDate job
+func validateProgressDate(item Produksi, date string) string {
+	d, err := time.Parse(dateutil.Layout, date)
+	if err != nil {
+		return fmt.Sprintf("Tanggal progress '%s' tidak valid, gunakan format YYYY-MM-DD", date)
+	}
+	if start, err := time.Parse(dateutil.Layout, item.StartDate); err == nil && d.Before(start) {
+		return fmt.Sprintf("Tanggal progress %s sebelum tanggal mulai produksi (%s)", date, item.StartDate)
+	}
+	if end, err := time.Parse(dateutil.Layout, item.EndDate); err == nil && d.After(end) {
+		return fmt.Sprintf("Tanggal progress %s melewati tanggal selesai produksi (%s)", date, item.EndDate)
+	}
+	return ""
+}
+
+// validateProgressTotal memastikan jumlah unit selesai tidak melebihi target
+func validateProgressTotal(item Produksi, entries []Progress) string {
+	total := 0
+	for _, p := range entries {
+		if p.Completed <= 0 {
+			return "Jumlah completed pada setiap progress harus lebih dari 0"
+		}
+		total += p.Completed
+	}
+	if total > item.Target {
+		return fmt.Sprintf("Total progress (%d) melebihi target produksi (%d)", total, item.Target)
+	}
+	return ""
+}
+
+// deriveStatus menentukan status job dari jumlah unit selesai.
+// Status manual lain (mis. "Tertunda") dipertahankan selama target belum tercapai.
+func deriveStatus(current string, completed, target int) string {
+	switch {
+	case target > 0 && completed >= target:
+		return statusSelesai
+	case current == statusSelesai || current == "":
+		return statusDalamProses
+	case completed > 0 && current == statusBelumDimulai:
+		return statusDalamProses
+	default:
+		return current
+	}
+}
+
+// recomputeProduksi menghitung ulang Completed dari jumlah entri progress dan menurunkan status
+func recomputeProduksi(tx *gorm.DB, produksiID int) error {
+	var item Produksi
+	if err := tx.First(&item, produksiID).Error; err != nil {
+		return err
+	}
+
+	var total int
+	if err := tx.Model(&Progress{}).Where("produksi_id = ?", produksiID).
+		Select("COALESCE(SUM(completed), 0)").Scan(&total).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&Produksi{}).Where("produksi_id = ?", produksiID).Updates(map[string]interface{}{
+		"completed": total,
+		"status":    deriveStatus(item.Status, total, item.Target),
+	}).Error
+}
+
+// attachProgress mengisi field `progress` dari tabel progress, urut berdasarkan tanggal
+func attachProgress(items []Produksi) {
+	if len(items) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProduksiID)
+	}
+
+	var entries []Progress
+	if err := db.Where("produksi_id IN ?", ids).Order("date, progress_id").Find(&entries).Error; err != nil {
+		log.Printf("Error fetching progress entries: %v", err)
+		return
+	}
+
+	byProduksi := make(map[int][]Progress)
+	for _, p := range entries {
+		byProduksi[p.ProduksiID] = append(byProduksi[p.ProduksiID], p)
+	}
+	for i := range items {
+		items[i].ProgressData = byProduksi[items[i].ProduksiID]
+	}
+}
+
+// migrateLegacyProgress memindahkan isi kolom progress_data ke tabel progress
+// untuk job yang belum memiliki entri, lalu mengosongkan kolom lama.
+func migrateLegacyProgress() {
+	var legacy []Produksi
+	if err := db.Where("progress_data IS NOT NULL AND progress_data <> ''").Find(&legacy).Error; err != nil {
+		log.Printf("Error fetching legacy progress_data: %v", err)
+		return
+	}
+	for _, item := range legacy {
+		var existing int64
+		db.Model(&Progress{}).Where("produksi_id = ?", item.ProduksiID).Count(&existing)
+
+		var legacyEntries []Progress
+		if err := json.Unmarshal([]byte(item.ProgressJSON), &legacyEntries); err != nil {
+			log.Printf("Error unmarshalling progress_data for produksi %d: %v", item.ProduksiID, err)
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if existing == 0 {
+				for _, p := range legacyEntries {
+					entry := Progress{ProduksiID: item.ProduksiID, Date: p.Date, Completed: p.Completed, Notes: p.Notes}
+					if err := tx.Create(&entry).Error; err != nil {
+						return err
+					}
+				}
+				if err := recomputeProduksi(tx, item.ProduksiID); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&Produksi{}).Where("produksi_id = ?", item.ProduksiID).Update("progress_data", "").Error
+		})
+		if err != nil {
+			log.Printf("Error migrating progress_data for produksi %d: %v", item.ProduksiID, err)
+			continue
+		}
+		log.Printf("Migrated %d legacy progress entries for produksi %d.", len(legacyEntries), item.ProduksiID)
+	}
+}
+
+// progressResponse mengembalikan entri progress bersama ringkasan job setelah dihitung ulang,
+// proyeksi penyelesaian terbaru dan material yang dikeluarkan dari inventory akibat perubahan tersebut
+func progressResponse(c *gin.Context, status int, entry *Progress, produksiID int, issued []Consumption) {
+	var item Produksi
+	db.First(&item, produksiID)
+	items := []Produksi{item}
+	attachProgress(items)
+	attachForecast(items)
+	c.JSON(status, gin.H{
+		"progress":    entry,
+		"completed":   item.Completed,
+		"target":      item.Target,
+		"status":      item.Status,
+		"consumption": issued,
+		"forecast":    items[0].Forecast,
+	})
+}
+
+// allowShortage membaca query ?allow_shortage=true; jika aktif, progress tetap dicatat
+// walaupun stok material kurang dan kekurangannya ditandai pada catatan pemakaian
+func allowShortage(c *gin.Context) bool {
+	return c.Query("allow_shortage") == "true"
+}
+
+// progressError menulis respons error dari saveProgress/deleteProgress
+func progressError(c *gin.Context, status int, err error) {
+	var shortage *ShortageError
+	if errors.As(err, &shortage) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "shortages": shortage.Lines})
+		return
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("Error saving progress: %v", err)
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// getProgress menampilkan seluruh entri progress job produksi
+func getProgress(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	var entries []Progress
+	if err := db.Where("produksi_id = ?", item.ProduksiID).Order("date, progress_id").Find(&entries).Error; err != nil {
+		log.Printf("Error fetching progress for produksi %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data progress", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// progressInvalid menandai kegagalan validasi progress di dalam transaksi (dijawab 400)
+type progressInvalid struct{ msg string }
+
+func (e *progressInvalid) Error() string {
+	return e.msg
+}
+
+// saveProgress memvalidasi dan menyimpan entri progress, menghitung ulang job, dan
+// mengeluarkan material dari inventory dalam satu transaksi. Baris produksi dikunci lebih dulu
+// agar entri progress yang disimpan bersamaan tidak melewati target.
+func saveProgress(item Produksi, entry *Progress, allowShortage bool) (int, []Consumption, error) {
+	var issued []Consumption
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked Produksi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, item.ProduksiID).Error; err != nil {
+			return err
+		}
+		if msg := validateProgressDate(locked, entry.Date); msg != "" {
+			return &progressInvalid{msg}
+		}
+		var others []Progress
+		if err := tx.Where("produksi_id = ? AND progress_id <> ?", locked.ProduksiID, entry.ProgressID).Find(&others).Error; err != nil {
+			return err
+		}
+		if msg := validateProgressTotal(locked, append(others, *entry)); msg != "" {
+			return &progressInvalid{msg}
+		}
+
+		if err := tx.Save(entry).Error; err != nil {
+			return err
+		}
+		if err := recomputeProduksi(tx, locked.ProduksiID); err != nil {
+			return err
+		}
+		var err error
+		issued, err = consumeMaterials(tx, locked.ProduksiID, &entry.ProgressID, allowShortage)
+		return err
+	})
+	var invalid *progressInvalid
+	if errors.As(err, &invalid) {
+		return http.StatusBadRequest, nil, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return 0, issued, nil
+}
+
+// createProgress mencatat progres baru untuk job produksi
+func createProgress(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+
+	var entry Progress
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+	entry.ProgressID = 0
+	entry.ProduksiID = item.ProduksiID
+
+	status, issued, err := saveProgress(item, &entry, allowShortage(c))
+	if err != nil {
+		progressError(c, status, err)
+		return
+	}
+	progressResponse(c, http.StatusCreated, &entry, item.ProduksiID, issued)
+}
+
+// updateProgress memperbarui entri progress job produksi
+func updateProgress(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	progressID, err := strconv.Atoi(c.Param("progressId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID progress tidak valid"})
+		return
+	}
+
+	var input Progress
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format JSON tidak valid", "details": err.Error()})
+		return
+	}
+
+	var entry Progress
+	if err := db.Where("produksi_id = ?", item.ProduksiID).First(&entry, progressID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Progress tidak ditemukan"})
+		return
+	}
+	entry.Date = input.Date
+	entry.Completed = input.Completed
+	entry.Notes = input.Notes
+
+	status, issued, err := saveProgress(item, &entry, allowShortage(c))
+	if err != nil {
+		progressError(c, status, err)
+		return
+	}
+	progressResponse(c, http.StatusOK, &entry, item.ProduksiID, issued)
+}
+
+// deleteProgress menghapus entri progress, menghitung ulang job produksi, dan
+// mengembalikan material yang sudah keluar untuk unit tersebut ke inventory
+func deleteProgress(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	progressID, err := strconv.Atoi(c.Param("progressId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID progress tidak valid"})
+		return
+	}
+
+	var deleted int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("produksi_id = ?", item.ProduksiID).Delete(&Progress{}, progressID)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		if err := recomputeProduksi(tx, item.ProduksiID); err != nil {
+			return err
+		}
+		_, err := consumeMaterials(tx, item.ProduksiID, nil, allowShortage(c))
+		return err
+	})
+	if err != nil {
+		progressError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Progress tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,466 @@
+package produksi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// Rekayasa - Referensi minimal ke tabel 'rekayasa' untuk pengecekan jadwal personel
+type Rekayasa struct {
+	RekayasaID int    `gorm:"column:rekayasa_id;primaryKey"`
+	Name       string `gorm:"column:name"`
+	Status     string `gorm:"column:status"`
+	Deadline   string `gorm:"column:deadline"`
+
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"` // Proyek di trash tidak dijadwalkan
+}
+
+func (Rekayasa) TableName() string {
+	return "rekayasa"
+}
+
+// Assignment - Penugasan satu personel (berdasarkan NIP) pada suatu pekerjaan dalam rentang tanggal
+type Assignment struct {
+	NIP    string `json:"nip"`
+	Source string `json:"source"` // produksi, rekayasa atau overhaul
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+
+	start, end time.Time
+}
+
+// Conflict - Personel yang ditugaskan pada dua pekerjaan dengan rentang tanggal yang tumpang tindih
+type Conflict struct {
+	NIP  string     `json:"nip"`
+	Job  Assignment `json:"job"`
+	With Assignment `json:"with"`
+}
+
+// validateSchedule memastikan startDate dan endDate valid dan endDate tidak sebelum startDate
+func validateSchedule(item Produksi) string {
+	start, err := time.Parse(dateutil.Layout, item.StartDate)
+	if err != nil {
+		return fmt.Sprintf("startDate '%s' tidak valid, gunakan format YYYY-MM-DD", item.StartDate)
+	}
+	end, err := time.Parse(dateutil.Layout, item.EndDate)
+	if err != nil {
+		return fmt.Sprintf("endDate '%s' tidak valid, gunakan format YYYY-MM-DD", item.EndDate)
+	}
+	if end.Before(start) {
+		return fmt.Sprintf("endDate (%s) tidak boleh sebelum startDate (%s)", item.EndDate, item.StartDate)
+	}
+	return ""
+}
+
+func newAssignment(nip, source string, id int, name string, start, end time.Time) Assignment {
+	return Assignment{
+		NIP: nip, Source: source, ID: id, Name: name,
+		Start: start.Format(dateutil.Layout), End: end.Format(dateutil.Layout),
+		start: start, end: end,
+	}
+}
+
+func (a Assignment) overlaps(b Assignment) bool {
+	return !a.start.After(b.end) && !b.start.After(a.end)
+}
+
+// produksiAssignments mengubah personel job produksi menjadi daftar penugasan
+func produksiAssignments(item Produksi) []Assignment {
+	start, okStart := dateutil.Parse(item.StartDate)
+	end, okEnd := dateutil.Parse(item.EndDate)
+	if !okStart || !okEnd {
+		return nil
+	}
+	var out []Assignment
+	for _, nip := range item.PersonnelNIPs {
+		out = append(out, newAssignment(nip, "produksi", item.ProduksiID, item.Name, start, end))
+	}
+	return out
+}
+
+// loadAssignments mengumpulkan penugasan personel yang masih aktif dari produksi, rekayasa dan overhaul.
+// Rekayasa dan overhaul hanya memiliki tenggat, sehingga dianggap berjalan dari hari ini sampai tenggatnya.
+// Job produksi dengan ID excludeID tidak diikutkan (dipakai saat job tersebut sedang diubah).
+func loadAssignments(excludeID int) ([]Assignment, error) {
+	var out []Assignment
+
+	var jobs []Produksi
+	if err := db.Where("(status IS NULL OR status <> ?) AND produksi_id <> ?", statusSelesai, excludeID).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	hydratePersonnel(jobs)
+	for _, job := range jobs {
+		out = append(out, produksiAssignments(job)...)
+	}
+
+	today := dateutil.DateOnly(time.Now())
+
+	var projects []Rekayasa
+	if err := db.Where("status IS NULL OR status <> ?", statusSelesai).Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	if len(projects) > 0 {
+		// Anggota tim rekayasa disimpan di rekayasa_team sebagai referensi personalia
+		ids := make([]int, 0, len(projects))
+		for _, p := range projects {
+			ids = append(ids, p.RekayasaID)
+		}
+		var members []struct {
+			RekayasaID int
+			NIP        string
+		}
+		if err := db.Table("rekayasa_team").Select("rekayasa_team.rekayasa_id, personalia.nip").
+			Joins("JOIN personalia ON personalia.personalia_id = rekayasa_team.personalia_id").
+			Where("rekayasa_team.rekayasa_id IN ? AND personalia.deleted_at IS NULL", ids).Scan(&members).Error; err != nil {
+			return nil, err
+		}
+		teams := make(map[int][]string)
+		for _, m := range members {
+			teams[m.RekayasaID] = append(teams[m.RekayasaID], m.NIP)
+		}
+		for _, p := range projects {
+			deadline, ok := dateutil.Parse(p.Deadline)
+			if !ok || deadline.Before(today) {
+				continue
+			}
+			for _, nip := range teams[p.RekayasaID] {
+				out = append(out, newAssignment(nip, "rekayasa", p.RekayasaID, p.Name, today, deadline))
+			}
+		}
+	}
+
+	// Kru overhaul (lead, teknisi, inspektur) disimpan di overhaul_crew sebagai referensi personalia
+	var crews []struct {
+		OverhaulID int
+		Name       string
+		Estimate   *time.Time
+		NIP        string
+	}
+	if err := db.Table("overhaul_crew oc").
+		Joins("JOIN overhaul o ON o.overhaul_id = oc.overhaul_id").
+		Joins("JOIN personalia p ON p.personalia_id = oc.personalia_id").
+		Where("o.deleted_at IS NULL AND (o.status IS NULL OR o.status <> ?) AND o.estimate IS NOT NULL AND p.deleted_at IS NULL", statusSelesai).
+		Select("o.overhaul_id, o.name, o.estimate, p.nip").
+		Scan(&crews).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range crews {
+		if m.NIP == "" {
+			continue
+		}
+		estimate := dateutil.DateOnly(*m.Estimate)
+		if estimate.Before(today) {
+			continue
+		}
+		out = append(out, newAssignment(m.NIP, "overhaul", m.OverhaulID, m.Name, today, estimate))
+	}
+	return out, nil
+}
+
+// hydratePersonnel hanya mengisi PersonnelNIPs tanpa memuat material dan progress
+func hydratePersonnel(items []Produksi) {
+	for i := range items {
+		items[i].PersonnelNIPs = personnelNIPs(items[i])
+	}
+}
+
+// findConflicts mencari penugasan lain yang tumpang tindih dengan penugasan job
+func findConflicts(job []Assignment, others []Assignment) []Conflict {
+	var conflicts []Conflict
+	for _, a := range job {
+		for _, b := range others {
+			if a.NIP == b.NIP && !(a.Source == b.Source && a.ID == b.ID) && a.overlaps(b) {
+				conflicts = append(conflicts, Conflict{NIP: a.NIP, Job: a, With: b})
+			}
+		}
+	}
+	return conflicts
+}
+
+// conflictKey membuat kunci yang sama untuk A-B dan B-A
+func conflictKey(cf Conflict) string {
+	a := fmt.Sprintf("%s-%d", cf.Job.Source, cf.Job.ID)
+	b := fmt.Sprintf("%s-%d", cf.With.Source, cf.With.ID)
+	if b < a {
+		a, b = b, a
+	}
+	return cf.NIP + "|" + a + "|" + b
+}
+
+// checkPersonnelConflicts mengecek double-booking personel job produksi terhadap pekerjaan lain
+func checkPersonnelConflicts(item Produksi) ([]Conflict, error) {
+	if len(item.PersonnelNIPs) == 0 {
+		return nil, nil
+	}
+	others, err := loadAssignments(item.ProduksiID)
+	if err != nil {
+		return nil, err
+	}
+	return findConflicts(produksiAssignments(item), others), nil
+}
+
+// allowConflict membaca query ?allow_conflict=true; jika aktif, job tetap disimpan
+// walaupun ada personel yang sudah ditugaskan di pekerjaan lain pada rentang yang sama
+func allowConflict(c *gin.Context) bool {
+	return c.Query("allow_conflict") == "true"
+}
+
+// validatePersonnelSchedule menulis respons 409 jika ada double-booking dan allow_conflict tidak aktif.
+// Mengembalikan false jika request harus dihentikan.
+func validatePersonnelSchedule(c *gin.Context, item Produksi) bool {
+	conflicts, err := checkPersonnelConflicts(item)
+	if err != nil {
+		log.Printf("Error checking personnel conflicts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa jadwal personel", "details": err.Error()})
+		return false
+	}
+	if len(conflicts) > 0 && !allowConflict(c) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Personel sudah ditugaskan pada pekerjaan lain di rentang tanggal yang sama", "conflicts": conflicts})
+		return false
+	}
+	return true
+}
+
+// weekStart mengembalikan hari Senin dari minggu tanggal t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// overlapDays menghitung jumlah hari (inklusif) irisan dua rentang tanggal
+func overlapDays(aStart, aEnd, bStart, bEnd time.Time) int {
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+// ScheduleTask - Satu baris Gantt untuk job produksi
+type ScheduleTask struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Start     string     `json:"start"`
+	End       string     `json:"end"`
+	Status    string     `json:"status"`
+	Target    int        `json:"target"`
+	Completed int        `json:"completed"`
+	Progress  int        `json:"progress"` // Persentase completed/target
+	Personnel []string   `json:"personnel"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// WeekLoad - Beban workshop dalam satu minggu (Senin-Minggu)
+type WeekLoad struct {
+	Week         string   `json:"week"` // Tahun-minggu ISO, mis. 2025-W29
+	Start        string   `json:"start"`
+	End          string   `json:"end"`
+	ActiveJobs   int      `json:"active_jobs"`
+	PlannedUnits float64  `json:"planned_units"` // Target job dibagi rata per hari kalender rentangnya
+	Personnel    int      `json:"personnel"`     // Jumlah personel berbeda yang ditugaskan
+	Capacity     float64  `json:"capacity,omitempty"`
+	Utilization  float64  `json:"utilization,omitempty"` // PlannedUnits / Capacity dalam persen
+	Overloaded   bool     `json:"overloaded"`
+	Overbooked   []string `json:"overbooked"` // NIP yang ditugaskan di lebih dari satu pekerjaan minggu ini
+}
+
+// weeklyCapacity membaca kapasitas workshop (unit per minggu) dari ?capacity= atau
+// variabel lingkungan PRODUKSI_WEEKLY_CAPACITY; 0 berarti kapasitas tidak diatur
+func weeklyCapacity(c *gin.Context) float64 {
+	value := c.Query("capacity")
+	if value == "" {
+		value = os.Getenv("PRODUKSI_WEEKLY_CAPACITY")
+	}
+	capacity, err := strconv.ParseFloat(value, 64)
+	if err != nil || capacity < 0 {
+		return 0
+	}
+	return capacity
+}
+
+// maxScheduleWeeks membatasi jumlah minggu pada feed agar rentang yang terlalu lebar tidak membebani server
+const maxScheduleWeeks = 104
+
+// getSchedule menampilkan feed Gantt job produksi beserta beban workshop per minggu
+// dan double-booking personel. Query opsional: from, to (YYYY-MM-DD), status, capacity.
+func getSchedule(c *gin.Context) {
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(dateutil.Layout, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter from tidak valid, gunakan format YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(dateutil.Layout, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter to tidak valid, gunakan format YYYY-MM-DD"})
+			return
+		}
+		to = t
+	}
+
+	query := db.Order("start_date, produksi_id")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if !from.IsZero() {
+		query = query.Where("end_date >= ?", from.Format(dateutil.Layout))
+	}
+	if !to.IsZero() {
+		query = query.Where("start_date <= ?", to.Format(dateutil.Layout))
+	}
+	var jobs []Produksi
+	if err := query.Find(&jobs).Error; err != nil {
+		log.Printf("Error fetching produksi schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jadwal produksi", "details": err.Error()})
+		return
+	}
+	hydratePersonnel(jobs)
+
+	others, err := loadAssignments(0)
+	if err != nil {
+		log.Printf("Error fetching personnel assignments: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jadwal personel", "details": err.Error()})
+		return
+	}
+
+	tasks := make([]ScheduleTask, 0, len(jobs))
+	conflicts := []Conflict{}
+	seen := make(map[string]bool) // Pasangan produksi-produksi cukup dilaporkan sekali
+	var rangeStart, rangeEnd time.Time
+	for _, job := range jobs {
+		task := ScheduleTask{
+			ID: job.ProduksiID, Name: job.Name, Start: job.StartDate, End: job.EndDate,
+			Status: job.Status, Target: job.Target, Completed: job.Completed,
+			Personnel: job.PersonnelNIPs, Conflicts: []Conflict{},
+		}
+		if job.Target > 0 {
+			task.Progress = int(math.Round(float64(job.Completed) / float64(job.Target) * 100))
+		}
+		if job.Status != statusSelesai {
+			task.Conflicts = append(task.Conflicts, findConflicts(produksiAssignments(job), others)...)
+			for _, cf := range task.Conflicts {
+				key := conflictKey(cf)
+				if !seen[key] {
+					seen[key] = true
+					conflicts = append(conflicts, cf)
+				}
+			}
+		}
+		tasks = append(tasks, task)
+
+		start, okStart := dateutil.Parse(job.StartDate)
+		end, okEnd := dateutil.Parse(job.EndDate)
+		if okStart && (rangeStart.IsZero() || start.Before(rangeStart)) {
+			rangeStart = start
+		}
+		if okEnd && end.After(rangeEnd) {
+			rangeEnd = end
+		}
+	}
+	if !from.IsZero() {
+		rangeStart = from
+	}
+	if !to.IsZero() {
+		rangeEnd = to
+	}
+
+	weeks := []WeekLoad{}
+	if !rangeStart.IsZero() && !rangeEnd.Before(rangeStart) {
+		weeks = computeWeekLoad(jobs, others, rangeStart, rangeEnd, weeklyCapacity(c))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":     tasks,
+		"weeks":     weeks,
+		"conflicts": conflicts,
+	})
+}
+
+// computeWeekLoad menghitung beban workshop per minggu dari job produksi yang belum selesai
+func computeWeekLoad(jobs []Produksi, others []Assignment, rangeStart, rangeEnd time.Time, capacity float64) []WeekLoad {
+	var weeks []WeekLoad
+	for ws := weekStart(rangeStart); !ws.After(rangeEnd) && len(weeks) < maxScheduleWeeks; ws = ws.AddDate(0, 0, 7) {
+		we := ws.AddDate(0, 0, 6)
+		year, week := ws.ISOWeek()
+		load := WeekLoad{
+			Week: fmt.Sprintf("%d-W%02d", year, week), Start: ws.Format(dateutil.Layout), End: we.Format(dateutil.Layout),
+			Capacity: capacity, Overbooked: []string{},
+		}
+
+		jobsPerNIP := make(map[string]map[string]bool)
+		addJob := func(a Assignment) {
+			if overlapDays(a.start, a.end, ws, we) == 0 {
+				return
+			}
+			if jobsPerNIP[a.NIP] == nil {
+				jobsPerNIP[a.NIP] = make(map[string]bool)
+			}
+			jobsPerNIP[a.NIP][fmt.Sprintf("%s-%d", a.Source, a.ID)] = true
+		}
+
+		for _, job := range jobs {
+			if job.Status == statusSelesai {
+				continue
+			}
+			start, okStart := dateutil.Parse(job.StartDate)
+			end, okEnd := dateutil.Parse(job.EndDate)
+			if !okStart || !okEnd {
+				continue
+			}
+			days := overlapDays(start, end, ws, we)
+			if days == 0 {
+				continue
+			}
+			load.ActiveJobs++
+			totalDays := int(end.Sub(start).Hours()/24) + 1
+			load.PlannedUnits += float64(job.Target) * float64(days) / float64(totalDays)
+			for _, a := range produksiAssignments(job) {
+				addJob(a)
+			}
+		}
+		for _, a := range others {
+			if _, assigned := jobsPerNIP[a.NIP]; assigned {
+				addJob(a)
+			}
+		}
+
+		load.Personnel = len(jobsPerNIP)
+		for nip, assigned := range jobsPerNIP {
+			if len(assigned) > 1 {
+				load.Overbooked = append(load.Overbooked, nip)
+			}
+		}
+		sort.Strings(load.Overbooked)
+
+		load.PlannedUnits = math.Round(load.PlannedUnits*100) / 100
+		if capacity > 0 {
+			load.Utilization = math.Round(load.PlannedUnits/capacity*1000) / 10
+			load.Overloaded = load.PlannedUnits > capacity
+		}
+		weeks = append(weeks, load)
+	}
+	return weeks
+}
@@ -0,0 +1,130 @@
+package produksi
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"kai-backend/pdfdoc"
+)
+
+// formatQty menampilkan angka tanpa nol desimal yang tidak perlu (mis. 2 bukan 2.000)
+func formatQty(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// workOrderNumber membentuk nomor work order dari ID job produksi
+func workOrderNumber(id int) string {
+	return fmt.Sprintf("WO-PRD-%05d", id)
+}
+
+// exportWorkOrderPDF mencetak work order job produksi: data job, personel, daftar material,
+// log progres dan kotak tanda tangan
+func exportWorkOrderPDF(c *gin.Context) {
+	item, ok := findProduksi(c)
+	if !ok {
+		return
+	}
+	items := []Produksi{item}
+	hydrateProduksi(items)
+	item = items[0]
+
+	lines, err := loadBOM(db, item.ProduksiID)
+	if err != nil {
+		log.Printf("Error fetching BOM for work order %d: %v", item.ProduksiID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data BOM", "details": err.Error()})
+		return
+	}
+
+	// Personel disimpan sebagai NIP; lengkapi dengan data personalia
+	people := make(map[string]Personalia)
+	if len(item.PersonnelNIPs) > 0 {
+		var found []Personalia
+		if err := db.Where("nip IN ?", item.PersonnelNIPs).Find(&found).Error; err != nil {
+			log.Printf("Error fetching personalia for work order %d: %v", item.ProduksiID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data personel", "details": err.Error()})
+			return
+		}
+		for _, p := range found {
+			people[p.NIP] = p
+		}
+	}
+
+	number := workOrderNumber(item.ProduksiID)
+	doc := pdfdoc.New("P", "Work Order Produksi", number)
+
+	doc.Section("Data Job")
+	fields := []pdfdoc.Field{
+		{Label: "No. Work Order", Value: number},
+		{Label: "Status", Value: item.Status},
+		{Label: "Nama Job", Value: item.Name},
+		{Label: "Target", Value: fmt.Sprintf("%d unit", item.Target)},
+		{Label: "Tanggal Mulai", Value: item.StartDate},
+		{Label: "Selesai", Value: fmt.Sprintf("%d unit", item.Completed)},
+		{Label: "Tanggal Selesai", Value: item.EndDate},
+	}
+	if item.Forecast != nil && item.Forecast.ProjectedDate != "" {
+		fields = append(fields, pdfdoc.Field{Label: "Proyeksi Selesai", Value: item.Forecast.ProjectedDate})
+	}
+	doc.Fields(fields)
+
+	doc.Section("Personel Ditugaskan")
+	personnelRows := make([][]string, 0, len(item.PersonnelNIPs))
+	for i, nip := range item.PersonnelNIPs {
+		p, found := people[nip]
+		if !found {
+			personnelRows = append(personnelRows, []string{strconv.Itoa(i + 1), nip, "(tidak terdaftar di personalia)", "", "", ""})
+			continue
+		}
+		personnelRows = append(personnelRows, []string{strconv.Itoa(i + 1), p.NIP, p.Jabatan, p.Divisi, p.Status, p.PhoneNumber})
+	}
+	doc.Table([]string{"No", "NIP", "Jabatan", "Divisi", "Status", "Telepon"}, []float64{8, 30, 35, 35, 20, 30}, personnelRows)
+
+	doc.Section("Daftar Material")
+	materialRows := make([][]string, 0, len(lines))
+	for i, line := range lines {
+		bl := buildBOMLine(line, item.Target)
+		name, satuan := "", ""
+		if line.Material != nil {
+			name, satuan = line.Material.MaterialsName, line.Material.Satuan
+		}
+		location := "-"
+		if line.Inventory != nil {
+			location = line.Inventory.ItemCode + " " + line.Inventory.Location
+		}
+		materialRows = append(materialRows, []string{
+			strconv.Itoa(i + 1), name, satuan, formatQty(line.QtyPerUnit), formatQty(bl.RequiredQty), location, line.Notes,
+		})
+	}
+	doc.Table([]string{"No", "Material", "Satuan", "Qty/Unit", "Qty Total", "Gudang", "Catatan"}, []float64{8, 45, 18, 18, 20, 35, 40}, materialRows)
+
+	doc.Section("Log Progres")
+	progressRows := make([][]string, 0, len(item.ProgressData))
+	cumulative := 0
+	for i, p := range item.ProgressData {
+		cumulative += p.Completed
+		progressRows = append(progressRows, []string{
+			strconv.Itoa(i + 1), p.Date, strconv.Itoa(p.Completed), fmt.Sprintf("%d / %d", cumulative, item.Target), p.Notes, "",
+		})
+	}
+	// Baris kosong untuk pencatatan manual di lantai produksi
+	for i := 0; i < 5; i++ {
+		progressRows = append(progressRows, []string{"", "", "", "", "", ""})
+	}
+	doc.Table([]string{"No", "Tanggal", "Selesai", "Kumulatif", "Catatan", "Paraf"}, []float64{8, 25, 18, 25, 70, 20}, progressRows)
+
+	doc.Signatures([]pdfdoc.Signature{
+		{Role: "Dibuat oleh"},
+		{Role: "Diperiksa oleh (QC)"},
+		{Role: "Disetujui oleh"},
+	})
+
+	if err := doc.Send(c, fmt.Sprintf("work_order_%s.pdf", number)); err != nil {
+		log.Printf("Error writing work order PDF to response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menulis file PDF"})
+		return
+	}
+}
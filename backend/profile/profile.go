@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -11,6 +12,9 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause" // Import clause for eager loading
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Struct model sesuai dengan skema database dan kebutuhan frontend
@@ -44,6 +48,8 @@ type Profile struct {
 
 	Education  *Education  `gorm:"foreignKey:EducationID;references:EducationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Experience *Experience `gorm:"foreignKey:ExperienceID;references:ExperienceID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
 func (Profile) TableName() string {
@@ -62,6 +68,15 @@ var db *gorm.DB // Menggunakan GORM DB instance
 func Init(database *gorm.DB) {
 	db = database
 	// Jangan AutoMigrate karena tabel sudah ada di database
+	trash.Register(trash.Purger{Module: "profile", Model: &Profile{}, Purge: purgeProfile})
+}
+
+// purgeProfile menghapus permanen profile; personalia yang menautkannya dilepas (profile_id NULL)
+func purgeProfile(tx *gorm.DB, id int) error {
+	if err := tx.Table("personalia").Where("profile_id = ?", id).Update("profile_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Profile{}, id).Error
 }
 
 // getAllProfiles mengambil semua item profile dari database beserta relasi terkait
@@ -213,7 +228,69 @@ func updateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, savedItem) // Kirim kembali item yang diperbarui
 }
 
-// deleteProfile menghapus item profile dari database
+// patchProfile memperbarui sebagian item profile (JSON Merge Patch, RFC 7396).
+// Hanya field yang dikirim yang divalidasi dan disimpan.
+func patchProfile(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID profile tidak valid"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var item Profile
+	if result := db.First(&item, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item profile tidak ditemukan"})
+		} else {
+			log.Printf("Error saat mencari profile dengan ID %d untuk di-patch: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencari item profile", "details": result.Error.Error()})
+		}
+		return
+	}
+
+	var merged Profile
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid", "details": err.Error()})
+		return
+	}
+	merged.ProfileID = item.ProfileID
+
+	if blank := patch.Blank(map[string]string{"email": merged.Email, "address": merged.Address, "phoneNumber": merged.PhoneNumber}); len(blank) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field email, address, phoneNumber tidak boleh kosong", "fields": blank})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &Profile{}, "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		if result := db.Model(&item).Select(columns).Updates(&merged); result.Error != nil {
+			log.Printf("Error saat mem-patch profile dengan ID %d: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui item profile", "details": result.Error.Error()})
+			return
+		}
+	}
+
+	var savedItem Profile
+	db.Preload(clause.Associations).First(&savedItem, item.ProfileID)
+	c.JSON(http.StatusOK, savedItem)
+}
+
+// deleteProfile memindahkan item profile ke trash (soft delete)
 func deleteProfile(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -250,11 +327,46 @@ func deleteProfile(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil) // 204 No Content
 }
 
+// getProfileTrash menampilkan item profile yang sedang berada di trash
+func getProfileTrash(c *gin.Context) {
+	profiles := []Profile{}
+	if err := trash.List(db.Preload(clause.Associations), &profiles); err != nil {
+		log.Printf("Error saat mengambil trash profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash profile", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+// restoreProfile memulihkan item profile dari trash
+func restoreProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID profile tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &Profile{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item profile tidak ditemukan di trash"})
+		} else {
+			log.Printf("Error saat memulihkan profile dengan ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan item profile", "details": err.Error()})
+		}
+		return
+	}
+	var item Profile
+	db.Preload(clause.Associations).First(&item, id)
+	c.JSON(http.StatusOK, item)
+}
+
 func RegisterRoutes(rg *gin.RouterGroup) {
 	api := rg
 	api.GET("/", getAllProfiles)
+	api.GET("/trash", getProfileTrash)
 	api.GET("/:id", getProfileByID)
 	api.POST("/", createProfile)
 	api.PUT("/:id", updateProfile)
+	api.PATCH("/:id", patchProfile)
 	api.DELETE("/:id", deleteProfile)
+	api.POST("/:id/restore", restoreProfile)
 }
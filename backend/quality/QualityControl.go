@@ -1,6 +1,7 @@
 package quality
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,6 +13,9 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 	// Import clause for eager loading
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Minimal Structs for Related Departments (add these near your QualityControl struct)
@@ -26,15 +30,20 @@ type Produksi struct {
 	StartDate  string `json:"startDate" gorm:"column:start_date"`
 	EndDate    string `json:"endDate" gorm:"column:end_date"`
 	// Tambahkan field lain jika diperlukan dari tabel produksi
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Job di trash tidak ditampilkan
 }
 
 type Overhaul struct {
-	OverhaulID int    `json:"id" gorm:"column:overhaul_id;primaryKey;autoIncrement"`
-	Name       string `json:"name" gorm:"column:name"`
-	Status     string `json:"status" gorm:"column:status"`
-	Estimate   string `json:"estimasi" gorm:"column:estimate"` // Changed to string
-	Progress   int    `json:"progress" gorm:"column:progress"`
+	OverhaulID  int        `json:"id" gorm:"column:overhaul_id;primaryKey;autoIncrement"`
+	Name        string     `json:"name" gorm:"column:name"`
+	Status      string     `json:"status" gorm:"column:status"`
+	Estimate    *time.Time `json:"estimasi" gorm:"column:estimate"`
+	CompletedAt *time.Time `json:"completed_at" gorm:"column:completed_at"`
+	Progress    int        `json:"progress" gorm:"column:progress"`
 	// Tambahkan field lain jika diperlukan dari tabel overhaul
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Overhaul di trash tidak ditampilkan
 }
 
 type Rekayasa struct {
@@ -43,12 +52,16 @@ type Rekayasa struct {
 	Name   string `gorm:"column:name"`
 	Status string `gorm:"column:status"`
 	// ... dll
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Proyek di trash tidak ditampilkan
 }
 
 type Inventory struct { // Digunakan juga untuk Kalibrasi jika ada relasi
-	ID   uint   `gorm:"column:id;primaryKey"` // Assuming 'id' is the primary key column name
+	ID   uint   `gorm:"column:inventory_id;primaryKey"`
 	Name string `gorm:"column:name"`
 	// ... dll
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
 }
 
 // Struct model sesuai dengan tabel quality_control dan relasi
@@ -59,7 +72,7 @@ type QualityControl struct {
 	ID        uint `gorm:"primaryKey;autoIncrement"` // Manual ID for GORM
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"` // Keep DeletedAt if soft deletes are truly intended and column exists
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index"`
 
 	QcID        int       `gorm:"column:qc_id;uniqueIndex"`           // Changed to uniqueIndex if QcID is unique for QC entries
 	ProductName string    `json:"product" gorm:"column:product_name"` // Mapping product frontend ke product_name
@@ -110,6 +123,7 @@ var db *gorm.DB
 
 func Init(database *gorm.DB) {
 	db = database
+	trash.Register(trash.Purger{Module: "quality", Model: &QualityControl{}})
 	log.Println("Quality Control module initialized.")
 	// Opsional: AutoMigrate jika Anda ingin GORM membuat/memperbarui tabel
 	// err := db.AutoMigrate(&QualityControl{}, &Produksi{}, &Overhaul{}, &Rekayasa{}, &Inventory{})
@@ -180,8 +194,7 @@ func findRekayasaIDByNumericID(numericID int) (*uint, error) {
 // findInventoryIDByNumericID finds the database ID for an Inventory item by its numeric ID.
 func findInventoryIDByNumericID(numericID int) (*uint, error) {
 	var inventory Inventory
-	// Example assumes mapping numericID to 'id':
-	result := db.Select("id").First(&inventory, numericID)
+	result := db.Select("inventory_id").First(&inventory, numericID)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, nil // Not found
@@ -278,7 +291,7 @@ func getAllQualityControl(c *gin.Context) {
 			qcEntry := QualityControl{
 				QcID:        item.ProduksiID, // Set QcID with ProduksiID for uniqueness
 				ProductName: item.Name,
-				BatchCode:   fmt.Sprintf("BATCH-%s-%d", item.StartDate[:4], item.ProduksiID), // Example batch
+				BatchCode:   fmt.Sprintf("BATCH-%s-%d", batchYear(parseDate(item.StartDate)), item.ProduksiID), // Example batch
 				Status:      status,
 				TestedCount: item.Target,
 				PassedCount: item.Completed,
@@ -308,14 +321,21 @@ func getAllQualityControl(c *gin.Context) {
 				determinedDepartment = "Overhaul" // Default to Overhaul if product name doesn't clearly indicate another department
 			}
 
+			// Tanggal QC: tanggal selesai aktual, atau estimasi selesai jika belum selesai
+			var qcDate time.Time
+			if item.CompletedAt != nil {
+				qcDate = *item.CompletedAt
+			} else if item.Estimate != nil {
+				qcDate = *item.Estimate
+			}
 			qcEntry := QualityControl{
 				QcID:        item.OverhaulID, // Set QcID with OverhaulID for uniqueness
 				ProductName: item.Name,
-				BatchCode:   fmt.Sprintf("BATCH-%s-%d", item.Estimate[:4], item.OverhaulID), // Example batch, use string slice
+				BatchCode:   fmt.Sprintf("BATCH-%s-%d", batchYear(qcDate), item.OverhaulID), // Example batch
 				Status:      status,
 				TestedCount: 100, // Assuming 100 as total for progress-based QC
 				PassedCount: item.Progress,
-				QcDate:      parseDate(item.Estimate), // Parse string date
+				QcDate:      qcDate,
 				Department:  determinedDepartment,
 				OverhaulID:  uintPtr(uint(item.OverhaulID)), // Set FK
 			}
@@ -380,8 +400,12 @@ func getAllQualityControl(c *gin.Context) {
 	c.JSON(http.StatusOK, allQCEntries)
 }
 
-// parseDate helper function to parse string date to time.Time
+// parseDate helper function to parse string date to time.Time.
+// Kolom DATE dibaca sebagai RFC3339 (parseTime=true), sehingga hanya bagian YYYY-MM-DD yang dipakai.
 func parseDate(dateStr string) time.Time {
+	if len(dateStr) > len("2006-01-02") {
+		dateStr = dateStr[:len("2006-01-02")]
+	}
 	t, err := time.Parse("2006-01-02", dateStr) // Assuming "YYYY-MM-DD" format
 	if err != nil {
 		log.Printf("Warning: Could not parse date string '%s': %v", dateStr, err)
@@ -390,6 +414,14 @@ func parseDate(dateStr string) time.Time {
 	return t
 }
 
+// batchYear mengembalikan tahun untuk kode batch, "0000" jika tanggal tidak diketahui
+func batchYear(t time.Time) string {
+	if t.IsZero() {
+		return "0000"
+	}
+	return t.Format("2006")
+}
+
 // uintPtr helper function to return a pointer to a uint
 func uintPtr(i uint) *uint {
 	return &i
@@ -524,7 +556,7 @@ func createQualityControl(c *gin.Context) {
 	c.JSON(http.StatusCreated, createdEntry) // Kirim kembali entri yang baru ditambahkan
 }
 
-// deleteQualityControl menghapus entri QC dari database
+// deleteQualityControl memindahkan entri QC ke trash
 func deleteQualityControl(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -555,68 +587,58 @@ func deleteQualityControl(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil) // 204 No Content
 }
 
-// updateQualityControl memperbarui entri QC di database
-func updateQualityControl(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID Quality Control tidak valid"})
+// getQualityControlTrash menampilkan entri QC yang sedang berada di trash
+func getQualityControlTrash(c *gin.Context) {
+	entries := []QualityControl{}
+	if err := trash.List(db, &entries); err != nil {
+		log.Printf("Error fetching deleted quality_control entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash Quality Control", "details": err.Error()})
 		return
 	}
-
-	var updatedEntry QualityControl
-	if err := c.ShouldBindJSON(&updatedEntry); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid", "details": err.Error()})
-		return
-	}
-
-	validDepartments := map[string]bool{
-		"Production": true,
-		"Overhaul":   true,
-		"Rekayasa":   true,
-		"Kalibrasi":  true,
-	}
-	if !validDepartments[updatedEntry.Department] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Department tidak valid"})
-		return
+	for i := range entries {
+		entries[i].FrontendID = generateFrontendID(&entries[i])
+		calculatePassRate(&entries[i])
 	}
+	c.JSON(http.StatusOK, entries)
+}
 
-	// Validasi sederhana
-	if updatedEntry.ProductName == "" || updatedEntry.BatchCode == "" || updatedEntry.Status == "" || updatedEntry.Department == "" || updatedEntry.TestedCount < 0 || updatedEntry.PassedCount < 0 || updatedEntry.QcDate.IsZero() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Semua field (product, batch, status, department, tested, passed, date) wajib diisi dan jumlah tidak boleh negatif"})
-		return
-	}
-	if updatedEntry.PassedCount > updatedEntry.TestedCount {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah lulus tidak boleh lebih besar dari jumlah diuji"})
+// restoreQualityControl memulihkan entri QC dari trash
+func restoreQualityControl(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID Quality Control tidak valid"})
 		return
 	}
-
-	// Cari entri yang ada berdasarkan ID (primary key)
-	var item QualityControl
-	// Preload relasi jika Anda ingin memastikan relasi lama tidak hilang saat Save jika tidak diupdate
-	// Namun, karena kita akan me-reset dan menugaskan ulang FK, Preload mungkin tidak diperlukan di sini
-	// kecuali jika Anda memiliki logika khusus terkait relasi lama.
-	if result := db.First(&item, id); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Entri Quality Control tidak ditemukan"})
+	if err := trash.Restore(db, &QualityControl{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entri Quality Control tidak ditemukan di trash"})
 		} else {
-			log.Printf("Error saat mencari entri QC dengan ID %d untuk diperbarui: %v", id, result.Error)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencari entri Quality Control", "details": result.Error.Error()})
+			log.Printf("Error saat memulihkan entri QC dengan ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan entri Quality Control", "details": err.Error()})
 		}
 		return
 	}
 
-	// *** Penanganan Foreign Key saat Update berdasarkan FrontendID ***
+	var entry QualityControl
+	db.First(&entry, id)
+	entry.FrontendID = generateFrontendID(&entry)
+	calculatePassRate(&entry)
+	c.JSON(http.StatusOK, entry)
+}
+
+// linkFrontendID menautkan ulang foreign key entri QC berdasarkan kode frontend (mis. PRD-001).
+// Semua foreign key lama di-reset lebih dulu agar data lama tidak tetap terkait.
+func linkFrontendID(item *QualityControl, frontendID string) {
 	// Pertama, reset semua Foreign Key yang ada untuk mencegah data lama tetap terkait
 	item.ProduksiID = nil
 	item.OverhaulID = nil
 	item.RekayasaID = nil
 	item.InventoryID = nil
 
-	if updatedEntry.FrontendID != "" {
-		deptPrefix, numericID, err := parseFrontendID(updatedEntry.FrontendID)
+	if frontendID != "" {
+		deptPrefix, numericID, err := parseFrontendID(frontendID)
 		if err != nil {
-			log.Printf("Error parsing FrontendID '%s' during update: %v", updatedEntry.FrontendID, err)
+			log.Printf("Error parsing FrontendID '%s' during update: %v", frontendID, err)
 			// Decide how to handle invalid FrontendID: return error or ignore?
 			// For now, we'll log and continue without setting FKs.
 		} else {
@@ -656,7 +678,61 @@ func updateQualityControl(c *gin.Context) {
 			}
 		}
 	}
-	// *** Akhir Penanganan Foreign Key saat Update ***
+}
+
+// updateQualityControl memperbarui entri QC di database
+func updateQualityControl(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID Quality Control tidak valid"})
+		return
+	}
+
+	var updatedEntry QualityControl
+	if err := c.ShouldBindJSON(&updatedEntry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid", "details": err.Error()})
+		return
+	}
+
+	validDepartments := map[string]bool{
+		"Production": true,
+		"Overhaul":   true,
+		"Rekayasa":   true,
+		"Kalibrasi":  true,
+	}
+	if !validDepartments[updatedEntry.Department] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Department tidak valid"})
+		return
+	}
+
+	// Validasi sederhana
+	if updatedEntry.ProductName == "" || updatedEntry.BatchCode == "" || updatedEntry.Status == "" || updatedEntry.Department == "" || updatedEntry.TestedCount < 0 || updatedEntry.PassedCount < 0 || updatedEntry.QcDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Semua field (product, batch, status, department, tested, passed, date) wajib diisi dan jumlah tidak boleh negatif"})
+		return
+	}
+	if updatedEntry.PassedCount > updatedEntry.TestedCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah lulus tidak boleh lebih besar dari jumlah diuji"})
+		return
+	}
+
+	// Cari entri yang ada berdasarkan ID (primary key)
+	var item QualityControl
+	// Preload relasi jika Anda ingin memastikan relasi lama tidak hilang saat Save jika tidak diupdate
+	// Namun, karena kita akan me-reset dan menugaskan ulang FK, Preload mungkin tidak diperlukan di sini
+	// kecuali jika Anda memiliki logika khusus terkait relasi lama.
+	if result := db.First(&item, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entri Quality Control tidak ditemukan"})
+		} else {
+			log.Printf("Error saat mencari entri QC dengan ID %d untuk diperbarui: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencari entri Quality Control", "details": result.Error.Error()})
+		}
+		return
+	}
+
+	// *** Penanganan Foreign Key saat Update berdasarkan FrontendID ***
+	linkFrontendID(&item, updatedEntry.FrontendID)
 
 	// Update field entri yang ada dengan data dari updatedEntry
 	item.ProductName = updatedEntry.ProductName
@@ -688,6 +764,96 @@ func updateQualityControl(c *gin.Context) {
 	c.JSON(http.StatusOK, savedEntry) // Kirim kembali entri yang diperbarui
 }
 
+// patchQualityControl memperbarui sebagian entri QC (JSON Merge Patch, RFC 7396).
+// Hanya field yang dikirim yang divalidasi; field `id` (kode frontend) menautkan ulang foreign key.
+func patchQualityControl(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID Quality Control tidak valid"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var item QualityControl
+	if result := db.First(&item, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entri Quality Control tidak ditemukan"})
+		} else {
+			log.Printf("Error saat mencari entri QC dengan ID %d untuk di-patch: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencari entri Quality Control", "details": result.Error.Error()})
+		}
+		return
+	}
+	item.FrontendID = generateFrontendID(&item)
+	calculatePassRate(&item)
+
+	var merged QualityControl
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid", "details": err.Error()})
+		return
+	}
+	merged.ID = item.ID
+
+	validDepartments := map[string]bool{
+		"Production": true,
+		"Overhaul":   true,
+		"Rekayasa":   true,
+		"Kalibrasi":  true,
+	}
+	if patch.Has("department") && !validDepartments[merged.Department] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Department tidak valid"})
+		return
+	}
+	blank := patch.Blank(map[string]string{"product": merged.ProductName, "batch": merged.BatchCode, "status": merged.Status})
+	if len(blank) > 0 || (patch.Has("tested") && merged.TestedCount < 0) || (patch.Has("passed") && merged.PassedCount < 0) ||
+		(patch.Has("date") && merged.QcDate.IsZero()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field (product, batch, status, date) tidak boleh kosong dan jumlah tidak boleh negatif"})
+		return
+	}
+	if patch.HasAny("tested", "passed") && merged.PassedCount > merged.TestedCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah lulus tidak boleh lebih besar dari jumlah diuji"})
+		return
+	}
+
+	// Kode frontend tidak disimpan sebagai kolom; dipakai untuk menautkan ulang foreign key
+	_, relink := patch.Take("id")
+	patch.Take("passRate") // Dihitung dari tested/passed, nilai dari client diabaikan
+
+	columns, rejected, err := patch.Columns(db, &QualityControl{}, "QcID", "CreatedAt", "UpdatedAt")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah", "fields": rejected})
+		return
+	}
+	if relink {
+		linkFrontendID(&merged, merged.FrontendID)
+		columns = append(columns, "produksi_id", "overhaul_id", "rekayasa_id", "inventory_id")
+	}
+
+	if len(columns) > 0 {
+		if result := db.Model(&item).Select(columns).Updates(&merged); result.Error != nil {
+			log.Printf("Error saat mem-patch entri QC dengan ID %d: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui entri Quality Control", "details": result.Error.Error()})
+			return
+		}
+	}
+
+	var savedEntry QualityControl
+	db.First(&savedEntry, item.ID)
+	savedEntry.FrontendID = generateFrontendID(&savedEntry)
+	calculatePassRate(&savedEntry)
+	c.JSON(http.StatusOK, savedEntry)
+}
+
 func getQualityControlByFrontendID(c *gin.Context) {
 	frontendCode := c.Param("frontendCode")
 	deptPrefix, numericID, err := parseFrontendID(frontendCode)
@@ -730,9 +896,12 @@ func getDepartmentFromPrefix(prefix string) string {
 // RegisterRoutes mendaftarkan rute API untuk modul Quality Control
 func RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/", getAllQualityControl)
+	rg.GET("/trash", getQualityControlTrash)
 	rg.GET("/:id", getQualityControlByID)
 	rg.POST("/", createQualityControl)
 	rg.PUT("/:id", updateQualityControl)
+	rg.PATCH("/:id", patchQualityControl)
 	rg.DELETE("/:id", deleteQualityControl)
+	rg.POST("/:id/restore", restoreQualityControl)
 	rg.GET("/frontend/:frontendCode", getQualityControlByFrontendID) // New endpoint for frontend ID search
 }
@@ -1,13 +1,18 @@
 package rekayasa
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"strings" // Import package strings untuk Join dan Split
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Rekayasa mewakili struktur data untuk item rekayasa di database
@@ -15,22 +20,33 @@ type Rekayasa struct {
 	RekayasaID int    `json:"id" gorm:"column:rekayasa_id;primaryKey;autoIncrement"`
 	Name       string `json:"name" gorm:"column:name"`
 	Status     string `json:"status" gorm:"column:status"`
-	// Team sekarang bertipe string di Go untuk disimpan sebagai VARCHAR/TEXT di DB.
-	// `json:"-"` agar GORM tidak mencoba mem-bind ini langsung dari JSON request body,
-	// karena kita akan mengonversinya secara manual dari RekayasaFrontend.Team.
-	Team     string `json:"-" gorm:"column:team"`
-	Deadline string `json:"deadline" gorm:"column:deadline"` // Frontend mengirim string tanggal
-	Progress int    `json:"progress" gorm:"column:progress"` // Frontend mengirim int, asumsikan kolom DB INT
+	// Kolom team lama (string dipisahkan ", "). Anggota tim kini disimpan di tabel
+	// rekayasa_team; kolom ini hanya menyisakan entri lama yang tidak cocok dengan personalia.
+	Team      string  `json:"-" gorm:"column:team"`
+	StartDate *string `json:"startDate" gorm:"column:start_date"` // Awal rencana linear untuk dashboard jadwal
+	Deadline  string  `json:"deadline" gorm:"column:deadline"`    // Frontend mengirim string tanggal (YYYY-MM-DD)
+	Progress  int     `json:"progress" gorm:"column:progress"`    // Frontend mengirim int, asumsikan kolom DB INT
+	// Job produksi hasil release-to-production; hanya diisi oleh endpoint tersebut
+	ProduksiID *int `json:"produksi_id" gorm:"column:produksi_id"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
-// Struct untuk menerima dan mengirim data ke/dari frontend (Team sebagai []string)
+// Struct untuk menerima dan mengirim data ke/dari frontend
 type RekayasaFrontend struct {
-	RekayasaID int      `json:"id"`
-	Name       string   `json:"name"`
-	Status     string   `json:"status"`
-	Team       []string `json:"team"` // Menerima dan mengirim array string dari/ke frontend
-	Deadline   string   `json:"deadline"`
-	Progress   int      `json:"progress"`
+	RekayasaID int          `json:"id"`
+	Name       string       `json:"name"`
+	Status     string       `json:"status"`
+	Team       []string     `json:"team"`                 // NIP anggota tim; tetap diterima untuk kompatibilitas
+	Members    []TeamMember `json:"members"`              // Anggota tim beserta peran (lead, engineer, drafter)
+	LegacyTeam []string     `json:"legacyTeam,omitempty"` // Entri tim lama yang belum cocok dengan personalia
+	StartDate  string       `json:"startDate"`            // Default tanggal proyek dibuat
+	Deadline   string       `json:"deadline"`
+	Progress   int          `json:"progress"`    // Dihitung dari bobot task yang selesai jika proyek memiliki task
+	ProduksiID *int         `json:"produksi_id"` // Job produksi hasil release-to-production
+
+	TaskSummary *TaskSummary `json:"taskSummary,omitempty"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"` // Hanya terisi untuk proyek di trash
 }
 
 // TableName mengembalikan nama tabel untuk model Rekayasa
@@ -43,6 +59,8 @@ var db *gorm.DB
 // Init menginisialisasi koneksi database untuk modul rekayasa
 func Init(database *gorm.DB) {
 	db = database
+	migrateLegacyTeams()
+	trash.Register(trash.Purger{Module: "rekayasa", Model: &Rekayasa{}, Purge: purgeProject, AfterPurge: removeProjectFiles})
 	log.Println("Rekayasa module initialized.")
 }
 
@@ -55,21 +73,11 @@ func getAllProjects(c *gin.Context) {
 		return
 	}
 
-	var projectsFrontend []RekayasaFrontend // Konversi ke struct untuk frontend (Team sebagai []string)
-	for _, pDB := range projectsDB {
-		var teamSlice []string
-		if pDB.Team != "" {
-			// Konversi string yang dipisahkan koma menjadi []string
-			teamSlice = strings.Split(pDB.Team, ", ")
-		}
-		projectsFrontend = append(projectsFrontend, RekayasaFrontend{
-			RekayasaID: pDB.RekayasaID,
-			Name:       pDB.Name,
-			Status:     pDB.Status,
-			Team:       teamSlice, // Gunakan slice yang sudah di-split
-			Deadline:   pDB.Deadline,
-			Progress:   pDB.Progress,
-		})
+	projectsFrontend, err := projectsToFrontend(projectsDB) // Lengkapi dengan anggota tim dari rekayasa_team
+	if err != nil {
+		log.Printf("Error fetching project teams: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project teams"})
+		return
 	}
 
 	c.JSON(http.StatusOK, projectsFrontend)
@@ -77,40 +85,28 @@ func getAllProjects(c *gin.Context) {
 
 // getProjectByID mengambil proyek berdasarkan ID dan mengonversinya untuk frontend
 func getProjectByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+	projectDB, ok := findProject(c)
+	if !ok {
 		return
 	}
+	respondProject(c, http.StatusOK, projectDB.RekayasaID)
+}
 
-	var projectDB Rekayasa // Ambil dari DB sebagai struct dengan Team string
+// respondProject memuat ulang proyek beserta timnya lalu mengirimkannya ke frontend
+func respondProject(c *gin.Context, status, id int) {
+	var projectDB Rekayasa
 	if result := db.First(&projectDB, id); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-		} else {
-			log.Printf("Error fetching project with ID %d: %v", id, result.Error)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
-		}
+		log.Printf("Error reloading project with ID %d: %v", id, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
 		return
 	}
-
-	var teamSlice []string
-	if projectDB.Team != "" {
-		// Konversi string yang dipisahkan koma menjadi []string
-		teamSlice = strings.Split(projectDB.Team, ", ")
-	}
-
-	projectFrontend := RekayasaFrontend{
-		RekayasaID: projectDB.RekayasaID,
-		Name:       projectDB.Name,
-		Status:     projectDB.Status,
-		Team:       teamSlice, // Gunakan slice yang sudah di-split
-		Deadline:   projectDB.Deadline,
-		Progress:   projectDB.Progress,
+	projects, err := projectsToFrontend([]Rekayasa{projectDB})
+	if err != nil {
+		log.Printf("Error fetching team for project %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project team"})
+		return
 	}
-
-	c.JSON(http.StatusOK, projectFrontend)
+	c.JSON(status, projects[0])
 }
 
 // createProject menambahkan proyek baru ke database
@@ -123,7 +119,8 @@ func createProject(c *gin.Context) {
 	}
 
 	// Validasi sederhana
-	if newProjectFrontend.Name == "" || newProjectFrontend.Deadline == "" || len(newProjectFrontend.Team) == 0 {
+	if newProjectFrontend.Name == "" || newProjectFrontend.Deadline == "" ||
+		(len(newProjectFrontend.Team) == 0 && len(newProjectFrontend.Members) == 0) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Name, Deadline, and Team are required"})
 		return
 	}
@@ -131,27 +128,44 @@ func createProject(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress must be between 0 and 100"})
 		return
 	}
+	// Rencana linear dimulai hari ini jika startDate tidak dikirim
+	if newProjectFrontend.StartDate == "" {
+		newProjectFrontend.StartDate = dateutil.Today().Format(dateutil.Layout)
+	}
+	if msg := validateProjectDates(db, 0, newProjectFrontend.StartDate, newProjectFrontend.Deadline); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
-	// Konversi Team []string dari frontend menjadi string yang dipisahkan koma untuk database
-	teamString := strings.Join(newProjectFrontend.Team, ", ")
+	// Anggota tim harus terdaftar di personalia
+	members, msg := requestTeam(db, newProjectFrontend)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
 	newProjectDB := Rekayasa{
-		Name:     newProjectFrontend.Name,
-		Status:   newProjectFrontend.Status,
-		Team:     teamString, // Simpan sebagai string yang dipisahkan koma
-		Deadline: newProjectFrontend.Deadline,
-		Progress: newProjectFrontend.Progress,
+		Name:      newProjectFrontend.Name,
+		Status:    newProjectFrontend.Status,
+		StartDate: &newProjectFrontend.StartDate,
+		Deadline:  newProjectFrontend.Deadline,
+		Progress:  newProjectFrontend.Progress,
 	}
 
-	if result := db.Create(&newProjectDB); result.Error != nil {
-		log.Printf("Error creating project in DB: %v", result.Error)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newProjectDB).Error; err != nil {
+			return err
+		}
+		return replaceTeam(tx, newProjectDB.RekayasaID, members)
+	})
+	if err != nil {
+		log.Printf("Error creating project in DB: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project in database"})
 		return
 	}
 
-	// Pastikan ID yang di-generate GORM dikembalikan ke frontend
-	newProjectFrontend.RekayasaID = newProjectDB.RekayasaID
-	c.JSON(http.StatusCreated, newProjectFrontend)
+	// Kembalikan proyek beserta ID yang di-generate GORM dan anggota timnya
+	respondProject(c, http.StatusCreated, newProjectDB.RekayasaID)
 }
 
 // updateProject memperbarui proyek yang sudah ada di database
@@ -169,7 +183,8 @@ func updateProject(c *gin.Context) {
 		return
 	}
 
-	if updatedProjectFrontend.Name == "" || updatedProjectFrontend.Deadline == "" || len(updatedProjectFrontend.Team) == 0 {
+	if updatedProjectFrontend.Name == "" || updatedProjectFrontend.Deadline == "" ||
+		(len(updatedProjectFrontend.Team) == 0 && len(updatedProjectFrontend.Members) == 0) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Name, Deadline, and Team are required"})
 		return
 	}
@@ -189,24 +204,185 @@ func updateProject(c *gin.Context) {
 		return
 	}
 
-	// Konversi Team []string dari frontend menjadi string yang dipisahkan koma untuk database
-	teamString := strings.Join(updatedProjectFrontend.Team, ", ")
+	// startDate kosong berarti tetap memakai awal rencana yang tersimpan
+	if updatedProjectFrontend.StartDate == "" && existingProjectDB.StartDate != nil {
+		updatedProjectFrontend.StartDate = dateutil.Format(*existingProjectDB.StartDate)
+	}
+	if msg := validateProjectDates(db, existingProjectDB.RekayasaID, updatedProjectFrontend.StartDate, updatedProjectFrontend.Deadline); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Anggota tim harus terdaftar di personalia
+	members, msg := requestTeam(db, updatedProjectFrontend)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Progress proyek yang memiliki task dihitung dari bobot task, nilai dari request diabaikan
+	computed, err := hasTasks(db, existingProjectDB.RekayasaID)
+	if err != nil {
+		log.Printf("Error checking tasks for project %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
 
 	existingProjectDB.Name = updatedProjectFrontend.Name
 	existingProjectDB.Status = updatedProjectFrontend.Status
-	existingProjectDB.Team = teamString // Simpan sebagai string yang dipisahkan koma
+	existingProjectDB.Team = "" // Tim lengkap dikirim ulang, entri lama tidak lagi dipakai
 	existingProjectDB.Deadline = updatedProjectFrontend.Deadline
-	existingProjectDB.Progress = updatedProjectFrontend.Progress
+	existingProjectDB.StartDate = nil
+	if updatedProjectFrontend.StartDate != "" {
+		existingProjectDB.StartDate = &updatedProjectFrontend.StartDate
+	}
+	if !computed {
+		existingProjectDB.Progress = updatedProjectFrontend.Progress
+	}
 
-	if result := db.Save(&existingProjectDB); result.Error != nil {
-		log.Printf("Error updating project with ID %d: %v", id, result.Error)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&existingProjectDB).Error; err != nil {
+			return err
+		}
+		return replaceTeam(tx, existingProjectDB.RekayasaID, members)
+	})
+	if err != nil {
+		log.Printf("Error updating project with ID %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
 		return
 	}
 
-	// Pastikan ID tetap sama dan kembalikan data yang diupdate ke frontend
-	updatedProjectFrontend.RekayasaID = existingProjectDB.RekayasaID
-	c.JSON(http.StatusOK, updatedProjectFrontend)
+	// Kembalikan data yang diupdate beserta anggota tim ke frontend
+	respondProject(c, http.StatusOK, existingProjectDB.RekayasaID)
+}
+
+// patchProject memperbarui sebagian proyek (JSON Merge Patch, RFC 7396).
+// Patch diterapkan pada representasi frontend (team sebagai []string NIP, members dengan peran);
+// hanya field yang dikirim yang divalidasi dan disimpan.
+func patchProject(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Invalid patch document", "details": err.Error()})
+		return
+	}
+
+	var projectDB Rekayasa
+	if result := db.First(&projectDB, id); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		} else {
+			log.Printf("Error finding project with ID %d for patch: %v", id, result.Error)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find project"})
+		}
+		return
+	}
+
+	currentList, err := projectsToFrontend([]Rekayasa{projectDB})
+	if err != nil {
+		log.Printf("Error fetching team for project %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project team"})
+		return
+	}
+	current := currentList[0]
+
+	var merged RekayasaFrontend
+	if err := patch.ApplyTo(current, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+
+	if blank := patch.Blank(map[string]string{"name": merged.Name, "deadline": merged.Deadline}); len(blank) > 0 ||
+		(patch.Has("team") && len(merged.Team) == 0) || (patch.Has("members") && len(merged.Members) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name, Deadline, and Team cannot be empty"})
+		return
+	}
+	if patch.Has("deadline") || patch.Has("startDate") {
+		if msg := validateProjectDates(db, projectDB.RekayasaID, merged.StartDate, merged.Deadline); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if patch.Has("progress") && (merged.Progress < 0 || merged.Progress > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Progress must be between 0 and 100"})
+		return
+	}
+	if patch.Has("progress") && merged.Progress != current.Progress {
+		computed, err := hasTasks(db, projectDB.RekayasaID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+			return
+		}
+		if computed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Progress is computed from task weights; update the tasks instead"})
+			return
+		}
+	}
+
+	// Tim disimpan di tabel rekayasa_team, bukan dipetakan langsung ke kolom.
+	// members diutamakan; team (NIP) hanya dipakai jika members tidak dikirim.
+	var members []TeamMember
+	_, membersChanged := patch.Take("members")
+	_, teamChanged := patch.Take("team")
+	if membersChanged || teamChanged {
+		var msg string
+		if membersChanged {
+			members, msg = resolveTeam(db, merged.Members)
+		} else {
+			members, msg = requestTeam(db, RekayasaFrontend{Team: merged.Team})
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	columns, rejected, err := patch.Columns(db, &Rekayasa{}, "produksi_id", "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only fields", "fields": rejected})
+		return
+	}
+	if membersChanged || teamChanged {
+		columns = append(columns, "team") // Entri tim lama ikut dibersihkan
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			updates := Rekayasa{
+				Name:     merged.Name,
+				Status:   merged.Status,
+				Deadline: merged.Deadline,
+				Progress: merged.Progress,
+			}
+			if merged.StartDate != "" {
+				updates.StartDate = &merged.StartDate
+			}
+			if err := tx.Model(&projectDB).Select(columns).Updates(&updates).Error; err != nil {
+				return err
+			}
+		}
+		if membersChanged || teamChanged {
+			return replaceTeam(tx, projectDB.RekayasaID, members)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error patching project with ID %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	respondProject(c, http.StatusOK, projectDB.RekayasaID)
 }
 
 // deleteProject menghapus proyek dari database
@@ -229,8 +405,9 @@ func deleteProject(c *gin.Context) {
 		return
 	}
 
-	if result := db.Delete(&project); result.Error != nil {
-		log.Printf("Error deleting project with ID %d: %v", id, result.Error)
+	// Proyek dipindahkan ke trash; task, milestone, dokumen, ECR, BOM dan tim tetap disimpan untuk restore
+	if err := db.Delete(&project).Error; err != nil {
+		log.Printf("Error deleting project with ID %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
 		return
 	}
@@ -238,17 +415,151 @@ func deleteProject(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// purgeProject menghapus permanen proyek. Task, milestone, dokumen, ECR, BOM dan anggota tim
+// dihapus lebih dulu karena terikat foreign key ke rekayasa. Job produksi hasil
+// release-to-production dan entri QC tetap ada, hanya tautannya yang dilepas. File dokumen
+// dihapus removeProjectFiles setelah transaksi berhasil.
+func purgeProject(tx *gorm.DB, id int) error {
+	if err := tx.Model(&Produksi{}).Where("rekayasa_id = ?", id).Update("rekayasa_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Table("quality_control").Where("rekayasa_id = ?", id).Update("rekayasa_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("rekayasa_id = ?", id).Delete(&DesignMaterial{}).Error; err != nil {
+		return err
+	}
+	if err := deleteProjectDocuments(tx, id); err != nil {
+		return err
+	}
+	if err := deleteProjectChangeRequests(tx, id); err != nil {
+		return err
+	}
+	if err := tx.Where("rekayasa_id = ?", id).Delete(&Task{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("rekayasa_id = ?", id).Delete(&Milestone{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("rekayasa_id = ?", id).Delete(&TeamMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Rekayasa{}, id).Error
+}
+
+// getProjectTrash mengambil proyek yang sedang berada di trash
+func getProjectTrash(c *gin.Context) {
+	var projectsDB []Rekayasa
+	if err := trash.List(db, &projectsDB); err != nil {
+		log.Printf("Error fetching deleted projects: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project trash"})
+		return
+	}
+
+	projectsFrontend, err := projectsToFrontend(projectsDB)
+	if err != nil {
+		log.Printf("Error fetching project teams: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projectsFrontend)
+}
+
+// restoreProject memulihkan proyek dari trash
+func restoreProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	if err := trash.Restore(db, &Rekayasa{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found in trash"})
+		} else {
+			log.Printf("Error restoring project with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore project"})
+		}
+		return
+	}
+	getProjectByID(c)
+}
+
 // RegisterRoutes mendaftarkan rute API untuk modul rekayasa
 func RegisterRoutes(r *gin.RouterGroup) {
 	// Daftarkan rute untuk GET dan POST agar bisa menerima baik dengan atau tanpa trailing slash
 	r.GET("", getAllProjects)  // Menangani /api/rekayasa (no trailing slash)
 	r.GET("/", getAllProjects) // Menangani /api/rekayasa/ (with trailing slash)
 
+	// Task terlambat lintas proyek
+	r.GET("/overdue-tasks", getOverdueTasks)
+
+	// Dashboard jadwal: sisa hari, progress aktual vs rencana linear, beban kerja per personel
+	r.GET("/dashboard", getDashboard)
+
+	// Engineering change request lintas proyek (filter status, reviewer, job produksi, item inventory)
+	r.GET("/change-requests", getAllChangeRequests)
+
+	// Proyek yang dihapus (trash)
+	r.GET("/trash", getProjectTrash)
+
 	r.GET("/:id", getProjectByID)
 
 	r.POST("", createProject)  // Menangani /api/rekayasa (no trailing slash)
 	r.POST("/", createProject) // Menangani /api/rekayasa/ (with trailing slash)
 
 	r.PUT("/:id", updateProject)
+	r.PATCH("/:id", patchProject)
 	r.DELETE("/:id", deleteProject)
+	r.POST("/:id/restore", restoreProject)
+
+	// Keanggotaan tim proyek (personalia dengan peran lead, engineer, drafter)
+	r.GET("/:id/team", getTeam)
+	r.POST("/:id/team", addTeamMember)
+	r.PUT("/:id/team/:memberId", updateTeamMember)
+	r.DELETE("/:id/team/:memberId", removeTeamMember)
+
+	// Milestone dan task proyek; progress proyek dihitung dari bobot task yang selesai
+	r.GET("/:id/milestones", getMilestones)
+	r.POST("/:id/milestones", createMilestone)
+	r.PUT("/:id/milestones/:milestoneId", updateMilestone)
+	r.DELETE("/:id/milestones/:milestoneId", deleteMilestone)
+	r.GET("/:id/tasks", getTasks)
+	r.POST("/:id/tasks", createTask)
+	r.PUT("/:id/tasks/:taskId", updateTask)
+	r.PATCH("/:id/tasks/:taskId", patchTask)
+	r.DELETE("/:id/tasks/:taskId", deleteTask)
+
+	// Repositori dokumen engineering dengan revisi (file disimpan di REKAYASA_DOCUMENT_ROOT)
+	r.GET("/:id/documents", getDocuments)
+	r.POST("/:id/documents", createDocument)
+	r.GET("/:id/documents/:docId", getDocument)
+	r.PUT("/:id/documents/:docId", updateDocument)
+	r.DELETE("/:id/documents/:docId", deleteDocument)
+	r.GET("/:id/documents/:docId/download", downloadCurrent)
+	r.POST("/:id/documents/:docId/revisions", createRevision)
+	r.PUT("/:id/documents/:docId/revisions/:revision/status", updateRevisionStatus)
+	r.GET("/:id/documents/:docId/revisions/:revision/download", downloadRevision)
+	r.DELETE("/:id/documents/:docId/revisions/:revision", deleteRevision)
+
+	// Engineering change request (ECR): submitted -> under_review -> approved/rejected -> implemented
+	r.GET("/:id/change-requests", getChangeRequests)
+	r.POST("/:id/change-requests", createChangeRequest)
+	r.GET("/:id/change-requests/:ecrId", getChangeRequest)
+	r.PUT("/:id/change-requests/:ecrId", updateChangeRequest)
+	r.DELETE("/:id/change-requests/:ecrId", deleteChangeRequest)
+	r.PUT("/:id/change-requests/:ecrId/status", updateChangeRequestStatus)
+	r.POST("/:id/change-requests/:ecrId/reviewers", addReviewer)
+	r.DELETE("/:id/change-requests/:ecrId/reviewers/:reviewerId", removeReviewer)
+	r.PUT("/:id/change-requests/:ecrId/reviewers/:reviewerId/decision", decideReview)
+	r.POST("/:id/change-requests/:ecrId/comments", addComment)
+	r.POST("/:id/change-requests/:ecrId/impacts", addImpact)
+	r.DELETE("/:id/change-requests/:ecrId/impacts/:impactId", removeImpact)
+
+	// BOM desain dan serah terima proyek selesai ke produksi
+	r.GET("/:id/bom", getDesignBOM)
+	r.POST("/:id/bom", createDesignBOMLine)
+	r.PUT("/:id/bom/:lineId", updateDesignBOMLine)
+	r.DELETE("/:id/bom/:lineId", deleteDesignBOMLine)
+	r.POST("/:id/release-to-production", releaseToProduction)
 }
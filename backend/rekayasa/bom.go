@@ -0,0 +1,203 @@
+package rekayasa
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Material - Referensi minimal ke master material pada tabel 'materials'
+type Material struct {
+	MaterialsID   int     `json:"id" gorm:"column:materials_id;primaryKey"`
+	MaterialsName string  `json:"name" gorm:"column:materials_name"`
+	Price         float64 `json:"harga" gorm:"column:price"`
+	Satuan        string  `json:"satuan" gorm:"column:satuan"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Material di trash dianggap tidak ada
+}
+
+func (Material) TableName() string {
+	return "materials"
+}
+
+// DesignMaterial - Satu baris BOM desain proyek rekayasa (tabel 'rekayasa_material').
+// Kuantitas per unit produk, sama seperti BOM job produksi; baris ini disalin ke
+// produksi_materials saat proyek dirilis ke produksi.
+type DesignMaterial struct {
+	RekayasaMaterialID int     `json:"id" gorm:"column:rekayasa_material_id;primaryKey;autoIncrement"`
+	RekayasaID         int     `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	MaterialsID        int     `json:"materials_id" gorm:"column:materials_id"`
+	InventoryID        *int    `json:"inventory_id,omitempty" gorm:"column:inventory_id"`
+	QtyPerUnit         float64 `json:"qty_per_unit" gorm:"column:qty_per_unit"`
+	Notes              string  `json:"notes" gorm:"column:notes"`
+
+	Material  *Material  `json:"material,omitempty" gorm:"foreignKey:MaterialsID;references:MaterialsID"`
+	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
+}
+
+func (DesignMaterial) TableName() string {
+	return "rekayasa_material"
+}
+
+// loadDesignBOM mengambil BOM desain proyek beserta master material dan inventory
+func loadDesignBOM(tx *gorm.DB, rekayasaID int) ([]DesignMaterial, error) {
+	var lines []DesignMaterial
+	err := tx.Preload("Material").Preload("Inventory").
+		Where("rekayasa_id = ?", rekayasaID).
+		Order("rekayasa_material_id").
+		Find(&lines).Error
+	return lines, err
+}
+
+// validateDesignBOMLine memeriksa referensi material dan inventory pada baris BOM desain
+func validateDesignBOMLine(line DesignMaterial) string {
+	if line.MaterialsID <= 0 || line.QtyPerUnit <= 0 {
+		return "materials_id and qty_per_unit (> 0) are required"
+	}
+	var master Material
+	if err := db.First(&master, line.MaterialsID).Error; err != nil {
+		return "Material not found"
+	}
+	if line.InventoryID != nil {
+		var inv Inventory
+		if err := db.First(&inv, *line.InventoryID).Error; err != nil {
+			return "Inventory item not found"
+		}
+	}
+	return ""
+}
+
+// findDesignBOMLine mengambil baris BOM desain berdasarkan parameter :lineId milik proyek
+func findDesignBOMLine(c *gin.Context, project Rekayasa) (DesignMaterial, bool) {
+	var line DesignMaterial
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid BOM line ID"})
+		return line, false
+	}
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).First(&line, lineID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "BOM line not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch BOM line", "details": err.Error()})
+		}
+		return line, false
+	}
+	return line, true
+}
+
+// respondDesignBOMLine memuat ulang baris BOM desain beserta referensinya lalu mengirimkannya
+func respondDesignBOMLine(c *gin.Context, status, lineID int) {
+	var line DesignMaterial
+	if err := db.Preload("Material").Preload("Inventory").First(&line, lineID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch BOM line"})
+		return
+	}
+	c.JSON(status, line)
+}
+
+// getDesignBOM menampilkan BOM desain proyek
+func getDesignBOM(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	lines, err := loadDesignBOM(db, project.RekayasaID)
+	if err != nil {
+		log.Printf("Error fetching BOM for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch BOM", "details": err.Error()})
+		return
+	}
+	if lines == nil {
+		lines = []DesignMaterial{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rekayasa_id": project.RekayasaID,
+		"name":        project.Name,
+		"lines":       lines,
+	})
+}
+
+// createDesignBOMLine menambahkan baris material ke BOM desain proyek
+func createDesignBOMLine(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var line DesignMaterial
+	if err := c.ShouldBindJSON(&line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateDesignBOMLine(line); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	line.RekayasaMaterialID = 0
+	line.RekayasaID = project.RekayasaID
+	line.Material = nil
+	line.Inventory = nil
+	if err := db.Create(&line).Error; err != nil {
+		log.Printf("Error creating BOM line for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save BOM line", "details": err.Error()})
+		return
+	}
+	respondDesignBOMLine(c, http.StatusCreated, line.RekayasaMaterialID)
+}
+
+// updateDesignBOMLine memperbarui baris material pada BOM desain proyek
+func updateDesignBOMLine(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	line, ok := findDesignBOMLine(c, project)
+	if !ok {
+		return
+	}
+
+	var input DesignMaterial
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateDesignBOMLine(input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	line.MaterialsID = input.MaterialsID
+	line.InventoryID = input.InventoryID
+	line.QtyPerUnit = input.QtyPerUnit
+	line.Notes = input.Notes
+	if err := db.Save(&line).Error; err != nil {
+		log.Printf("Error updating BOM line %d: %v", line.RekayasaMaterialID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update BOM line", "details": err.Error()})
+		return
+	}
+	respondDesignBOMLine(c, http.StatusOK, line.RekayasaMaterialID)
+}
+
+// deleteDesignBOMLine menghapus baris material dari BOM desain proyek
+func deleteDesignBOMLine(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	line, ok := findDesignBOMLine(c, project)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&line).Error; err != nil {
+		log.Printf("Error deleting BOM line %d: %v", line.RekayasaMaterialID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete BOM line", "details": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
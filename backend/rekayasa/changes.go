@@ -0,0 +1,826 @@
+package rekayasa
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Status engineering change request (ECR).
+// submitted -> under_review -> approved/rejected (ditentukan keputusan reviewer) -> implemented.
+const (
+	ecrSubmitted   = "submitted"
+	ecrUnderReview = "under_review"
+	ecrApproved    = "approved"
+	ecrRejected    = "rejected"
+	ecrImplemented = "implemented"
+)
+
+// Keputusan reviewer ECR
+const (
+	decisionPending  = "pending"
+	decisionApproved = "approved"
+	decisionRejected = "rejected"
+)
+
+// Jenis dampak perubahan: produk jadi, job produksi, atau item inventory
+const (
+	impactProduct   = "product"
+	impactProduksi  = "produksi"
+	impactInventory = "inventory"
+)
+
+// Produksi - Referensi minimal ke tabel 'produksi' untuk daftar dampak ECR dan serah terima ke produksi
+type Produksi struct {
+	ProduksiID    int     `json:"id" gorm:"column:produksi_id;primaryKey;autoIncrement"`
+	Name          string  `json:"name" gorm:"column:name"`
+	Target        int     `json:"target" gorm:"column:target"`
+	Status        string  `json:"status" gorm:"column:status"`
+	StartDate     string  `json:"startDate" gorm:"column:start_date"`
+	EndDate       string  `json:"endDate" gorm:"column:end_date"`
+	Budget        float64 `json:"budget" gorm:"column:budget"`
+	RekayasaID    *int    `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	PersonnelJSON string  `json:"-" gorm:"column:personnel_data"` // Array NIP (format kolom personnel_data modul produksi)
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Job di trash dianggap tidak ada
+}
+
+func (Produksi) TableName() string {
+	return "produksi"
+}
+
+// Inventory - Referensi minimal ke tabel 'inventory' untuk daftar dampak ECR
+type Inventory struct {
+	InventoryID int    `json:"id" gorm:"column:inventory_id;primaryKey"`
+	Name        string `json:"name" gorm:"column:name"`
+	ItemCode    string `json:"itemCode" gorm:"column:itemCode"`
+	Quantity    int    `json:"quantity" gorm:"column:quantity"`
+	Location    string `json:"location" gorm:"column:location"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
+}
+
+func (Inventory) TableName() string {
+	return "inventory"
+}
+
+// ChangeRequest - Engineering change request terhadap produk yang sudah dirilis (tabel 'rekayasa_ecr')
+type ChangeRequest struct {
+	ECRID         int        `json:"id" gorm:"column:ecr_id;primaryKey;autoIncrement"`
+	ECRNumber     string     `json:"ecrNumber" gorm:"column:ecr_number"`
+	RekayasaID    int        `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	Title         string     `json:"title" gorm:"column:title"`
+	Description   string     `json:"description" gorm:"column:description"`
+	Reason        string     `json:"reason" gorm:"column:reason"`
+	Status        string     `json:"status" gorm:"column:status"`
+	RequestedBy   *int       `json:"requested_by" gorm:"column:requested_by"`
+	SubmittedAt   time.Time  `json:"submittedAt" gorm:"column:submitted_at"`
+	DecidedAt     *time.Time `json:"decidedAt" gorm:"column:decided_at"`
+	ImplementedAt *time.Time `json:"implementedAt" gorm:"column:implemented_at"`
+
+	RequesterNIP string        `json:"requester_nip,omitempty" gorm:"-"` // Alternatif requested_by saat input
+	Requester    *Personalia   `json:"requester,omitempty" gorm:"foreignKey:RequestedBy;references:PersonaliaID"`
+	Reviewers    []ECRReviewer `json:"reviewers" gorm:"foreignKey:ECRID;references:ECRID"`
+	Impacts      []ECRImpact   `json:"impacts" gorm:"foreignKey:ECRID;references:ECRID"`
+	Comments     []ECRComment  `json:"comments" gorm:"foreignKey:ECRID;references:ECRID"`
+}
+
+func (ChangeRequest) TableName() string {
+	return "rekayasa_ecr"
+}
+
+// ECRReviewer - Personalia yang ditugaskan meninjau ECR beserta keputusannya
+type ECRReviewer struct {
+	ECRReviewerID int        `json:"id" gorm:"column:ecr_reviewer_id;primaryKey;autoIncrement"`
+	ECRID         int        `json:"ecr_id" gorm:"column:ecr_id"`
+	PersonaliaID  int        `json:"personalia_id" gorm:"column:personalia_id"`
+	Decision      string     `json:"decision" gorm:"column:decision"`
+	Remarks       string     `json:"remarks" gorm:"column:remarks"`
+	DecidedAt     *time.Time `json:"decidedAt" gorm:"column:decided_at"`
+
+	NIP        string      `json:"nip,omitempty" gorm:"-"` // Alternatif personalia_id saat input
+	Personalia *Personalia `json:"personalia,omitempty" gorm:"foreignKey:PersonaliaID;references:PersonaliaID"`
+}
+
+func (ECRReviewer) TableName() string {
+	return "rekayasa_ecr_reviewer"
+}
+
+// ECRComment - Komentar diskusi pada ECR
+type ECRComment struct {
+	CommentID    int       `json:"id" gorm:"column:comment_id;primaryKey;autoIncrement"`
+	ECRID        int       `json:"ecr_id" gorm:"column:ecr_id"`
+	PersonaliaID *int      `json:"personalia_id" gorm:"column:personalia_id"`
+	Body         string    `json:"body" gorm:"column:body"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	NIP    string      `json:"nip,omitempty" gorm:"-"` // Alternatif personalia_id saat input
+	Author *Personalia `json:"author,omitempty" gorm:"foreignKey:PersonaliaID;references:PersonaliaID"`
+}
+
+func (ECRComment) TableName() string {
+	return "rekayasa_ecr_comment"
+}
+
+// ECRImpact - Satu entri daftar dampak ECR. ItemName menyimpan nama produk, atau salinan
+// nama job/item agar tetap terbaca jika job produksi atau item inventory dihapus.
+type ECRImpact struct {
+	ImpactID    int    `json:"id" gorm:"column:impact_id;primaryKey;autoIncrement"`
+	ECRID       int    `json:"ecr_id" gorm:"column:ecr_id"`
+	ImpactType  string `json:"type" gorm:"column:impact_type"`
+	ItemName    string `json:"itemName" gorm:"column:item_name"`
+	ProduksiID  *int   `json:"produksi_id" gorm:"column:produksi_id"`
+	InventoryID *int   `json:"inventory_id" gorm:"column:inventory_id"`
+	Description string `json:"description" gorm:"column:description"`
+
+	Produksi  *Produksi  `json:"produksi,omitempty" gorm:"foreignKey:ProduksiID;references:ProduksiID"`
+	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
+}
+
+func (ECRImpact) TableName() string {
+	return "rekayasa_ecr_impact"
+}
+
+// resolvePersonalia mencari personalia berdasarkan personalia_id atau nip
+func resolvePersonalia(tx *gorm.DB, id int, nip string) (Personalia, string) {
+	var p Personalia
+	switch {
+	case id > 0:
+		if err := tx.First(&p, id).Error; err != nil {
+			return p, fmt.Sprintf("Personalia with ID %d not found", id)
+		}
+	case nip != "":
+		if err := tx.Where("nip = ?", nip).First(&p).Error; err != nil {
+			return p, fmt.Sprintf("Personalia with NIP '%s' not found", nip)
+		}
+	default:
+		return p, "personalia_id or nip is required"
+	}
+	return p, ""
+}
+
+// resolveReviewer memvalidasi reviewer baru: personalia harus ada, bukan pengaju ECR dan belum terdaftar
+func resolveReviewer(tx *gorm.DB, ecr ChangeRequest, r ECRReviewer, existing []ECRReviewer) (ECRReviewer, string) {
+	p, msg := resolvePersonalia(tx, r.PersonaliaID, r.NIP)
+	if msg != "" {
+		return r, msg
+	}
+	if ecr.RequestedBy != nil && *ecr.RequestedBy == p.PersonaliaID {
+		return r, "The requester cannot review their own change request"
+	}
+	for _, e := range existing {
+		if e.PersonaliaID == p.PersonaliaID {
+			return r, fmt.Sprintf("Personalia '%s' is already a reviewer", p.NIP)
+		}
+	}
+	return ECRReviewer{ECRID: ecr.ECRID, PersonaliaID: p.PersonaliaID, Decision: decisionPending}, ""
+}
+
+// resolveImpact memvalidasi entri dampak dan mengisi ItemName dari job produksi/item inventory
+func resolveImpact(tx *gorm.DB, impact *ECRImpact) string {
+	impact.ItemName = strings.TrimSpace(impact.ItemName)
+	switch impact.ImpactType {
+	case impactProduct:
+		impact.ProduksiID, impact.InventoryID = nil, nil
+		if impact.ItemName == "" {
+			return "itemName is required for product impacts"
+		}
+	case impactProduksi:
+		impact.InventoryID = nil
+		var job Produksi
+		if impact.ProduksiID == nil || tx.First(&job, *impact.ProduksiID).Error != nil {
+			return "A valid produksi_id is required for produksi impacts"
+		}
+		impact.ItemName = job.Name
+	case impactInventory:
+		impact.ProduksiID = nil
+		var item Inventory
+		if impact.InventoryID == nil || tx.First(&item, *impact.InventoryID).Error != nil {
+			return "A valid inventory_id is required for inventory impacts"
+		}
+		impact.ItemName = item.Name
+	default:
+		return fmt.Sprintf("Invalid impact type '%s' (product, produksi, inventory)", impact.ImpactType)
+	}
+	return ""
+}
+
+// loadChangeRequests memuat ECR beserta pengaju, reviewer, dampak dan komentar
+func loadChangeRequests(query *gorm.DB) ([]ChangeRequest, error) {
+	var items []ChangeRequest
+	err := query.Preload("Requester").
+		Preload("Reviewers", func(tx *gorm.DB) *gorm.DB { return tx.Order("ecr_reviewer_id") }).Preload("Reviewers.Personalia").
+		Preload("Impacts", func(tx *gorm.DB) *gorm.DB { return tx.Order("impact_type, impact_id") }).
+		Preload("Impacts.Produksi").Preload("Impacts.Inventory").
+		Preload("Comments", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at, comment_id") }).Preload("Comments.Author").
+		Order("ecr_id DESC").Find(&items).Error
+	return items, err
+}
+
+// findChangeRequest mengambil ECR berdasarkan parameter :ecrId milik proyek
+func findChangeRequest(c *gin.Context, project Rekayasa) (ChangeRequest, bool) {
+	ecrID, err := strconv.Atoi(c.Param("ecrId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return ChangeRequest{}, false
+	}
+	items, err := loadChangeRequests(db.Where("rekayasa_id = ? AND ecr_id = ?", project.RekayasaID, ecrID))
+	if err != nil {
+		log.Printf("Error fetching change request %d: %v", ecrID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change request", "details": err.Error()})
+		return ChangeRequest{}, false
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+		return ChangeRequest{}, false
+	}
+	return items[0], true
+}
+
+// respondChangeRequest memuat ulang ECR lalu mengirimkannya
+func respondChangeRequest(c *gin.Context, status, ecrID int) {
+	items, err := loadChangeRequests(db.Where("ecr_id = ?", ecrID))
+	if err != nil || len(items) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change request"})
+		return
+	}
+	c.JSON(status, items[0])
+}
+
+// applyReviewOutcome menetapkan hasil ECR yang sedang direview: rejected jika ada reviewer
+// yang menolak, approved jika seluruh reviewer menyetujui
+func applyReviewOutcome(tx *gorm.DB, ecrID int) error {
+	var reviewers []ECRReviewer
+	if err := tx.Where("ecr_id = ?", ecrID).Find(&reviewers).Error; err != nil {
+		return err
+	}
+	approved := 0
+	for _, r := range reviewers {
+		if r.Decision == decisionRejected {
+			return tx.Model(&ChangeRequest{}).Where("ecr_id = ?", ecrID).
+				Updates(map[string]interface{}{"status": ecrRejected, "decided_at": time.Now()}).Error
+		}
+		if r.Decision == decisionApproved {
+			approved++
+		}
+	}
+	if len(reviewers) > 0 && approved == len(reviewers) {
+		return tx.Model(&ChangeRequest{}).Where("ecr_id = ?", ecrID).
+			Updates(map[string]interface{}{"status": ecrApproved, "decided_at": time.Now()}).Error
+	}
+	return nil
+}
+
+// getAllChangeRequests menampilkan ECR lintas proyek.
+// Filter opsional: ?status=, ?reviewer= (NIP, hanya yang menunggu keputusannya), ?produksi_id=, ?inventory_id=
+func getAllChangeRequests(c *gin.Context) {
+	query := db.Model(&ChangeRequest{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if nip := c.Query("reviewer"); nip != "" {
+		query = query.Where("status = ? AND ecr_id IN (?)", ecrUnderReview,
+			db.Table("rekayasa_ecr_reviewer r").Select("r.ecr_id").
+				Joins("JOIN personalia p ON p.personalia_id = r.personalia_id").
+				Where("p.nip = ? AND r.decision = ?", nip, decisionPending))
+	}
+	if produksiID := c.Query("produksi_id"); produksiID != "" {
+		query = query.Where("ecr_id IN (?)", db.Model(&ECRImpact{}).Select("ecr_id").Where("produksi_id = ?", produksiID))
+	}
+	if inventoryID := c.Query("inventory_id"); inventoryID != "" {
+		query = query.Where("ecr_id IN (?)", db.Model(&ECRImpact{}).Select("ecr_id").Where("inventory_id = ?", inventoryID))
+	}
+
+	items, err := loadChangeRequests(query)
+	if err != nil {
+		log.Printf("Error fetching change requests: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests", "details": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []ChangeRequest{}
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// getChangeRequests menampilkan ECR milik proyek. Filter opsional: ?status=
+func getChangeRequests(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	query := db.Where("rekayasa_id = ?", project.RekayasaID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	items, err := loadChangeRequests(query)
+	if err != nil {
+		log.Printf("Error fetching change requests for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests", "details": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []ChangeRequest{}
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func getChangeRequest(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ecr)
+}
+
+// createChangeRequest mengajukan ECR baru (status submitted). Reviewer dan daftar dampak
+// boleh langsung disertakan lewat field reviewers dan impacts.
+func createChangeRequest(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var req ChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || strings.TrimSpace(req.Description) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and description are required"})
+		return
+	}
+	requesterID := 0
+	if req.RequestedBy != nil {
+		requesterID = *req.RequestedBy
+	}
+	requester, msg := resolvePersonalia(db, requesterID, req.RequesterNIP)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Requester: " + msg})
+		return
+	}
+
+	ecr := ChangeRequest{
+		RekayasaID:  project.RekayasaID,
+		Title:       req.Title,
+		Description: req.Description,
+		Reason:      req.Reason,
+		Status:      ecrSubmitted,
+		RequestedBy: &requester.PersonaliaID,
+		SubmittedAt: time.Now(),
+	}
+	reviewers := make([]ECRReviewer, 0, len(req.Reviewers))
+	for _, r := range req.Reviewers {
+		resolved, msg := resolveReviewer(db, ecr, r, reviewers)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		reviewers = append(reviewers, resolved)
+	}
+	impacts := req.Impacts
+	for i := range impacts {
+		if msg := resolveImpact(db, &impacts[i]); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Requester", "Reviewers", "Impacts", "Comments").Create(&ecr).Error; err != nil {
+			return err
+		}
+		ecr.ECRNumber = fmt.Sprintf("ECR-%04d", ecr.ECRID)
+		if err := tx.Model(&ecr).Update("ecr_number", ecr.ECRNumber).Error; err != nil {
+			return err
+		}
+		for i := range reviewers {
+			reviewers[i].ECRID = ecr.ECRID
+			if err := tx.Omit("Personalia").Create(&reviewers[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range impacts {
+			impacts[i].ImpactID = 0
+			impacts[i].ECRID = ecr.ECRID
+			if err := tx.Omit("Produksi", "Inventory").Create(&impacts[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error creating change request for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create change request", "details": err.Error()})
+		return
+	}
+	respondChangeRequest(c, http.StatusCreated, ecr.ECRID)
+}
+
+// updateChangeRequest mengubah judul, deskripsi dan alasan ECR selama belum masuk review
+func updateChangeRequest(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	if ecr.Status != ecrSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Change request is %s and can no longer be edited", ecr.Status)})
+		return
+	}
+
+	var req ChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || strings.TrimSpace(req.Description) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and description are required"})
+		return
+	}
+
+	if err := db.Model(&ChangeRequest{}).Where("ecr_id = ?", ecr.ECRID).Updates(map[string]interface{}{
+		"title": req.Title, "description": req.Description, "reason": req.Reason,
+	}).Error; err != nil {
+		log.Printf("Error updating change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+	respondChangeRequest(c, http.StatusOK, ecr.ECRID)
+}
+
+// deleteECRChildren menghapus reviewer, komentar dan dampak milik ECR yang dipilih query
+func deleteECRChildren(tx *gorm.DB, ecrIDs interface{}) error {
+	for _, model := range []interface{}{&ECRReviewer{}, &ECRComment{}, &ECRImpact{}} {
+		if err := tx.Where("ecr_id IN (?)", ecrIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteChangeRequest menghapus ECR yang belum diputuskan (submitted) atau ditolak.
+// ECR approved/implemented disimpan sebagai riwayat perubahan desain.
+func deleteChangeRequest(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	if ecr.Status != ecrSubmitted && ecr.Status != ecrRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Change request is %s and cannot be deleted", ecr.Status)})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteECRChildren(tx, []int{ecr.ECRID}); err != nil {
+			return err
+		}
+		return tx.Delete(&ChangeRequest{}, ecr.ECRID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete change request"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// updateChangeRequestStatus menjalankan transisi manual ECR:
+// submitted -> under_review (minimal satu reviewer), approved -> implemented,
+// rejected -> submitted (diajukan ulang; keputusan reviewer direset).
+// Status approved/rejected hanya ditetapkan dari keputusan reviewer.
+func updateChangeRequestStatus(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{"status": req.Status}
+	resetReviews := false
+	switch {
+	case ecr.Status == ecrSubmitted && req.Status == ecrUnderReview:
+		if len(ecr.Reviewers) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Assign at least one reviewer before starting the review"})
+			return
+		}
+	case ecr.Status == ecrApproved && req.Status == ecrImplemented:
+		updates["implemented_at"] = time.Now()
+	case ecr.Status == ecrRejected && req.Status == ecrSubmitted:
+		updates["decided_at"] = nil
+		updates["submitted_at"] = time.Now()
+		resetReviews = true
+	case req.Status == ecrApproved || req.Status == ecrRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Approval and rejection are recorded through reviewer decisions"})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot change status from %s to %s", ecr.Status, req.Status)})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if resetReviews {
+			if err := tx.Model(&ECRReviewer{}).Where("ecr_id = ?", ecr.ECRID).Updates(map[string]interface{}{
+				"decision": decisionPending, "decided_at": nil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&ChangeRequest{}).Where("ecr_id = ?", ecr.ECRID).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("Error updating status of change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request status"})
+		return
+	}
+	log.Printf("Change request %s moved from %s to %s", ecr.ECRNumber, ecr.Status, req.Status)
+	respondChangeRequest(c, http.StatusOK, ecr.ECRID)
+}
+
+// findReviewer mengambil reviewer berdasarkan parameter :reviewerId pada ECR
+func findReviewer(c *gin.Context, ecr ChangeRequest) (ECRReviewer, bool) {
+	reviewerID, err := strconv.Atoi(c.Param("reviewerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reviewer ID"})
+		return ECRReviewer{}, false
+	}
+	for _, r := range ecr.Reviewers {
+		if r.ECRReviewerID == reviewerID {
+			return r, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Reviewer not found"})
+	return ECRReviewer{}, false
+}
+
+// addReviewer menugaskan personalia (personalia_id atau nip) sebagai reviewer ECR
+func addReviewer(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	if ecr.Status != ecrSubmitted && ecr.Status != ecrUnderReview {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Reviewers cannot be added to a %s change request", ecr.Status)})
+		return
+	}
+
+	var req ECRReviewer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	reviewer, msg := resolveReviewer(db, ecr, req, ecr.Reviewers)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := db.Omit("Personalia").Create(&reviewer).Error; err != nil {
+		log.Printf("Error adding reviewer to change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reviewer"})
+		return
+	}
+	respondChangeRequest(c, http.StatusCreated, ecr.ECRID)
+}
+
+// removeReviewer melepas reviewer yang belum memberi keputusan. Jika ECR sedang direview,
+// hasil review dihitung ulang dari reviewer yang tersisa.
+func removeReviewer(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	reviewer, ok := findReviewer(c, ecr)
+	if !ok {
+		return
+	}
+	if reviewer.Decision != decisionPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reviewers who already decided cannot be removed"})
+		return
+	}
+	if ecr.Status == ecrUnderReview && len(ecr.Reviewers) == 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A change request under review must keep at least one reviewer"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ECRReviewer{}, reviewer.ECRReviewerID).Error; err != nil {
+			return err
+		}
+		if ecr.Status == ecrUnderReview {
+			return applyReviewOutcome(tx, ecr.ECRID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error removing reviewer %d: %v", reviewer.ECRReviewerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reviewer"})
+		return
+	}
+	respondChangeRequest(c, http.StatusOK, ecr.ECRID)
+}
+
+// decideReview mencatat keputusan reviewer (approved/rejected) pada ECR yang sedang direview.
+// Satu penolakan membuat ECR rejected; persetujuan seluruh reviewer membuat ECR approved.
+func decideReview(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	reviewer, ok := findReviewer(c, ecr)
+	if !ok {
+		return
+	}
+	if ecr.Status != ecrUnderReview {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Change request is %s, not under review", ecr.Status)})
+		return
+	}
+
+	var req struct {
+		Decision string `json:"decision"`
+		Remarks  string `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if req.Decision != decisionApproved && req.Decision != decisionRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Decision must be approved or rejected"})
+		return
+	}
+	if req.Decision == decisionRejected && strings.TrimSpace(req.Remarks) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Remarks are required when rejecting a change request"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ECRReviewer{}).Where("ecr_reviewer_id = ?", reviewer.ECRReviewerID).Updates(map[string]interface{}{
+			"decision": req.Decision, "remarks": req.Remarks, "decided_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return applyReviewOutcome(tx, ecr.ECRID)
+	})
+	if err != nil {
+		log.Printf("Error recording decision of reviewer %d: %v", reviewer.ECRReviewerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record decision"})
+		return
+	}
+	respondChangeRequest(c, http.StatusOK, ecr.ECRID)
+}
+
+// addComment menambahkan komentar diskusi (penulis via personalia_id atau nip)
+func addComment(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+
+	var req ECRComment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is required"})
+		return
+	}
+	authorID := 0
+	if req.PersonaliaID != nil {
+		authorID = *req.PersonaliaID
+	}
+	author, msg := resolvePersonalia(db, authorID, req.NIP)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Author: " + msg})
+		return
+	}
+
+	comment := ECRComment{ECRID: ecr.ECRID, PersonaliaID: &author.PersonaliaID, Body: req.Body, CreatedAt: time.Now()}
+	if err := db.Omit("Author").Create(&comment).Error; err != nil {
+		log.Printf("Error adding comment to change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+	respondChangeRequest(c, http.StatusCreated, ecr.ECRID)
+}
+
+// addImpact menambahkan entri dampak (product, produksi atau inventory) pada ECR yang belum diimplementasikan
+func addImpact(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	if ecr.Status == ecrImplemented {
+		c.JSON(http.StatusConflict, gin.H{"error": "Impacts of an implemented change request cannot be changed"})
+		return
+	}
+
+	var impact ECRImpact
+	if err := c.ShouldBindJSON(&impact); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := resolveImpact(db, &impact); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	impact.ImpactID = 0
+	impact.ECRID = ecr.ECRID
+	if err := db.Omit("Produksi", "Inventory").Create(&impact).Error; err != nil {
+		log.Printf("Error adding impact to change request %d: %v", ecr.ECRID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add impact"})
+		return
+	}
+	respondChangeRequest(c, http.StatusCreated, ecr.ECRID)
+}
+
+// removeImpact menghapus entri dampak dari ECR yang belum diimplementasikan
+func removeImpact(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	ecr, ok := findChangeRequest(c, project)
+	if !ok {
+		return
+	}
+	if ecr.Status == ecrImplemented {
+		c.JSON(http.StatusConflict, gin.H{"error": "Impacts of an implemented change request cannot be changed"})
+		return
+	}
+	impactID, err := strconv.Atoi(c.Param("impactId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid impact ID"})
+		return
+	}
+
+	result := db.Where("ecr_id = ?", ecr.ECRID).Delete(&ECRImpact{}, impactID)
+	if result.Error != nil {
+		log.Printf("Error removing impact %d: %v", impactID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove impact"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impact not found"})
+		return
+	}
+	respondChangeRequest(c, http.StatusOK, ecr.ECRID)
+}
+
+// deleteProjectChangeRequests menghapus seluruh ECR proyek; dipanggil di dalam transaksi deleteProject
+func deleteProjectChangeRequests(tx *gorm.DB, rekayasaID int) error {
+	ids := tx.Model(&ChangeRequest{}).Select("ecr_id").Where("rekayasa_id = ?", rekayasaID)
+	if err := deleteECRChildren(tx, ids); err != nil {
+		return err
+	}
+	return tx.Where("rekayasa_id = ?", rekayasaID).Delete(&ChangeRequest{}).Error
+}
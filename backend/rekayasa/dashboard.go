@@ -0,0 +1,296 @@
+package rekayasa
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// Tingkat risiko jadwal proyek pada dashboard
+const (
+	riskDone    = "done"
+	riskOverdue = "overdue"
+	riskBehind  = "behind"
+	riskDueSoon = "due_soon"
+	riskOnTrack = "on_track"
+)
+
+// ProjectSchedule - Status jadwal satu proyek terhadap rencana linear startDate -> deadline
+type ProjectSchedule struct {
+	RekayasaID       int    `json:"id"`
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	StartDate        string `json:"startDate,omitempty"`
+	Deadline         string `json:"deadline"`
+	DaysRemaining    int    `json:"daysRemaining"` // Negatif jika deadline sudah lewat
+	Progress         int    `json:"progress"`
+	ExpectedProgress *int   `json:"expectedProgress"` // nil jika startDate belum diisi
+	Variance         *int   `json:"variance"`         // progress - expectedProgress
+	BehindSchedule   bool   `json:"behindSchedule"`
+	Overdue          bool   `json:"overdue"`
+	Risk             string `json:"risk"`
+}
+
+// WorkloadProject - Satu proyek aktif pada rekap beban kerja personel
+type WorkloadProject struct {
+	RekayasaID     int    `json:"id"`
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	DaysRemaining  int    `json:"daysRemaining"`
+	BehindSchedule bool   `json:"behindSchedule"`
+}
+
+// Workload - Beban kerja satu personel di seluruh proyek rekayasa yang masih berjalan
+type Workload struct {
+	PersonaliaID int               `json:"personalia_id"`
+	NIP          string            `json:"nip"`
+	Jabatan      string            `json:"jabatan"`
+	Divisi       string            `json:"divisi"`
+	ProjectCount int               `json:"projectCount"`
+	LeadCount    int               `json:"leadCount"`
+	OpenTasks    int               `json:"openTasks"`
+	OverdueTasks int               `json:"overdueTasks"`
+	OpenWeight   float64           `json:"openWeight"` // Total bobot task yang belum selesai
+	Projects     []WorkloadProject `json:"projects"`
+}
+
+// parseInputDate memvalidasi tanggal input dengan format ketat YYYY-MM-DD
+func parseInputDate(field, value string) (time.Time, string) {
+	t, err := time.Parse(dateutil.Layout, value)
+	if err != nil {
+		return t, fmt.Sprintf("Invalid %s '%s', use YYYY-MM-DD", field, value)
+	}
+	return t, ""
+}
+
+// validateProjectDates memeriksa startDate (opsional) dan deadline proyek. Untuk proyek yang
+// sudah ada, deadline tidak boleh lebih awal dari dueDate task atau milestone.
+func validateProjectDates(tx *gorm.DB, rekayasaID int, startDate, deadline string) string {
+	end, msg := parseInputDate("deadline", deadline)
+	if msg != "" {
+		return msg
+	}
+	if startDate != "" {
+		start, msg := parseInputDate("startDate", startDate)
+		if msg != "" {
+			return msg
+		}
+		if start.After(end) {
+			return fmt.Sprintf("startDate (%s) cannot be after the deadline (%s)", startDate, deadline)
+		}
+	}
+	if rekayasaID == 0 {
+		return ""
+	}
+
+	// due_date disimpan sebagai string YYYY-MM-DD sehingga MAX leksikal sama dengan tanggal terakhir
+	for _, check := range []struct{ table, label string }{{"rekayasa_task", "task"}, {"rekayasa_milestone", "milestone"}} {
+		var latest *string
+		if err := tx.Table(check.table).Where("rekayasa_id = ? AND due_date <> ''", rekayasaID).Select("MAX(due_date)").Scan(&latest).Error; err != nil {
+			return "Failed to check due dates: " + err.Error()
+		}
+		if latest == nil {
+			continue
+		}
+		if due, ok := dateutil.Parse(*latest); ok && due.After(end) {
+			return fmt.Sprintf("deadline (%s) cannot be before the latest %s due date (%s)", deadline, check.label, *latest)
+		}
+	}
+	return ""
+}
+
+// expectedProgress menghitung progress yang seharusnya tercapai pada asOf
+// jika pekerjaan berjalan linear dari start sampai deadline
+func expectedProgress(start, deadline, asOf time.Time) int {
+	if !asOf.After(start) {
+		return 0
+	}
+	if !asOf.Before(deadline) {
+		return 100
+	}
+	return int(math.Round(float64(dateutil.DaysBetween(start, asOf)) / float64(dateutil.DaysBetween(start, deadline)) * 100))
+}
+
+// scheduleOf menyusun status jadwal proyek. tolerance adalah selisih poin progress yang masih
+// dianggap sesuai jadwal; dueSoon adalah batas hari menuju deadline untuk risiko due_soon.
+func scheduleOf(p Rekayasa, asOf time.Time, tolerance, dueSoon int) ProjectSchedule {
+	s := ProjectSchedule{
+		RekayasaID: p.RekayasaID,
+		Name:       p.Name,
+		Status:     p.Status,
+		Deadline:   dateutil.Format(p.Deadline),
+		Progress:   p.Progress,
+	}
+	if p.StartDate != nil {
+		s.StartDate = dateutil.Format(*p.StartDate)
+	}
+	deadline, ok := dateutil.Parse(p.Deadline)
+	done := p.Status == projectSelesai
+	if ok {
+		s.DaysRemaining = dateutil.DaysBetween(asOf, deadline)
+		s.Overdue = !done && s.DaysRemaining < 0
+		if start, ok := dateutil.Parse(s.StartDate); ok {
+			expected := expectedProgress(start, deadline, asOf)
+			variance := p.Progress - expected
+			s.ExpectedProgress, s.Variance = &expected, &variance
+			s.BehindSchedule = !done && variance < -tolerance
+		}
+	}
+
+	switch {
+	case done:
+		s.Risk = riskDone
+	case s.Overdue:
+		s.Risk = riskOverdue
+	case s.BehindSchedule:
+		s.Risk = riskBehind
+	case ok && s.DaysRemaining <= dueSoon:
+		s.Risk = riskDueSoon
+	default:
+		s.Risk = riskOnTrack
+	}
+	return s
+}
+
+// queryInt membaca parameter query bilangan bulat non-negatif dengan nilai default
+func queryInt(c *gin.Context, name string, fallback int) int {
+	if v, err := strconv.Atoi(c.Query(name)); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
+}
+
+// buildWorkload merekap anggota tim dan task terbuka per personel untuk proyek aktif
+func buildWorkload(schedules map[int]ProjectSchedule, asOf time.Time) ([]Workload, error) {
+	ids := make([]int, 0, len(schedules))
+	for id := range schedules {
+		ids = append(ids, id)
+	}
+	teams, err := loadTeams(ids)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	if len(ids) > 0 {
+		if err := db.Where("rekayasa_id IN ? AND status <> ? AND owner_id IS NOT NULL", ids, taskSelesai).Find(&tasks).Error; err != nil {
+			return nil, err
+		}
+	}
+	markOverdue(tasks, asOf)
+
+	byPerson := make(map[int]*Workload)
+	for _, id := range ids {
+		for _, m := range teams[id] {
+			w := byPerson[m.PersonaliaID]
+			if w == nil {
+				w = &Workload{PersonaliaID: m.PersonaliaID, Projects: []WorkloadProject{}}
+				if m.Personalia != nil {
+					w.NIP, w.Jabatan, w.Divisi = m.Personalia.NIP, m.Personalia.Jabatan, m.Personalia.Divisi
+				}
+				byPerson[m.PersonaliaID] = w
+			}
+			s := schedules[id]
+			w.ProjectCount++
+			if m.Role == roleLead {
+				w.LeadCount++
+			}
+			w.Projects = append(w.Projects, WorkloadProject{
+				RekayasaID: id, Name: s.Name, Role: m.Role, DaysRemaining: s.DaysRemaining, BehindSchedule: s.BehindSchedule,
+			})
+		}
+	}
+	for _, t := range tasks {
+		w := byPerson[*t.OwnerID]
+		if w == nil {
+			continue // Owner sudah keluar dari tim; task tetap terlihat di proyeknya
+		}
+		w.OpenTasks++
+		w.OpenWeight += t.Weight
+		if t.Overdue {
+			w.OverdueTasks++
+		}
+	}
+
+	result := make([]Workload, 0, len(byPerson))
+	for _, w := range byPerson {
+		sort.Slice(w.Projects, func(i, j int) bool { return w.Projects[i].DaysRemaining < w.Projects[j].DaysRemaining })
+		result = append(result, *w)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ProjectCount != result[j].ProjectCount {
+			return result[i].ProjectCount > result[j].ProjectCount
+		}
+		if result[i].OpenTasks != result[j].OpenTasks {
+			return result[i].OpenTasks > result[j].OpenTasks
+		}
+		return result[i].NIP < result[j].NIP
+	})
+	return result, nil
+}
+
+// getDashboard menampilkan proyek berdasarkan sisa hari menuju deadline, progress aktual
+// terhadap rencana linear, proyek yang tertinggal jadwal, dan beban kerja per personel.
+// Parameter opsional: ?tolerance= (poin progress, default 10), ?due_soon_days= (default 14),
+// ?include_done=true untuk ikut menampilkan proyek berstatus Selesai.
+func getDashboard(c *gin.Context) {
+	tolerance := queryInt(c, "tolerance", 10)
+	dueSoon := queryInt(c, "due_soon_days", 14)
+
+	var projects []Rekayasa
+	if err := db.Find(&projects).Error; err != nil {
+		log.Printf("Error fetching projects for dashboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects", "details": err.Error()})
+		return
+	}
+
+	asOf := dateutil.Today()
+	list := []ProjectSchedule{}
+	active := make(map[int]ProjectSchedule)
+	summary := map[string]int{"projects": 0, riskOverdue: 0, riskBehind: 0, riskDueSoon: 0, riskOnTrack: 0, riskDone: 0, "noStartDate": 0}
+	for _, p := range projects {
+		s := scheduleOf(p, asOf, tolerance, dueSoon)
+		summary[s.Risk]++
+		if s.Risk == riskDone {
+			if c.Query("include_done") != "true" {
+				continue
+			}
+		} else {
+			active[p.RekayasaID] = s
+			if s.ExpectedProgress == nil {
+				summary["noStartDate"]++
+			}
+		}
+		summary["projects"]++
+		list = append(list, s)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if (list[i].Risk == riskDone) != (list[j].Risk == riskDone) {
+			return list[j].Risk == riskDone
+		}
+		return list[i].DaysRemaining < list[j].DaysRemaining
+	})
+
+	workload, err := buildWorkload(active, asOf)
+	if err != nil {
+		log.Printf("Error computing rekayasa workload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute workload", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"asOf":      asOf.Format(dateutil.Layout),
+		"tolerance": tolerance,
+		"summary":   summary,
+		"projects":  list,
+		"workload":  workload,
+	})
+}
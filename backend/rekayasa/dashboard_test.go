@@ -0,0 +1,33 @@
+package rekayasa
+
+import (
+	"testing"
+	"time"
+
+	"kai-backend/dateutil"
+)
+
+func TestExpectedProgress(t *testing.T) {
+	tests := []struct {
+		start, deadline, asOf string
+		want                  int
+	}{
+		{"2024-03-01", "2024-03-11", "2024-02-20", 0},
+		{"2024-03-01", "2024-03-11", "2024-03-01", 0},
+		{"2024-03-01", "2024-03-11", "2024-03-02", 10},
+		{"2024-03-01", "2024-03-11", "2024-03-06", 50},
+		{"2024-03-01", "2024-03-04", "2024-03-03", 67},
+		{"2024-03-01", "2024-03-11", "2024-03-11", 100},
+		{"2024-03-01", "2024-03-11", "2024-04-01", 100},
+		{"2024-03-01", "2024-03-01", "2024-03-01", 0},
+	}
+	parse := func(v string) time.Time {
+		d, _ := time.Parse(dateutil.Layout, v)
+		return d
+	}
+	for _, tt := range tests {
+		if got := expectedProgress(parse(tt.start), parse(tt.deadline), parse(tt.asOf)); got != tt.want {
+			t.Errorf("expectedProgress(%s, %s, %s) = %d, want %d", tt.start, tt.deadline, tt.asOf, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,719 @@
+package rekayasa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"kai-backend/dberr"
+	"kai-backend/upload"
+)
+
+// Status revisi dokumen engineering
+const (
+	docDraft    = "draft"
+	docReview   = "review"
+	docReleased = "released"
+	docObsolete = "obsolete"
+)
+
+// docTransitions - Perubahan status revisi yang diizinkan
+var docTransitions = map[string][]string{
+	docDraft:    {docReview, docObsolete},
+	docReview:   {docDraft, docReleased, docObsolete},
+	docReleased: {docObsolete},
+}
+
+var validDocTypes = map[string]bool{"drawing": true, "specification": true, "test_procedure": true, "other": true}
+
+// Document - Dokumen engineering (gambar, spesifikasi, prosedur uji) milik proyek rekayasa
+type Document struct {
+	DocumentID int       `json:"id" gorm:"column:document_id;primaryKey;autoIncrement"`
+	RekayasaID int       `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	DocNumber  string    `json:"docNumber" gorm:"column:doc_number"`
+	Title      string    `json:"title" gorm:"column:title"`
+	DocType    string    `json:"docType" gorm:"column:doc_type"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	Revisions []DocumentRevision `json:"revisions" gorm:"foreignKey:DocumentID;references:DocumentID"`
+	Current   *DocumentRevision  `json:"current" gorm:"-"` // Revisi released yang berlaku, nil jika belum ada
+}
+
+func (Document) TableName() string {
+	return "rekayasa_document"
+}
+
+// DocumentRevision - Satu revisi file dokumen. File disimpan di disk lokal di bawah
+// REKAYASA_DOCUMENT_ROOT; StoredPath relatif terhadap root tersebut.
+type DocumentRevision struct {
+	RevisionID  int        `json:"id" gorm:"column:revision_id;primaryKey;autoIncrement"`
+	DocumentID  int        `json:"document_id" gorm:"column:document_id"`
+	Revision    string     `json:"revision" gorm:"column:revision"` // A, B, ..., Z, AA, ...
+	Status      string     `json:"status" gorm:"column:status"`
+	FileName    string     `json:"fileName" gorm:"column:file_name"`
+	StoredPath  string     `json:"-" gorm:"column:stored_path"`
+	ContentType string     `json:"contentType" gorm:"column:content_type"`
+	Size        int64      `json:"size" gorm:"column:size"`
+	Checksum    string     `json:"checksum" gorm:"column:checksum"` // SHA-256 (hex)
+	UploadedBy  *int       `json:"uploaded_by" gorm:"column:uploaded_by"`
+	UploadedAt  time.Time  `json:"uploadedAt" gorm:"column:uploaded_at"`
+	ReleasedAt  *time.Time `json:"releasedAt" gorm:"column:released_at"`
+	Notes       string     `json:"notes" gorm:"column:notes"`
+
+	Uploader *Personalia `json:"uploader,omitempty" gorm:"foreignKey:UploadedBy;references:PersonaliaID"`
+}
+
+func (DocumentRevision) TableName() string {
+	return "rekayasa_document_revision"
+}
+
+// documentRoot mengembalikan direktori penyimpanan dokumen (env REKAYASA_DOCUMENT_ROOT)
+func documentRoot() string {
+	if root := os.Getenv("REKAYASA_DOCUMENT_ROOT"); root != "" {
+		return root
+	}
+	return filepath.Join("storage", "rekayasa")
+}
+
+// maxDocumentBytes mengembalikan batas ukuran upload (env REKAYASA_DOCUMENT_MAX_MB, default 50 MB)
+func maxDocumentBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("REKAYASA_DOCUMENT_MAX_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 50 << 20
+}
+
+// nextRevision menghitung huruf revisi berikutnya: "" -> A, A -> B, Z -> AA, AZ -> BA
+func nextRevision(current string) string {
+	if current == "" {
+		return "A"
+	}
+	letters := []byte(current)
+	for i := len(letters) - 1; i >= 0; i-- {
+		if letters[i] < 'Z' {
+			letters[i]++
+			return string(letters)
+		}
+		letters[i] = 'A'
+	}
+	return "A" + string(letters)
+}
+
+// storeRevisionFile menyimpan file upload ke <root>/<rekayasa_id>/<document_id>/ dan
+// menghitung checksum SHA-256 sambil menulis. Nama file di disk unik per upload sehingga
+// upload yang gagal hanya menghapus file miliknya sendiri; huruf revisi diisi pemanggil.
+func storeRevisionFile(fh *multipart.FileHeader, doc Document) (DocumentRevision, error) {
+	rev := DocumentRevision{DocumentID: doc.DocumentID, FileName: filepath.Base(fh.Filename)}
+
+	src, err := fh.Open()
+	if err != nil {
+		return rev, err
+	}
+	defer src.Close()
+
+	relDir := filepath.Join(strconv.Itoa(doc.RekayasaID), strconv.Itoa(doc.DocumentID))
+	dir := filepath.Join(documentRoot(), relDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return rev, err
+	}
+	dst, err := os.CreateTemp(dir, "*_"+upload.SafeFileName(fh.Filename, "file"))
+	if err != nil {
+		return rev, err
+	}
+	rev.StoredPath = filepath.Join(relDir, filepath.Base(dst.Name()))
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(dst, hash), src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return rev, err
+	}
+	rev.Size = size
+	rev.Checksum = hex.EncodeToString(hash.Sum(nil))
+	rev.ContentType = fh.Header.Get("Content-Type")
+	if rev.ContentType == "" || rev.ContentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(fh.Filename)); byExt != "" {
+			rev.ContentType = byExt
+		}
+	}
+	return rev, nil
+}
+
+// removeRevisionFile menghapus file revisi dari disk; kegagalan hanya dicatat di log
+func removeRevisionFile(rev DocumentRevision) {
+	if rev.StoredPath == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(documentRoot(), rev.StoredPath)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing document file %s: %v", rev.StoredPath, err)
+	}
+}
+
+// fileChecksum menghitung SHA-256 file di disk
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// formUploader membaca pengunggah dari field form uploaded_by (personalia_id) atau nip
+func formUploader(c *gin.Context) (*int, string) {
+	var p Personalia
+	switch {
+	case c.PostForm("uploaded_by") != "":
+		id, err := strconv.Atoi(c.PostForm("uploaded_by"))
+		if err != nil || db.First(&p, id).Error != nil {
+			return nil, fmt.Sprintf("Personalia with ID '%s' not found", c.PostForm("uploaded_by"))
+		}
+	case c.PostForm("nip") != "":
+		if err := db.Where("nip = ?", c.PostForm("nip")).First(&p).Error; err != nil {
+			return nil, fmt.Sprintf("Personalia with NIP '%s' not found", c.PostForm("nip"))
+		}
+	default:
+		return nil, "Uploader is required (uploaded_by or nip)"
+	}
+	return &p.PersonaliaID, ""
+}
+
+// formFile membaca file upload dengan batas ukuran
+func formFile(c *gin.Context) (*multipart.FileHeader, int, string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentBytes())
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d MB limit", maxDocumentBytes()>>20)
+		}
+		return nil, http.StatusBadRequest, "File is required (multipart field 'file')"
+	}
+	return fh, 0, ""
+}
+
+// setCurrent mengisi revisi released yang berlaku pada dokumen
+func setCurrent(doc *Document) {
+	doc.Current = nil
+	for i := range doc.Revisions {
+		if doc.Revisions[i].Status == docReleased {
+			doc.Current = &doc.Revisions[i]
+			return
+		}
+	}
+}
+
+// loadDocuments memuat dokumen beserta revisi (terbaru lebih dulu) dan pengunggahnya
+func loadDocuments(query *gorm.DB) ([]Document, error) {
+	var docs []Document
+	err := query.Preload("Revisions", func(tx *gorm.DB) *gorm.DB { return tx.Order("revision_id DESC") }).
+		Preload("Revisions.Uploader").Order("doc_number, document_id").Find(&docs).Error
+	for i := range docs {
+		setCurrent(&docs[i])
+	}
+	return docs, err
+}
+
+// findDocument mengambil dokumen berdasarkan parameter :docId milik proyek
+func findDocument(c *gin.Context, project Rekayasa) (Document, bool) {
+	docID, err := strconv.Atoi(c.Param("docId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return Document{}, false
+	}
+	docs, err := loadDocuments(db.Where("rekayasa_id = ? AND document_id = ?", project.RekayasaID, docID))
+	if err != nil {
+		log.Printf("Error fetching document %d: %v", docID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document", "details": err.Error()})
+		return Document{}, false
+	}
+	if len(docs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return Document{}, false
+	}
+	return docs[0], true
+}
+
+// findRevision mengambil revisi dokumen berdasarkan huruf revisi pada parameter :revision
+func findRevision(c *gin.Context, doc Document) (DocumentRevision, bool) {
+	letter := strings.ToUpper(c.Param("revision"))
+	for _, rev := range doc.Revisions {
+		if rev.Revision == letter {
+			return rev, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Revision '%s' not found", letter)})
+	return DocumentRevision{}, false
+}
+
+// respondDocument memuat ulang dokumen lalu mengirimkannya
+func respondDocument(c *gin.Context, status, documentID int) {
+	docs, err := loadDocuments(db.Where("document_id = ?", documentID))
+	if err != nil || len(docs) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document"})
+		return
+	}
+	c.JSON(status, docs[0])
+}
+
+// docNumberAvailable memastikan nomor dokumen belum dipakai dokumen lain
+func docNumberAvailable(c *gin.Context, number string, exceptID int) bool {
+	var count int64
+	if err := db.Model(&Document{}).Where("doc_number = ? AND document_id <> ?", number, exceptID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check document number", "details": err.Error()})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Document number '%s' is already used", number)})
+		return false
+	}
+	return true
+}
+
+// getDocuments menampilkan dokumen proyek beserta seluruh revisinya. Filter opsional: ?docType=
+func getDocuments(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	query := db.Where("rekayasa_id = ?", project.RekayasaID)
+	if docType := c.Query("docType"); docType != "" {
+		query = query.Where("doc_type = ?", docType)
+	}
+	docs, err := loadDocuments(query)
+	if err != nil {
+		log.Printf("Error fetching documents for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents", "details": err.Error()})
+		return
+	}
+	if docs == nil {
+		docs = []Document{}
+	}
+	c.JSON(http.StatusOK, docs)
+}
+
+func getDocument(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// createDocument membuat dokumen baru dari upload multipart (file, title, docType, docNumber,
+// uploaded_by/nip, notes). File pertama menjadi revisi A berstatus draft.
+func createDocument(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	fh, status, msg := formFile(c)
+	if msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	doc := Document{
+		RekayasaID: project.RekayasaID,
+		DocNumber:  strings.TrimSpace(c.PostForm("docNumber")),
+		Title:      strings.TrimSpace(c.PostForm("title")),
+		DocType:    c.DefaultPostForm("docType", "other"),
+		CreatedAt:  time.Now(),
+	}
+	if doc.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		return
+	}
+	if !validDocTypes[doc.DocType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid docType '%s' (drawing, specification, test_procedure, other)", doc.DocType)})
+		return
+	}
+	if doc.DocNumber != "" && !docNumberAvailable(c, doc.DocNumber, 0) {
+		return
+	}
+	uploader, msg := formUploader(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var stored DocumentRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Revisions").Create(&doc).Error; err != nil {
+			return err
+		}
+		if doc.DocNumber == "" {
+			doc.DocNumber = fmt.Sprintf("RKY-%03d-%04d", project.RekayasaID, doc.DocumentID)
+			if err := tx.Model(&doc).Update("doc_number", doc.DocNumber).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		if stored, err = storeRevisionFile(fh, doc); err != nil {
+			return err
+		}
+		stored.Revision = "A"
+		stored.Status = docDraft
+		stored.UploadedBy = uploader
+		stored.UploadedAt = time.Now()
+		stored.Notes = c.PostForm("notes")
+		return tx.Create(&stored).Error
+	})
+	if err != nil {
+		removeRevisionFile(stored)
+		log.Printf("Error creating document for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document", "details": err.Error()})
+		return
+	}
+	log.Printf("Stored document %s rev A (%d bytes, sha256 %s)", doc.DocNumber, stored.Size, stored.Checksum)
+	respondDocument(c, http.StatusCreated, doc.DocumentID)
+}
+
+// updateDocument memperbarui metadata dokumen (title, docType, docNumber)
+func updateDocument(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+
+	var req Document
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		return
+	}
+	if !validDocTypes[req.DocType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid docType '%s' (drawing, specification, test_procedure, other)", req.DocType)})
+		return
+	}
+	if req.DocNumber == "" {
+		req.DocNumber = doc.DocNumber
+	}
+	if !docNumberAvailable(c, req.DocNumber, doc.DocumentID) {
+		return
+	}
+
+	if err := db.Model(&Document{}).Where("document_id = ?", doc.DocumentID).Updates(map[string]interface{}{
+		"title": req.Title, "doc_type": req.DocType, "doc_number": req.DocNumber,
+	}).Error; err != nil {
+		log.Printf("Error updating document %d: %v", doc.DocumentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update document"})
+		return
+	}
+	respondDocument(c, http.StatusOK, doc.DocumentID)
+}
+
+// deleteDocument menghapus dokumen yang seluruh revisinya masih draft.
+// Dokumen yang pernah direview/dirilis disimpan sebagai rekaman engineering.
+func deleteDocument(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	for _, rev := range doc.Revisions {
+		if rev.Status != docDraft {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only documents with draft revisions only can be deleted; mark revisions obsolete instead"})
+			return
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", doc.DocumentID).Delete(&DocumentRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Document{}, doc.DocumentID).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting document %d: %v", doc.DocumentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document"})
+		return
+	}
+	for _, rev := range doc.Revisions {
+		removeRevisionFile(rev)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// revisionConflict - Revisi baru ditolak karena revisi sebelumnya belum selesai (dijawab 409)
+type revisionConflict struct{ msg string }
+
+func (e *revisionConflict) Error() string {
+	return e.msg
+}
+
+// pendingRevision mengembalikan pesan jika revisi terbaru masih draft/review
+func pendingRevision(last DocumentRevision) string {
+	if last.Status == docDraft || last.Status == docReview {
+		return fmt.Sprintf("Revision %s is still in %s; release, obsolete or delete it first", last.Revision, last.Status)
+	}
+	return ""
+}
+
+// createRevision mengunggah revisi baru (huruf berikutnya) berstatus draft.
+// Ditolak jika masih ada revisi draft/review yang belum selesai.
+func createRevision(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	if len(doc.Revisions) > 0 {
+		if msg := pendingRevision(doc.Revisions[0]); msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+	}
+
+	fh, status, msg := formFile(c)
+	if msg != "" {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	uploader, msg := formUploader(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	stored, err := storeRevisionFile(fh, doc)
+	if err == nil {
+		stored.Status = docDraft
+		stored.UploadedBy = uploader
+		stored.UploadedAt = time.Now()
+		stored.Notes = c.PostForm("notes")
+		// Dokumen dikunci agar huruf revisi dihitung dari revisi terbaru, bukan dari data
+		// yang dibaca sebelum upload
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Document{}, doc.DocumentID).Error; err != nil {
+				return err
+			}
+			var latest []DocumentRevision
+			if err := tx.Where("document_id = ?", doc.DocumentID).Order("revision_id DESC").Limit(1).Find(&latest).Error; err != nil {
+				return err
+			}
+			previous := ""
+			if len(latest) > 0 {
+				if msg := pendingRevision(latest[0]); msg != "" {
+					return &revisionConflict{msg}
+				}
+				previous = latest[0].Revision
+			}
+			stored.Revision = nextRevision(previous)
+			return tx.Create(&stored).Error
+		})
+	}
+	if err != nil {
+		removeRevisionFile(stored)
+		var conflict *revisionConflict
+		switch {
+		case errors.As(err, &conflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case dberr.IsDuplicateKey(err):
+			c.JSON(http.StatusConflict, gin.H{"error": "Another revision was uploaded at the same time; reload the document and try again"})
+		default:
+			log.Printf("Error storing revision for document %d: %v", doc.DocumentID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store revision", "details": err.Error()})
+		}
+		return
+	}
+	log.Printf("Stored document %s rev %s (%d bytes, sha256 %s)", doc.DocNumber, stored.Revision, stored.Size, stored.Checksum)
+	respondDocument(c, http.StatusCreated, doc.DocumentID)
+}
+
+// updateRevisionStatus mengubah status revisi (draft -> review -> released -> obsolete).
+// Merilis revisi membuat revisi released sebelumnya menjadi obsolete.
+func updateRevisionStatus(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	rev, ok := findRevision(c, doc)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Notes  string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	allowed := false
+	for _, next := range docTransitions[rev.Status] {
+		allowed = allowed || next == req.Status
+	}
+	if !allowed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot change revision status from %s to %s", rev.Status, req.Status)})
+		return
+	}
+
+	updates := map[string]interface{}{"status": req.Status}
+	if req.Notes != "" {
+		updates["notes"] = req.Notes
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.Status == docReleased {
+			updates["released_at"] = time.Now()
+			if err := tx.Model(&DocumentRevision{}).Where("document_id = ? AND status = ? AND revision_id <> ?", doc.DocumentID, docReleased, rev.RevisionID).
+				Update("status", docObsolete).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&DocumentRevision{}).Where("revision_id = ?", rev.RevisionID).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("Error updating status of revision %d: %v", rev.RevisionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update revision status"})
+		return
+	}
+	respondDocument(c, http.StatusOK, doc.DocumentID)
+}
+
+// deleteRevision menghapus revisi draft beserta file-nya
+func deleteRevision(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	rev, ok := findRevision(c, doc)
+	if !ok {
+		return
+	}
+	if rev.Status != docDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft revisions can be deleted"})
+		return
+	}
+	if len(doc.Revisions) == 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A document must keep at least one revision; delete the document instead"})
+		return
+	}
+
+	if err := db.Delete(&DocumentRevision{}, rev.RevisionID).Error; err != nil {
+		log.Printf("Error deleting revision %d: %v", rev.RevisionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete revision"})
+		return
+	}
+	removeRevisionFile(rev)
+	c.Status(http.StatusNoContent)
+}
+
+// sendRevision mengirim file revisi setelah memastikan checksum-nya masih sesuai
+func sendRevision(c *gin.Context, doc Document, rev DocumentRevision) {
+	path := filepath.Join(documentRoot(), rev.StoredPath)
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		log.Printf("Error reading document file %s: %v", rev.StoredPath, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document file is missing from storage"})
+		return
+	}
+	if checksum != rev.Checksum {
+		log.Printf("Checksum mismatch for %s rev %s: stored %s, file %s", doc.DocNumber, rev.Revision, rev.Checksum, checksum)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Document file checksum does not match the recorded checksum"})
+		return
+	}
+	if rev.ContentType != "" {
+		c.Header("Content-Type", rev.ContentType)
+	}
+	c.Header("X-Checksum-SHA256", rev.Checksum)
+	c.FileAttachment(path, fmt.Sprintf("%s_rev%s%s", doc.DocNumber, rev.Revision, filepath.Ext(rev.FileName)))
+}
+
+// downloadRevision mengunduh revisi tertentu, termasuk revisi lama yang sudah obsolete
+func downloadRevision(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	rev, ok := findRevision(c, doc)
+	if !ok {
+		return
+	}
+	sendRevision(c, doc, rev)
+}
+
+// downloadCurrent mengunduh revisi released yang berlaku
+func downloadCurrent(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, project)
+	if !ok {
+		return
+	}
+	if doc.Current == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document has no released revision"})
+		return
+	}
+	sendRevision(c, doc, *doc.Current)
+}
+
+// deleteProjectDocuments menghapus rekaman dokumen proyek; dipanggil di dalam transaksi deleteProject.
+// File di disk dihapus lewat removeProjectFiles setelah transaksi berhasil.
+func deleteProjectDocuments(tx *gorm.DB, rekayasaID int) error {
+	if err := tx.Where("document_id IN (?)", tx.Model(&Document{}).Select("document_id").Where("rekayasa_id = ?", rekayasaID)).
+		Delete(&DocumentRevision{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("rekayasa_id = ?", rekayasaID).Delete(&Document{}).Error
+}
+
+// removeProjectFiles menghapus direktori dokumen proyek dari disk
+func removeProjectFiles(rekayasaID int) {
+	if err := os.RemoveAll(filepath.Join(documentRoot(), strconv.Itoa(rekayasaID))); err != nil {
+		log.Printf("Error removing document files for rekayasa %d: %v", rekayasaID, err)
+	}
+}
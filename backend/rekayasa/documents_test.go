@@ -0,0 +1,23 @@
+package rekayasa
+
+import "testing"
+
+func TestNextRevision(t *testing.T) {
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"", "A"},
+		{"A", "B"},
+		{"Y", "Z"},
+		{"Z", "AA"},
+		{"AA", "AB"},
+		{"AZ", "BA"},
+		{"ZZ", "AAA"},
+	}
+	for _, tt := range tests {
+		if got := nextRevision(tt.current); got != tt.want {
+			t.Errorf("nextRevision(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rekayasa
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/produksi"
+)
+
+// projectSelesai - Status proyek rekayasa yang sudah selesai (nilai yang dipakai frontend)
+const projectSelesai = "Selesai"
+
+var errAlreadyReleased = errors.New("project has already been released to production")
+
+// unreleasedDocuments mengembalikan nomor dokumen yang belum memiliki revisi released.
+// Dokumen yang seluruh revisinya obsolete dianggap sudah ditarik dan tidak dihitung.
+func unreleasedDocuments(docs []Document) []string {
+	pending := []string{}
+	for _, doc := range docs {
+		if doc.Current != nil {
+			continue
+		}
+		for _, rev := range doc.Revisions {
+			if rev.Status != docObsolete {
+				pending = append(pending, doc.DocNumber)
+				break
+			}
+		}
+	}
+	return pending
+}
+
+// releaseToProduction membuat job produksi dari proyek rekayasa yang sudah selesai:
+// nama proyek, BOM desain dan tim (NIP) disalin, lalu kedua record saling ditautkan.
+// Body: target (wajib), startDate, endDate (YYYY-MM-DD, wajib), name dan budget opsional.
+// Ditolak jika proyek belum berstatus Selesai, sudah pernah dirilis, tidak memiliki BOM,
+// atau ada dokumen yang belum released.
+func releaseToProduction(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	if project.ProduksiID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project has already been released to production", "produksi_id": *project.ProduksiID})
+		return
+	}
+	if project.Status != projectSelesai {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Project status must be '%s' before release to production (current: '%s')", projectSelesai, project.Status)})
+		return
+	}
+
+	docs, err := loadDocuments(db.Where("rekayasa_id = ?", project.RekayasaID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents", "details": err.Error()})
+		return
+	}
+	if pending := unreleasedDocuments(docs); len(pending) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "All project documents must be released before release to production", "unreleased": pending})
+		return
+	}
+	released := 0
+	for _, doc := range docs {
+		if doc.Current != nil {
+			released++
+		}
+	}
+	if released == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project has no released documents"})
+		return
+	}
+
+	bom, err := loadDesignBOM(db, project.RekayasaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch BOM", "details": err.Error()})
+		return
+	}
+	if len(bom) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project has no BOM; add design materials before release to production"})
+		return
+	}
+
+	var req struct {
+		Name      string  `json:"name"`
+		Target    int     `json:"target"`
+		StartDate string  `json:"startDate"`
+		EndDate   string  `json:"endDate"`
+		Budget    float64 `json:"budget"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		req.Name = project.Name
+	}
+
+	// Tim proyek menjadi personel job produksi (kolom personnel_data berisi array NIP)
+	teams, err := loadTeams([]int{project.RekayasaID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team", "details": err.Error()})
+		return
+	}
+	nips := []string{}
+	for _, m := range teams[project.RekayasaID] {
+		if m.Personalia != nil {
+			nips = append(nips, m.Personalia.NIP)
+		}
+	}
+	materials := make([]produksi.ProduksiMaterial, 0, len(bom))
+	for _, line := range bom {
+		materials = append(materials, produksi.ProduksiMaterial{
+			MaterialsID: line.MaterialsID,
+			InventoryID: line.InventoryID,
+			QtyPerUnit:  line.QtyPerUnit,
+			Notes:       line.Notes,
+		})
+	}
+
+	// Job dibuat lewat modul produksi agar validasi jadwal dan bentrok personel sama dengan
+	// POST /api/produksi (?allow_conflict=true diteruskan)
+	var job produksi.Produksi
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		job, err = produksi.CreateJob(tx, produksi.NewJob{
+			Name:          req.Name,
+			Target:        req.Target,
+			StartDate:     req.StartDate,
+			EndDate:       req.EndDate,
+			Budget:        req.Budget,
+			RekayasaID:    &project.RekayasaID,
+			Personnel:     nips,
+			Materials:     materials,
+			AllowConflict: c.Query("allow_conflict") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		// Cegah rilis ganda saat dua permintaan berjalan bersamaan
+		result := tx.Model(&Rekayasa{}).Where("rekayasa_id = ? AND produksi_id IS NULL", project.RekayasaID).
+			Update("produksi_id", job.ProduksiID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyReleased
+		}
+		return nil
+	})
+	var invalid *produksi.JobError
+	if errors.As(err, &invalid) {
+		c.JSON(invalid.Status, invalid.Body())
+		return
+	}
+	if errors.Is(err, errAlreadyReleased) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project has already been released to production"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error releasing rekayasa %d to production: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create production job", "details": err.Error()})
+		return
+	}
+	log.Printf("Released rekayasa %d to production as produksi %d (%d BOM lines, %d personnel)", project.RekayasaID, job.ProduksiID, len(bom), len(nips))
+
+	project.ProduksiID = &job.ProduksiID
+	projects, err := projectsToFrontend([]Rekayasa{project})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"project":   projects[0],
+		"produksi":  job,
+		"bom_lines": len(bom),
+	})
+}
@@ -0,0 +1,686 @@
+package rekayasa
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+	"kai-backend/mergepatch"
+)
+
+// Status task rekayasa
+const (
+	taskBelumDimulai = "Belum Dimulai"
+	taskDikerjakan   = "Dalam Pengerjaan"
+	taskSelesai      = "Selesai"
+)
+
+var validTaskStatus = map[string]bool{taskBelumDimulai: true, taskDikerjakan: true, taskSelesai: true}
+
+// Milestone - Tahapan proyek rekayasa yang mengelompokkan task
+type Milestone struct {
+	MilestoneID int    `json:"id" gorm:"column:milestone_id;primaryKey;autoIncrement"`
+	RekayasaID  int    `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	Name        string `json:"name" gorm:"column:name"`
+	DueDate     string `json:"dueDate" gorm:"column:due_date"`
+	SortOrder   int    `json:"sortOrder" gorm:"column:sort_order"`
+
+	// Dihitung dari task milestone setiap kali dimuat
+	Progress int    `json:"progress" gorm:"-"`
+	Overdue  bool   `json:"overdue" gorm:"-"`
+	Tasks    []Task `json:"tasks" gorm:"-"`
+}
+
+func (Milestone) TableName() string {
+	return "rekayasa_milestone"
+}
+
+// Task - Pekerjaan dalam proyek rekayasa. Weight menentukan kontribusi task
+// terhadap progress proyek saat task selesai.
+type Task struct {
+	TaskID      int        `json:"id" gorm:"column:task_id;primaryKey;autoIncrement"`
+	RekayasaID  int        `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	MilestoneID *int       `json:"milestone_id" gorm:"column:milestone_id"`
+	Name        string     `json:"name" gorm:"column:name"`
+	OwnerID     *int       `json:"owner_id" gorm:"column:owner_id"` // personalia_id anggota tim
+	DueDate     string     `json:"dueDate" gorm:"column:due_date"`
+	Weight      float64    `json:"weight" gorm:"column:weight"`
+	Status      string     `json:"status" gorm:"column:status"`
+	CompletedAt *time.Time `json:"completedAt" gorm:"column:completed_at"`
+	Notes       string     `json:"notes" gorm:"column:notes"`
+
+	Owner    *Personalia `json:"owner,omitempty" gorm:"foreignKey:OwnerID;references:PersonaliaID"`
+	Overdue  bool        `json:"overdue" gorm:"-"`
+	DaysLate int         `json:"daysLate" gorm:"-"`
+	OwnerNIP string      `json:"owner_nip,omitempty" gorm:"-"` // Alternatif owner_id saat input
+}
+
+func (Task) TableName() string {
+	return "rekayasa_task"
+}
+
+// TaskSummary - Ringkasan task proyek untuk daftar proyek
+type TaskSummary struct {
+	Total   int `json:"total"`
+	Done    int `json:"done"`
+	Overdue int `json:"overdue"`
+}
+
+// markOverdue menandai task yang belum selesai dan sudah melewati dueDate
+func markOverdue(tasks []Task, asOf time.Time) {
+	for i := range tasks {
+		tasks[i].Overdue, tasks[i].DaysLate = false, 0
+		due, ok := dateutil.Parse(tasks[i].DueDate)
+		if !ok || tasks[i].Status == taskSelesai || !asOf.After(due) {
+			continue
+		}
+		tasks[i].Overdue = true
+		tasks[i].DaysLate = int(math.Round(asOf.Sub(due).Hours() / 24))
+	}
+}
+
+// weightedProgress menghitung persentase bobot task yang sudah selesai
+func weightedProgress(tasks []Task) int {
+	total, done := 0.0, 0.0
+	for _, t := range tasks {
+		total += t.Weight
+		if t.Status == taskSelesai {
+			done += t.Weight
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	return int(math.Round(done / total * 100))
+}
+
+// hasTasks mengecek apakah progress proyek dihitung dari task
+func hasTasks(tx *gorm.DB, rekayasaID int) (bool, error) {
+	var count int64
+	err := tx.Model(&Task{}).Where("rekayasa_id = ?", rekayasaID).Count(&count).Error
+	return count > 0, err
+}
+
+// recomputeProjectProgress menyimpan progress proyek dari bobot task yang selesai.
+// Proyek tanpa task tetap memakai progress yang diisi manual.
+func recomputeProjectProgress(tx *gorm.DB, rekayasaID int) error {
+	var tasks []Task
+	if err := tx.Where("rekayasa_id = ?", rekayasaID).Find(&tasks).Error; err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tx.Model(&Rekayasa{}).Where("rekayasa_id = ?", rekayasaID).Update("progress", weightedProgress(tasks)).Error
+}
+
+// loadTaskSummaries menghitung ringkasan task untuk beberapa proyek sekaligus
+func loadTaskSummaries(ids []int) (map[int]*TaskSummary, error) {
+	summaries := make(map[int]*TaskSummary)
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	var tasks []Task
+	if err := db.Where("rekayasa_id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	markOverdue(tasks, dateutil.Today())
+	for _, t := range tasks {
+		s := summaries[t.RekayasaID]
+		if s == nil {
+			s = &TaskSummary{}
+			summaries[t.RekayasaID] = s
+		}
+		s.Total++
+		if t.Status == taskSelesai {
+			s.Done++
+		}
+		if t.Overdue {
+			s.Overdue++
+		}
+	}
+	return summaries, nil
+}
+
+// validateTask memeriksa task terhadap proyek: nama, bobot, status, tanggal, milestone dan owner.
+// Owner diisi lewat owner_id atau owner_nip dan harus anggota tim proyek.
+func validateTask(project Rekayasa, task *Task) string {
+	if strings.TrimSpace(task.Name) == "" {
+		return "Task name is required"
+	}
+	if task.Weight <= 0 {
+		return "Weight must be greater than 0"
+	}
+	if task.Status == "" {
+		task.Status = taskBelumDimulai
+	}
+	if !validTaskStatus[task.Status] {
+		return fmt.Sprintf("Invalid task status '%s' (Belum Dimulai, Dalam Pengerjaan, Selesai)", task.Status)
+	}
+	if task.DueDate != "" {
+		due, err := time.Parse(dateutil.Layout, task.DueDate)
+		if err != nil {
+			return fmt.Sprintf("Invalid dueDate '%s', use YYYY-MM-DD", task.DueDate)
+		}
+		if deadline, ok := dateutil.Parse(project.Deadline); ok && due.After(deadline) {
+			return fmt.Sprintf("dueDate (%s) cannot be after the project deadline (%s)", task.DueDate, deadline.Format(dateutil.Layout))
+		}
+	}
+	if task.MilestoneID != nil {
+		var count int64
+		if err := db.Model(&Milestone{}).Where("milestone_id = ? AND rekayasa_id = ?", *task.MilestoneID, project.RekayasaID).Count(&count).Error; err != nil || count == 0 {
+			return fmt.Sprintf("Milestone with ID %d not found in this project", *task.MilestoneID)
+		}
+	}
+
+	if task.OwnerID == nil && task.OwnerNIP != "" {
+		var p Personalia
+		if err := db.Where("nip = ?", task.OwnerNIP).First(&p).Error; err != nil {
+			return fmt.Sprintf("Personalia with NIP '%s' not found", task.OwnerNIP)
+		}
+		task.OwnerID = &p.PersonaliaID
+	}
+	if task.OwnerID != nil {
+		var count int64
+		if err := db.Model(&TeamMember{}).Where("rekayasa_id = ? AND personalia_id = ?", project.RekayasaID, *task.OwnerID).Count(&count).Error; err != nil || count == 0 {
+			return "Task owner must be a member of the project team"
+		}
+	}
+	return ""
+}
+
+// applyCompletion mengisi completedAt saat task menjadi Selesai dan mengosongkannya jika dibuka kembali
+func applyCompletion(task *Task, previousStatus string) {
+	switch {
+	case task.Status == taskSelesai && (previousStatus != taskSelesai || task.CompletedAt == nil):
+		now := time.Now()
+		task.CompletedAt = &now
+	case task.Status != taskSelesai:
+		task.CompletedAt = nil
+	}
+}
+
+// findTask mengambil task berdasarkan parameter :taskId milik proyek
+func findTask(c *gin.Context, project Rekayasa) (Task, bool) {
+	var task Task
+	taskID, err := strconv.Atoi(c.Param("taskId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return task, false
+	}
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task", "details": err.Error()})
+		}
+		return task, false
+	}
+	return task, true
+}
+
+// respondTask memuat ulang task beserta owner dan flag overdue
+func respondTask(c *gin.Context, status, taskID int) {
+	var task Task
+	if err := db.Preload("Owner").First(&task, taskID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task", "details": err.Error()})
+		return
+	}
+	tasks := []Task{task}
+	markOverdue(tasks, dateutil.Today())
+	c.JSON(status, tasks[0])
+}
+
+// getTasks menampilkan task proyek. Filter opsional: ?milestone_id=, ?status=, ?owner_id=, ?overdue=true
+func getTasks(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	query := db.Preload("Owner").Where("rekayasa_id = ?", project.RekayasaID)
+	if milestoneID := c.Query("milestone_id"); milestoneID != "" {
+		query = query.Where("milestone_id = ?", milestoneID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+
+	var tasks []Task
+	if err := query.Order("due_date IS NULL, due_date, task_id").Find(&tasks).Error; err != nil {
+		log.Printf("Error fetching tasks for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks", "details": err.Error()})
+		return
+	}
+	markOverdue(tasks, dateutil.Today())
+
+	if c.Query("overdue") == "true" {
+		overdue := []Task{}
+		for _, t := range tasks {
+			if t.Overdue {
+				overdue = append(overdue, t)
+			}
+		}
+		tasks = overdue
+	}
+	if tasks == nil {
+		tasks = []Task{}
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// createTask menambahkan task ke proyek lalu menghitung ulang progress proyek
+func createTask(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var task Task
+	if err := c.ShouldBindJSON(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateTask(project, &task); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	task.TaskID = 0
+	task.RekayasaID = project.RekayasaID
+	task.Owner = nil
+	task.CompletedAt = nil
+	applyCompletion(&task, "")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return recomputeProjectProgress(tx, project.RekayasaID)
+	})
+	if err != nil {
+		log.Printf("Error creating task for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		return
+	}
+	respondTask(c, http.StatusCreated, task.TaskID)
+}
+
+// updateTask mengganti seluruh field task
+func updateTask(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	task, ok := findTask(c, project)
+	if !ok {
+		return
+	}
+
+	var req Task
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateTask(project, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	previousStatus := task.Status
+	task.MilestoneID = req.MilestoneID
+	task.Name = req.Name
+	task.OwnerID = req.OwnerID
+	task.DueDate = req.DueDate
+	task.Weight = req.Weight
+	task.Status = req.Status
+	task.Notes = req.Notes
+	applyCompletion(&task, previousStatus)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Owner").Save(&task).Error; err != nil {
+			return err
+		}
+		return recomputeProjectProgress(tx, project.RekayasaID)
+	})
+	if err != nil {
+		log.Printf("Error updating task %d: %v", task.TaskID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+	respondTask(c, http.StatusOK, task.TaskID)
+}
+
+// patchTask memperbarui sebagian task (JSON Merge Patch, RFC 7396), mis. hanya status
+func patchTask(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	task, ok := findTask(c, project)
+	if !ok {
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Invalid patch document", "details": err.Error()})
+		return
+	}
+
+	var merged Task
+	if err := patch.ApplyTo(task, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	merged.TaskID = task.TaskID
+	merged.RekayasaID = task.RekayasaID
+
+	// owner_nip hanya alternatif input untuk owner_id
+	_, ownerByNIP := patch.Take("owner_nip")
+	ownerByNIP = ownerByNIP && !patch.Has("owner_id")
+	if ownerByNIP {
+		merged.OwnerID = nil
+	}
+	if msg := validateTask(project, &merged); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &Task{}, "rekayasa_id", "completedAt")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only fields", "fields": rejected})
+		return
+	}
+	if ownerByNIP {
+		columns = append(columns, "owner_id")
+	}
+	if patch.Has("status") {
+		applyCompletion(&merged, task.Status)
+		columns = append(columns, "completed_at")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&task).Select(columns).Updates(&merged).Error; err != nil {
+				return err
+			}
+		}
+		return recomputeProjectProgress(tx, project.RekayasaID)
+	})
+	if err != nil {
+		log.Printf("Error patching task %d: %v", task.TaskID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+	respondTask(c, http.StatusOK, task.TaskID)
+}
+
+// deleteTask menghapus task lalu menghitung ulang progress proyek
+func deleteTask(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	task, ok := findTask(c, project)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&task).Error; err != nil {
+			return err
+		}
+		return recomputeProjectProgress(tx, project.RekayasaID)
+	})
+	if err != nil {
+		log.Printf("Error deleting task %d: %v", task.TaskID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// getMilestones menampilkan milestone proyek beserta task dan progress masing-masing.
+// Task tanpa milestone dikembalikan terpisah.
+func getMilestones(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var milestones []Milestone
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).Order("sort_order, due_date, milestone_id").Find(&milestones).Error; err != nil {
+		log.Printf("Error fetching milestones for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch milestones", "details": err.Error()})
+		return
+	}
+	var tasks []Task
+	if err := db.Preload("Owner").Where("rekayasa_id = ?", project.RekayasaID).Order("due_date IS NULL, due_date, task_id").Find(&tasks).Error; err != nil {
+		log.Printf("Error fetching tasks for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks", "details": err.Error()})
+		return
+	}
+	asOf := dateutil.Today()
+	markOverdue(tasks, asOf)
+
+	byMilestone := make(map[int][]Task)
+	unassigned := []Task{}
+	for _, t := range tasks {
+		if t.MilestoneID == nil {
+			unassigned = append(unassigned, t)
+			continue
+		}
+		byMilestone[*t.MilestoneID] = append(byMilestone[*t.MilestoneID], t)
+	}
+	for i := range milestones {
+		m := &milestones[i]
+		m.Tasks = byMilestone[m.MilestoneID]
+		if m.Tasks == nil {
+			m.Tasks = []Task{}
+		}
+		m.Progress = weightedProgress(m.Tasks)
+		if due, ok := dateutil.Parse(m.DueDate); ok && asOf.After(due) && (len(m.Tasks) == 0 || m.Progress < 100) {
+			m.Overdue = true
+		}
+	}
+	if milestones == nil {
+		milestones = []Milestone{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress":   weightedProgress(tasks),
+		"milestones": milestones,
+		"unassigned": unassigned,
+	})
+}
+
+// validateMilestone memeriksa nama dan tanggal milestone terhadap deadline proyek
+func validateMilestone(project Rekayasa, m Milestone) string {
+	if strings.TrimSpace(m.Name) == "" {
+		return "Milestone name is required"
+	}
+	if m.DueDate != "" {
+		due, err := time.Parse(dateutil.Layout, m.DueDate)
+		if err != nil {
+			return fmt.Sprintf("Invalid dueDate '%s', use YYYY-MM-DD", m.DueDate)
+		}
+		if deadline, ok := dateutil.Parse(project.Deadline); ok && due.After(deadline) {
+			return fmt.Sprintf("dueDate (%s) cannot be after the project deadline (%s)", m.DueDate, deadline.Format(dateutil.Layout))
+		}
+	}
+	return ""
+}
+
+// findMilestone mengambil milestone berdasarkan parameter :milestoneId milik proyek
+func findMilestone(c *gin.Context, project Rekayasa) (Milestone, bool) {
+	var m Milestone
+	milestoneID, err := strconv.Atoi(c.Param("milestoneId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid milestone ID"})
+		return m, false
+	}
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).First(&m, milestoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Milestone not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch milestone", "details": err.Error()})
+		}
+		return m, false
+	}
+	return m, true
+}
+
+func createMilestone(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var m Milestone
+	if err := c.ShouldBindJSON(&m); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateMilestone(project, m); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	m.MilestoneID = 0
+	m.RekayasaID = project.RekayasaID
+
+	if err := db.Create(&m).Error; err != nil {
+		log.Printf("Error creating milestone for rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create milestone"})
+		return
+	}
+	m.Tasks = []Task{}
+	c.JSON(http.StatusCreated, m)
+}
+
+func updateMilestone(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	m, ok := findMilestone(c, project)
+	if !ok {
+		return
+	}
+
+	var req Milestone
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+	if msg := validateMilestone(project, req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	m.Name = req.Name
+	m.DueDate = req.DueDate
+	m.SortOrder = req.SortOrder
+
+	if err := db.Save(&m).Error; err != nil {
+		log.Printf("Error updating milestone %d: %v", m.MilestoneID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update milestone"})
+		return
+	}
+	m.Tasks = []Task{}
+	c.JSON(http.StatusOK, m)
+}
+
+// deleteMilestone menghapus milestone; task di dalamnya tetap ada tanpa milestone
+func deleteMilestone(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	m, ok := findMilestone(c, project)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Task{}).Where("milestone_id = ?", m.MilestoneID).Update("milestone_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&m).Error
+	})
+	if err != nil {
+		log.Printf("Error deleting milestone %d: %v", m.MilestoneID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete milestone"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// OverdueTask - Task terlambat beserta nama proyeknya untuk daftar lintas proyek
+type OverdueTask struct {
+	Task
+	ProjectName string `json:"projectName"`
+}
+
+// getOverdueTasks menampilkan task terlambat di seluruh proyek, paling terlambat lebih dulu.
+// Filter opsional: ?owner_id=
+func getOverdueTasks(c *gin.Context) {
+	query := db.Preload("Owner").Where("status <> ? AND due_date IS NOT NULL AND due_date <> '' AND due_date < ?",
+		taskSelesai, time.Now().Format(dateutil.Layout))
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+
+	var tasks []Task
+	if err := query.Find(&tasks).Error; err != nil {
+		log.Printf("Error fetching overdue tasks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overdue tasks", "details": err.Error()})
+		return
+	}
+	markOverdue(tasks, dateutil.Today())
+
+	ids := make([]int, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.RekayasaID)
+	}
+	names := make(map[int]string)
+	if len(ids) > 0 {
+		var projects []Rekayasa
+		if err := db.Where("rekayasa_id IN ?", ids).Find(&projects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects", "details": err.Error()})
+			return
+		}
+		for _, p := range projects {
+			names[p.RekayasaID] = p.Name
+		}
+	}
+
+	result := make([]OverdueTask, 0, len(tasks))
+	for _, t := range tasks {
+		if t.Overdue {
+			result = append(result, OverdueTask{Task: t, ProjectName: names[t.RekayasaID]})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].DaysLate != result[j].DaysLate {
+			return result[i].DaysLate > result[j].DaysLate
+		}
+		return result[i].TaskID < result[j].TaskID
+	})
+	c.JSON(http.StatusOK, result)
+}
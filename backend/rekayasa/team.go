@@ -0,0 +1,489 @@
+package rekayasa
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"kai-backend/dateutil"
+)
+
+// Peran anggota tim rekayasa
+const (
+	roleLead     = "lead"
+	roleEngineer = "engineer"
+	roleDrafter  = "drafter"
+)
+
+var validRoles = map[string]bool{roleLead: true, roleEngineer: true, roleDrafter: true}
+
+// Personalia - Referensi minimal ke tabel 'personalia' untuk anggota tim
+type Personalia struct {
+	PersonaliaID int    `json:"personalia_id" gorm:"column:personalia_id;primaryKey"`
+	NIP          string `json:"nip" gorm:"column:nip"`
+	Jabatan      string `json:"jabatan" gorm:"column:jabatan"`
+	Divisi       string `json:"divisi" gorm:"column:divisi"`
+	Status       string `json:"status" gorm:"column:status"`
+	ProfileID    *int   `json:"-" gorm:"column:profile_id"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Personalia di trash dianggap tidak ada
+}
+
+func (Personalia) TableName() string {
+	return "personalia"
+}
+
+// Profile - Referensi minimal ke tabel 'profile'; email dipakai untuk mencocokkan data tim lama
+type Profile struct {
+	ProfileID int    `gorm:"column:profile_id;primaryKey"`
+	Email     string `gorm:"column:email"`
+
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
+}
+
+func (Profile) TableName() string {
+	return "profile"
+}
+
+// TeamMember - Keanggotaan personalia pada proyek rekayasa (tabel 'rekayasa_team')
+type TeamMember struct {
+	RekayasaTeamID int    `json:"id" gorm:"column:rekayasa_team_id;primaryKey;autoIncrement"`
+	RekayasaID     int    `json:"rekayasa_id" gorm:"column:rekayasa_id"`
+	PersonaliaID   int    `json:"personalia_id" gorm:"column:personalia_id"`
+	Role           string `json:"role" gorm:"column:role"`
+
+	NIP        string      `json:"nip,omitempty" gorm:"-"` // Alternatif personalia_id saat input
+	Personalia *Personalia `json:"personalia,omitempty" gorm:"foreignKey:PersonaliaID;references:PersonaliaID"`
+}
+
+func (TeamMember) TableName() string {
+	return "rekayasa_team"
+}
+
+// findPersonalia mencari personalia berdasarkan NIP, lalu berdasarkan email profil
+// (lengkap atau bagian sebelum '@'). Tabel personalia tidak menyimpan nama,
+// sehingga email adalah satu-satunya identitas teks selain NIP.
+func findPersonalia(tx *gorm.DB, ref string) (Personalia, bool) {
+	var p Personalia
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return p, false
+	}
+	if err := tx.Where("nip = ?", ref).First(&p).Error; err == nil {
+		return p, true
+	}
+	var profiles []Profile
+	if err := tx.Where("LOWER(email) = LOWER(?) OR LOWER(email) LIKE LOWER(?)", ref, ref+"@%").Find(&profiles).Error; err != nil || len(profiles) != 1 {
+		return p, false
+	}
+	if err := tx.Where("profile_id = ?", profiles[0].ProfileID).First(&p).Error; err != nil {
+		return p, false
+	}
+	return p, true
+}
+
+// resolveTeam memvalidasi daftar anggota: personalia harus ada (via personalia_id atau nip),
+// peran valid, tidak ada anggota ganda dan paling banyak satu lead
+func resolveTeam(tx *gorm.DB, members []TeamMember) ([]TeamMember, string) {
+	seen := make(map[int]bool)
+	leads := 0
+	resolved := make([]TeamMember, 0, len(members))
+	for _, m := range members {
+		if m.Role == "" {
+			m.Role = roleEngineer
+		}
+		if !validRoles[m.Role] {
+			return nil, fmt.Sprintf("Invalid role '%s' (lead, engineer, drafter)", m.Role)
+		}
+
+		var p Personalia
+		switch {
+		case m.PersonaliaID > 0:
+			if err := tx.First(&p, m.PersonaliaID).Error; err != nil {
+				return nil, fmt.Sprintf("Personalia with ID %d not found", m.PersonaliaID)
+			}
+		case m.NIP != "":
+			if err := tx.Where("nip = ?", m.NIP).First(&p).Error; err != nil {
+				return nil, fmt.Sprintf("Personalia with NIP '%s' not found", m.NIP)
+			}
+		default:
+			return nil, "Each team member requires personalia_id or nip"
+		}
+
+		if seen[p.PersonaliaID] {
+			return nil, fmt.Sprintf("Personalia '%s' is listed more than once", p.NIP)
+		}
+		seen[p.PersonaliaID] = true
+		if m.Role == roleLead {
+			leads++
+		}
+		resolved = append(resolved, TeamMember{PersonaliaID: p.PersonaliaID, Role: m.Role, NIP: p.NIP})
+	}
+	if leads > 1 {
+		return nil, "A project can only have one lead"
+	}
+	return resolved, ""
+}
+
+// teamFromRefs mengubah daftar NIP/email dari field `team` (format lama) menjadi anggota berperan engineer
+func teamFromRefs(tx *gorm.DB, refs []string) ([]TeamMember, string) {
+	members := make([]TeamMember, 0, len(refs))
+	for _, ref := range refs {
+		p, ok := findPersonalia(tx, ref)
+		if !ok {
+			return nil, fmt.Sprintf("Team member '%s' not found in personalia", ref)
+		}
+		members = append(members, TeamMember{PersonaliaID: p.PersonaliaID, Role: roleEngineer})
+	}
+	return members, ""
+}
+
+// requestTeam mengambil anggota tim dari request: `members` (dengan peran) diutamakan,
+// jika kosong dipakai `team` berisi NIP
+func requestTeam(tx *gorm.DB, project RekayasaFrontend) ([]TeamMember, string) {
+	if len(project.Members) > 0 {
+		return resolveTeam(tx, project.Members)
+	}
+	members, msg := teamFromRefs(tx, project.Team)
+	if msg != "" {
+		return nil, msg
+	}
+	return resolveTeam(tx, members)
+}
+
+// replaceTeam mengganti seluruh anggota tim proyek. Task milik personel yang keluar
+// dari tim dilepas owner-nya.
+func replaceTeam(tx *gorm.DB, rekayasaID int, members []TeamMember) error {
+	if err := tx.Where("rekayasa_id = ?", rekayasaID).Delete(&TeamMember{}).Error; err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		row := TeamMember{RekayasaID: rekayasaID, PersonaliaID: m.PersonaliaID, Role: m.Role}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		ids = append(ids, m.PersonaliaID)
+	}
+	query := tx.Model(&Task{}).Where("rekayasa_id = ? AND owner_id IS NOT NULL", rekayasaID)
+	if len(ids) > 0 {
+		query = query.Where("owner_id NOT IN ?", ids)
+	}
+	return query.Update("owner_id", nil).Error
+}
+
+// loadTeams memuat anggota tim untuk beberapa proyek sekaligus, lead lebih dulu
+func loadTeams(ids []int) (map[int][]TeamMember, error) {
+	teams := make(map[int][]TeamMember)
+	if len(ids) == 0 {
+		return teams, nil
+	}
+	var rows []TeamMember
+	if err := db.Preload("Personalia").Where("rekayasa_id IN ?", ids).
+		Order("rekayasa_id, FIELD(role, 'lead', 'engineer', 'drafter'), rekayasa_team_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		teams[r.RekayasaID] = append(teams[r.RekayasaID], r)
+	}
+	return teams, nil
+}
+
+// toFrontend menyusun representasi frontend; `team` tetap berisi NIP anggota untuk kompatibilitas
+func toFrontend(p Rekayasa, members []TeamMember) RekayasaFrontend {
+	f := RekayasaFrontend{
+		RekayasaID: p.RekayasaID,
+		Name:       p.Name,
+		Status:     p.Status,
+		Team:       []string{},
+		Members:    []TeamMember{},
+		Deadline:   dateutil.Format(p.Deadline),
+		Progress:   p.Progress,
+		ProduksiID: p.ProduksiID,
+	}
+	if p.StartDate != nil {
+		f.StartDate = dateutil.Format(*p.StartDate)
+	}
+	if p.DeletedAt.Valid {
+		f.DeletedAt = &p.DeletedAt.Time
+	}
+	for _, m := range members {
+		if m.Personalia != nil {
+			m.NIP = m.Personalia.NIP
+		}
+		f.Team = append(f.Team, m.NIP)
+		f.Members = append(f.Members, m)
+	}
+	// Kolom team hanya menyimpan entri lama yang belum cocok dengan personalia
+	if p.Team != "" {
+		f.LegacyTeam = strings.Split(p.Team, ", ")
+	}
+	return f
+}
+
+// projectsToFrontend mengonversi proyek beserta tim dan ringkasan task-nya
+func projectsToFrontend(projects []Rekayasa) ([]RekayasaFrontend, error) {
+	ids := make([]int, 0, len(projects))
+	for _, p := range projects {
+		ids = append(ids, p.RekayasaID)
+	}
+	teams, err := loadTeams(ids)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := loadTaskSummaries(ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]RekayasaFrontend, 0, len(projects))
+	for _, p := range projects {
+		f := toFrontend(p, teams[p.RekayasaID])
+		f.TaskSummary = summaries[p.RekayasaID]
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// migrateLegacyTeams memindahkan kolom team (string dipisahkan ", ") ke tabel rekayasa_team.
+// Entri dicocokkan dengan NIP atau email profil; entri yang tidak cocok dibiarkan di kolom team
+// (ditampilkan sebagai legacyTeam) agar bisa dipetakan manual.
+func migrateLegacyTeams() {
+	var legacy []Rekayasa
+	if err := db.Where("team IS NOT NULL AND team <> ''").Find(&legacy).Error; err != nil {
+		log.Printf("Error fetching legacy rekayasa teams: %v", err)
+		return
+	}
+	for _, project := range legacy {
+		var existing []TeamMember
+		if err := db.Where("rekayasa_id = ?", project.RekayasaID).Find(&existing).Error; err != nil {
+			log.Printf("Error fetching team for rekayasa %d: %v", project.RekayasaID, err)
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, m := range existing {
+			seen[m.PersonaliaID] = true
+		}
+
+		var matched []TeamMember
+		var unmatched []string
+		for _, ref := range strings.Split(project.Team, ", ") {
+			if strings.TrimSpace(ref) == "" {
+				continue
+			}
+			p, ok := findPersonalia(db, ref)
+			if !ok {
+				unmatched = append(unmatched, ref)
+				continue
+			}
+			if !seen[p.PersonaliaID] {
+				seen[p.PersonaliaID] = true
+				matched = append(matched, TeamMember{RekayasaID: project.RekayasaID, PersonaliaID: p.PersonaliaID, Role: roleEngineer})
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i := range matched {
+				if err := tx.Create(&matched[i]).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&Rekayasa{}).Where("rekayasa_id = ?", project.RekayasaID).Update("team", strings.Join(unmatched, ", ")).Error
+		})
+		if err != nil {
+			log.Printf("Error migrating team for rekayasa %d: %v", project.RekayasaID, err)
+			continue
+		}
+		log.Printf("Migrated %d legacy team members for rekayasa %d into rekayasa_team.", len(matched), project.RekayasaID)
+		if len(unmatched) > 0 {
+			log.Printf("Rekayasa %d: %d team entries not found in personalia, kept as legacy team: %s",
+				project.RekayasaID, len(unmatched), strings.Join(unmatched, ", "))
+		}
+	}
+}
+
+// findProject mengambil proyek berdasarkan parameter :id dan menulis respons error jika gagal
+func findProject(c *gin.Context) (Rekayasa, bool) {
+	var project Rekayasa
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return project, false
+	}
+	if err := db.First(&project, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		} else {
+			log.Printf("Error fetching project with ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		}
+		return project, false
+	}
+	return project, true
+}
+
+// findMember mengambil anggota tim berdasarkan parameter :memberId milik proyek
+func findMember(c *gin.Context, project Rekayasa) (TeamMember, bool) {
+	var member TeamMember
+	memberID, err := strconv.Atoi(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return member, false
+	}
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).First(&member, memberID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team member not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team member", "details": err.Error()})
+		}
+		return member, false
+	}
+	return member, true
+}
+
+// respondTeam mengirim daftar anggota tim proyek terbaru
+func respondTeam(c *gin.Context, status, rekayasaID int) {
+	teams, err := loadTeams([]int{rekayasaID})
+	if err != nil {
+		log.Printf("Error fetching team for rekayasa %d: %v", rekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team", "details": err.Error()})
+		return
+	}
+	members := teams[rekayasaID]
+	if members == nil {
+		members = []TeamMember{}
+	}
+	c.JSON(status, members)
+}
+
+// getTeam menampilkan anggota tim proyek beserta data personalia
+func getTeam(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	respondTeam(c, http.StatusOK, project.RekayasaID)
+}
+
+// addTeamMember menambahkan personalia (personalia_id atau nip) ke tim proyek
+func addTeamMember(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	var req TeamMember
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+
+	var existing []TeamMember
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team", "details": err.Error()})
+		return
+	}
+	resolved, msg := resolveTeam(db, append(existing, req))
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	added := resolved[len(resolved)-1]
+	member := TeamMember{RekayasaID: project.RekayasaID, PersonaliaID: added.PersonaliaID, Role: added.Role}
+	if err := db.Create(&member).Error; err != nil {
+		log.Printf("Error adding team member to rekayasa %d: %v", project.RekayasaID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add team member"})
+		return
+	}
+	respondTeam(c, http.StatusCreated, project.RekayasaID)
+}
+
+// updateTeamMember mengubah peran anggota tim
+func updateTeamMember(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	member, ok := findMember(c, project)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format", "details": err.Error()})
+		return
+	}
+
+	if req.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role is required"})
+		return
+	}
+
+	var existing []TeamMember
+	if err := db.Where("rekayasa_id = ?", project.RekayasaID).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team", "details": err.Error()})
+		return
+	}
+	for i := range existing {
+		if existing[i].RekayasaTeamID == member.RekayasaTeamID {
+			existing[i].Role = req.Role
+		}
+	}
+	if _, msg := resolveTeam(db, existing); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := db.Model(&member).Update("role", req.Role).Error; err != nil {
+		log.Printf("Error updating team member %d: %v", member.RekayasaTeamID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update team member"})
+		return
+	}
+	respondTeam(c, http.StatusOK, project.RekayasaID)
+}
+
+// removeTeamMember mengeluarkan anggota dari tim; tim tidak boleh kosong
+func removeTeamMember(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+	member, ok := findMember(c, project)
+	if !ok {
+		return
+	}
+
+	var count int64
+	if err := db.Model(&TeamMember{}).Where("rekayasa_id = ?", project.RekayasaID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team", "details": err.Error()})
+		return
+	}
+	if count <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A project must keep at least one team member"})
+		return
+	}
+
+	// Task milik anggota yang keluar dilepas owner-nya
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Task{}).Where("rekayasa_id = ? AND owner_id = ?", project.RekayasaID, member.PersonaliaID).
+			Update("owner_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&member).Error
+	})
+	if err != nil {
+		log.Printf("Error removing team member %d: %v", member.RekayasaTeamID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove team member"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
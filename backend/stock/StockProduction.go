@@ -1,6 +1,7 @@
 package stock
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,6 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"kai-backend/mergepatch"
+	"kai-backend/trash"
 )
 
 // Struktur model sesuai tabel yang ada di database
@@ -15,11 +19,15 @@ import (
 type Inventory struct {
 	InventoryID int    `gorm:"column:inventory_id;primaryKey"`
 	Name        string `gorm:"column:name"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Item di trash dianggap tidak ada
 }
 
 type Produksi struct {
 	ProduksiID int    `gorm:"column:produksi_id;primaryKey"`
 	Name       string `gorm:"column:name"`
+
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"` // Job di trash dianggap tidak ada
 }
 
 type StockProduction struct {
@@ -35,6 +43,8 @@ type StockProduction struct {
 
 	Inventory *Inventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;references:InventoryID"`
 	Produksi  *Produksi  `json:"produksi,omitempty" gorm:"foreignKey:ProduksiID;references:ProduksiID"`
+
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at"`
 }
 
 func (StockProduction) TableName() string {
@@ -51,6 +61,7 @@ var db *gorm.DB
 
 func Init(database *gorm.DB) {
 	db = database
+	trash.Register(trash.Purger{Module: "stock", Model: &StockProduction{}, Purge: purgeStock})
 }
 
 func getAllStock(c *gin.Context) {
@@ -151,6 +162,66 @@ func updateStock(c *gin.Context) {
 	c.JSON(http.StatusOK, updated)
 }
 
+// patchStock memperbarui sebagian data stok (JSON Merge Patch, RFC 7396).
+// inventory_id/produksi_id bernilai null melepas relasi; lastUpdate selalu diisi waktu sekarang.
+func patchStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	patch, err := mergepatch.Bind(c)
+	if err != nil {
+		c.JSON(mergepatch.Status(err), gin.H{"error": "Format patch tidak valid", "details": err.Error()})
+		return
+	}
+
+	var item StockProduction
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data tidak ditemukan"})
+		return
+	}
+
+	var merged StockProduction
+	if err := patch.ApplyTo(item, &merged); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid", "details": err.Error()})
+		return
+	}
+	merged.StockID = item.StockID
+
+	blank := patch.Blank(map[string]string{"itemName": merged.ItemName, "location": merged.Location, "status": merged.Status})
+	if len(blank) > 0 || (patch.Has("quantity") && merged.Quantity < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field wajib tidak boleh kosong atau negatif"})
+		return
+	}
+
+	columns, rejected, err := patch.Columns(db, &StockProduction{}, "lastUpdate", "deleted_at")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses patch", "details": err.Error()})
+		return
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field tidak dikenal atau tidak dapat diubah", "fields": rejected})
+		return
+	}
+
+	if len(columns) > 0 {
+		merged.LastUpdate = time.Now()
+		columns = append(columns, "last_update")
+		if err := db.Model(&item).Select(columns).Updates(&merged).Error; err != nil {
+			log.Printf("Gagal patch stok: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan perubahan"})
+			return
+		}
+	}
+
+	var updated StockProduction
+	db.Preload("Inventory").Preload("Produksi").First(&updated, item.StockID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteStock memindahkan data stok ke trash (soft delete)
 func deleteStock(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -165,6 +236,7 @@ func deleteStock(c *gin.Context) {
 	}
 
 	if err := db.Delete(&item).Error; err != nil {
+		log.Printf("Gagal menghapus stok %d: %v", item.StockID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus data"})
 		return
 	}
@@ -172,10 +244,54 @@ func deleteStock(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// purgeStock menghapus permanen data stok. Riwayat pengiriman order tetap disimpan,
+// hanya rujukan ke baris stok yang dilepas.
+func purgeStock(tx *gorm.DB, id int) error {
+	if err := tx.Table("customer_shipment").Where("stock_id = ?", id).Update("stock_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&StockProduction{}, id).Error
+}
+
+// getStockTrash menampilkan data stok yang sedang berada di trash
+func getStockTrash(c *gin.Context) {
+	items := []StockProduction{}
+	if err := trash.List(db.Preload("Inventory").Preload("Produksi"), &items); err != nil {
+		log.Printf("Gagal mengambil trash stok: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trash stok"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// restoreStock memulihkan data stok dari trash
+func restoreStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	if err := trash.Restore(db, &StockProduction{}, id); err != nil {
+		if errors.Is(err, trash.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data tidak ditemukan di trash"})
+		} else {
+			log.Printf("Gagal memulihkan stok %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan data"})
+		}
+		return
+	}
+	var restored StockProduction
+	db.Preload("Inventory").Preload("Produksi").First(&restored, id)
+	c.JSON(http.StatusOK, restored)
+}
+
 func RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/", getAllStock)
+	r.GET("/trash", getStockTrash)
 	r.GET("/:id", getStockByID)
 	r.POST("/", createStock)
 	r.PUT("/:id", updateStock)
+	r.PATCH("/:id", patchStock)
 	r.DELETE("/:id", deleteStock)
+	r.POST("/:id/restore", restoreStock)
 }
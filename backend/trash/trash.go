@@ -0,0 +1,198 @@
+// Package trash menyeragamkan soft delete antar modul. Setiap modul menandai baris yang dihapus
+// dengan kolom deleted_at (gorm.DeletedAt), menampilkan isi trash, memulihkan baris, dan
+// mendaftarkan fungsi purge agar baris yang melewati masa retensi dapat dihapus permanen.
+package trash
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DefaultRetentionDays dipakai jika TRASH_RETENTION_DAYS tidak diatur
+const DefaultRetentionDays = 30
+
+// ErrNotInTrash dikembalikan Restore jika baris tidak ada atau tidak sedang dihapus
+var ErrNotInTrash = errors.New("data tidak ditemukan di trash")
+
+// Purger - Pendaftaran purge satu modul
+type Purger struct {
+	Module string
+	// Model adalah pointer ke model modul yang memiliki field gorm.DeletedAt
+	Model interface{}
+	// Purge menghapus permanen satu baris beserta data turunan milik modul.
+	// Jika nil, hanya baris itu sendiri yang dihapus.
+	Purge func(tx *gorm.DB, id int) error
+	// AfterPurge (opsional) dipanggil setelah transaksi purge berhasil di-commit, untuk
+	// membersihkan data di luar database seperti file upload.
+	AfterPurge func(id int)
+}
+
+var (
+	db      *gorm.DB
+	purgers []Purger
+)
+
+// Init menyimpan instance database untuk purge
+func Init(database *gorm.DB) {
+	db = database
+	log.Println("Trash module initialized.")
+}
+
+// Register mendaftarkan modul agar ikut dibersihkan oleh purge admin
+func Register(p Purger) {
+	purgers = append(purgers, p)
+}
+
+// RetentionDays mengembalikan masa retensi trash dalam hari (env TRASH_RETENTION_DAYS)
+func RetentionDays() int {
+	if days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && days >= 0 {
+		return days
+	}
+	return DefaultRetentionDays
+}
+
+// primaryKey mengembalikan nama kolom primary key model
+func primaryKey(tx *gorm.DB, model interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return "", errors.New("model tidak memiliki primary key")
+	}
+	return stmt.Schema.PrioritizedPrimaryField.DBName, nil
+}
+
+// List memuat baris yang sedang berada di trash ke dest (pointer ke slice model),
+// diurutkan dari yang terakhir dihapus
+func List(tx *gorm.DB, dest interface{}) error {
+	return tx.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(dest).Error
+}
+
+// Restore mengosongkan deleted_at baris id. ErrNotInTrash jika baris tidak sedang dihapus.
+func Restore(tx *gorm.DB, model interface{}, id int) error {
+	key, err := primaryKey(tx, model)
+	if err != nil {
+		return err
+	}
+	result := tx.Unscoped().Model(model).Where(key+" = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotInTrash
+	}
+	return nil
+}
+
+// Skipped - Baris yang gagal di-purge (mis. masih direferensikan modul lain)
+type Skipped struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// Result - Hasil purge satu modul
+type Result struct {
+	Module  string    `json:"module"`
+	Purged  []int     `json:"purged"`
+	Skipped []Skipped `json:"skipped"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// purgeModule menghapus permanen baris modul yang dihapus sebelum cutoff. Setiap baris
+// diproses dalam transaksinya sendiri sehingga satu kegagalan tidak membatalkan yang lain.
+func purgeModule(p Purger, cutoff time.Time) Result {
+	res := Result{Module: p.Module, Purged: []int{}, Skipped: []Skipped{}}
+	key, err := primaryKey(db, p.Model)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	var ids []int
+	if err := db.Unscoped().Model(p.Model).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Order(key).Pluck(key, &ids).Error; err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if p.Purge != nil {
+				return p.Purge(tx, id)
+			}
+			return tx.Unscoped().Where(key+" = ?", id).Delete(p.Model).Error
+		})
+		if err != nil {
+			log.Printf("Error purging %s %d: %v", p.Module, id, err)
+			res.Skipped = append(res.Skipped, Skipped{ID: id, Reason: err.Error()})
+			continue
+		}
+		if p.AfterPurge != nil {
+			p.AfterPurge(id)
+		}
+		res.Purged = append(res.Purged, id)
+	}
+	return res
+}
+
+// Purge menghapus permanen baris semua modul yang dihapus sebelum cutoff
+func Purge(cutoff time.Time) []Result {
+	results := make([]Result, 0, len(purgers))
+	for _, p := range purgers {
+		res := purgeModule(p, cutoff)
+		log.Printf("Purged %d %s row(s) deleted before %s, %d skipped", len(res.Purged), p.Module, cutoff.Format(time.RFC3339), len(res.Skipped))
+		results = append(results, res)
+	}
+	return results
+}
+
+// authorized memeriksa header Authorization: Bearer <ADMIN_TOKEN>. Tanpa ADMIN_TOKEN
+// endpoint admin dinonaktifkan.
+func authorized(c *gin.Context) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Endpoint admin nonaktif, atur ADMIN_TOKEN"})
+		return false
+	}
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token admin tidak valid"})
+		return false
+	}
+	return true
+}
+
+// purgeTrash menghapus permanen isi trash semua modul yang lebih lama dari masa retensi
+// (?retention_days= untuk mengganti nilai default)
+func purgeTrash(c *gin.Context) {
+	if !authorized(c) {
+		return
+	}
+	days := RetentionDays()
+	if v := c.Query("retention_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "retention_days harus bilangan bulat >= 0"})
+			return
+		}
+		days = n
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	c.JSON(http.StatusOK, gin.H{
+		"retentionDays": days,
+		"cutoff":        cutoff,
+		"modules":       Purge(cutoff),
+	})
+}
+
+// RegisterRoutes mendaftarkan rute admin trash
+func RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/purge", purgeTrash)
+}
@@ -0,0 +1,20 @@
+// Package upload menyediakan helper bersama untuk file upload yang disimpan di disk lokal
+// (dokumen rekayasa, foto langkah overhaul).
+package upload
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SafeFileName membersihkan nama file upload agar aman dipakai di disk; fallback dipakai
+// jika nama yang tersisa kosong atau hanya "." / ".."
+func SafeFileName(name, fallback string) string {
+	name = unsafeFileChars.ReplaceAllString(filepath.Base(name), "_")
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+	return name
+}
@@ -264,6 +264,7 @@ export default function Inventory() {
     if (status === 'Tidak Tersedia') return 'error';
     if (status === 'Diproduksi') return 'info';
     if (status === 'Perbaikan') return 'default';
+    if (status === 'Unserviceable') return 'error';
     return 'default';
   };
 
@@ -556,6 +557,7 @@ export default function Inventory() {
                   <MenuItem value="Tidak Tersedia">Tidak Tersedia</MenuItem>
                   <MenuItem value="Diproduksi">Diproduksi</MenuItem>
                   <MenuItem value="Perbaikan">Perbaikan</MenuItem>
+                  <MenuItem value="Unserviceable">Unserviceable</MenuItem>
                 </Select>
               </FormControl>
             </Grid>